@@ -59,7 +59,9 @@ func configureAddCmd(rootCmd *cobra.Command, cliArgs *CliArgs) {
 		Use:   "flake",
 		Short: "Mark a test as flaky",
 		Long: "'captain add flake' can be used to mark a test as flaky. To select a test, specify the metadata that " +
-			"uniquely identifies a single test.",
+			"uniquely identifies a single test. The --owner, --reason, --issue, and --expires-at flags document the " +
+			"flake and are not used to identify the test. Using --match glob or --match regex, the identifying values " +
+			"are treated as patterns instead.",
 		Example: `captain add flake --suite-id "example" --file "./test/controller_spec.rb" --description "My test"`,
 		PreRunE: initCLIServiceWithArgs(auxiliaryFlagSet, cliArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -80,8 +82,16 @@ func configureAddCmd(rootCmd *cobra.Command, cliArgs *CliArgs) {
 		Use:   "quarantine",
 		Short: "Quarantine a test in Captain",
 		Long: "'captain add quarantine' can be used to quarantine a test. To select a test, specify the metadata that " +
-			"uniquely identifies a single test.",
-		Example: `captain add quarantine --suite-id "example" --file "./test/controller_spec.rb" --description "My test"`,
+			"uniquely identifies a single test.\n\n" +
+			"The quarantine itself can be documented using the --owner, --reason, and --issue flags. Using " +
+			"--expires-at (an RFC 3339 timestamp or a YYYY-MM-DD date), the quarantine stops applying after the given " +
+			"date. None of these flags are used to identify the test.\n\n" +
+			"Using --match glob or --match regex, the identifying values are treated as patterns instead. This " +
+			"quarantines every test that matches all of them, e.g. a parameterized test or a whole directory.",
+		Example: `captain add quarantine --suite-id "example" --file "./test/controller_spec.rb" --description "My test"` +
+			"\n" + `captain add quarantine --suite-id "example" --description "My test" --owner "platform-team" ` +
+			`--reason "Times out on CI" --issue "JIRA-123" --expires-at "2023-06-01"` +
+			"\n" + `captain add quarantine --suite-id "example" --file "spec/features/**" --match glob`,
 		PreRunE: initCLIServiceWithArgs(auxiliaryFlagSet, cliArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			captain, err := cli.GetService(cmd)
@@ -65,6 +65,11 @@ const (
 	flakesFileName      = "flakes.yaml"
 	quarantinesFileName = "quarantines.yaml"
 	timingsFileName     = "timings.yaml"
+	historyFileName     = "history.yaml"
+	databaseFileName    = "captain.db"
+
+	storageBackendYAML   = "yaml"
+	storageBackendSQLite = "sqlite"
 )
 
 // findInParentDir starts at the current working directory and walk up to the root, trying
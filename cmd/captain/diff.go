@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+func configureDiffCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	var diffConfig cli.DiffConfig
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [flags] <base-results> <head-results>",
+		Short: "Compares two test results",
+		Long: "'captain diff' prints a markdown summary of newly failing, newly flaky, newly passing, added, removed, " +
+			"and considerably slower tests between two test results in RWX v1 JSON (e.g. as written by the " +
+			"'rwx-v1-json' reporter or 'captain parse results'). Tests need to match exactly unless --identity is set.",
+		Example: `  captain diff main.json head.json` + "\n" +
+			`  captain diff --identity description --identity file main.json head.json`,
+		Args:    cobra.ExactArgs(2),
+		PreRunE: unsafeInitParsingOnly(cliArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			args := cliArgs.RootCliArgs.positionalArgs
+
+			captain, err := cli.GetService(cmd)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			return errors.WithStack(captain.Diff(cmd.Context(), args[0], args[1], diffConfig))
+		},
+	}
+
+	diffCmd.Flags().StringSliceVar(&diffConfig.IdentityComponents, "identity", nil,
+		"the components that identify the same test in both test results, e.g. 'description', 'file', 'id', or a "+
+			"meta field. Tests that lack one of them are reported as added or removed")
+
+	rootCmd.AddCommand(diffCmd)
+	return nil
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 
@@ -56,6 +58,7 @@ var genericParsers []parsing.Parser = []parsing.Parser{
 	new(parsing.RWXParser),
 	new(parsing.JUnitTestsuitesParser),
 	new(parsing.JUnitTestsuiteParser),
+	new(parsing.CTRFParser),
 }
 
 var invalidSuiteIDRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
@@ -215,13 +218,27 @@ func makeAPIClient(
 			return nil, err
 		}
 
+		maxRetries := remote.DefaultMaxRetries
+		if cfg.Cloud.Retries != nil {
+			maxRetries = *cfg.Cloud.Retries
+		}
+
 		return wrapError(remote.NewClient(remote.ClientConfig{
-			Debug:    cfg.Output.Debug,
-			Host:     cfg.Cloud.APIHost,
-			Insecure: cfg.Cloud.Insecure,
-			Log:      logger,
-			Token:    cfg.Secrets.APIToken,
-			Provider: provider,
+			Debug:          cfg.Output.Debug,
+			Host:           cfg.Cloud.APIHost,
+			Insecure:       cfg.Cloud.Insecure,
+			Log:            logger,
+			Token:          cfg.Secrets.APIToken,
+			Provider:       provider,
+			SpoolDirectory: cfg.Cloud.SpoolDirectory,
+			CacheDirectory: cfg.Cloud.CacheDirectory,
+			CacheMaxAge:    cfg.Cloud.CacheMaxAge,
+			Timeout:        cfg.Cloud.Timeout,
+			MaxRetries:     maxRetries,
+			Proxy:          cfg.Cloud.Proxy,
+			CACertFile:     cfg.Cloud.CAFile,
+			ClientCertFile: cfg.Cloud.ClientCert,
+			ClientKeyFile:  cfg.Cloud.ClientKey,
 		}))
 	}
 
@@ -239,6 +256,40 @@ func makeAPIClient(
 		logger.Warnf("To start using Captain Cloud, please remove the 'cloud.disabled' setting in the config file.")
 	}
 
+	return wrapError(makeLocalClient(cfg, logger, suiteID))
+}
+
+// makeLocalClient returns a client for the storage backend that is configured under `storage` in the config file.
+func makeLocalClient(cfg Config, logger *zap.SugaredLogger, suiteID string) (local.Client, error) {
+	if err := validateStorageConfig(cfg); err != nil {
+		return local.Client{}, err
+	}
+
+	if cfg.Storage.Backend == storageBackendSQLite {
+		return makeSQLiteClient(cfg, logger, suiteID)
+	}
+
+	return makeYAMLClient(logger, suiteID)
+}
+
+func validateStorageConfig(cfg Config) error {
+	switch cfg.Storage.Backend {
+	case "", storageBackendYAML, storageBackendSQLite:
+		return nil
+	default:
+		return errors.NewConfigurationError(
+			"Unsupported storage backend",
+			fmt.Sprintf("The storage backend %q is not supported.", cfg.Storage.Backend),
+			fmt.Sprintf(
+				"Please set 'storage.backend' to either %q or %q in the config file.",
+				storageBackendYAML, storageBackendSQLite,
+			),
+		)
+	}
+}
+
+// makeYAMLClient returns a client that stores flakes, quarantines, and timings in the YAML files under `.captain`.
+func makeYAMLClient(logger *zap.SugaredLogger, suiteID string) (local.Client, error) {
 	flakesFilePath, err := findInParentDir(filepath.Join(captainDirectory, suiteID, flakesFileName))
 	if err != nil {
 		flakesFilePath = filepath.Join(captainDirectory, suiteID, flakesFileName)
@@ -266,5 +317,32 @@ func makeAPIClient(
 		)
 	}
 
-	return wrapError(local.NewClient(fs.Local{}, flakesFilePath, quarantinesFilePath, timingsFilePath))
+	// The history is only written by Captain itself, which is why it's always kept next to the timings
+	historyFilePath := filepath.Join(filepath.Dir(timingsFilePath), historyFileName)
+
+	client, err := local.NewClient(
+		fs.Local{}, logger, flakesFilePath, quarantinesFilePath, timingsFilePath, historyFilePath,
+	)
+	return client, errors.WithStack(err)
+}
+
+// makeSQLiteClient returns a client that stores flakes, quarantines, timings, and history in a SQLite database. Unless
+// configured otherwise, the database is shared by all suites and located under `.captain`.
+func makeSQLiteClient(cfg Config, logger *zap.SugaredLogger, suiteID string) (local.Client, error) {
+	databasePath := cfg.Storage.Path
+	if databasePath == "" {
+		var err error
+		databasePath, err = findInParentDir(filepath.Join(captainDirectory, databaseFileName))
+		if err != nil {
+			databasePath = filepath.Join(captainDirectory, databaseFileName)
+			logger.Debugf("Unable to find an existing database. Captain will create a new one at %q", databasePath)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(databasePath), 0o755); err != nil {
+		return local.Client{}, errors.NewSystemError("unable to create directory for %q: %s", databasePath, err)
+	}
+
+	client, err := local.NewSQLiteClient(logger, databasePath, suiteID)
+	return client, errors.WithStack(err)
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+type listArgs struct {
+	output      string
+	testResults string
+}
+
+func configureListCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	var lArgs listArgs
+
+	newListSubCmd := func(
+		use, short, long string,
+		list func(cli.Service) func(context.Context, cli.ListConfig) error,
+	) *cobra.Command {
+		return &cobra.Command{
+			Use:     use + " [flags] --suite-id=<suite>",
+			Short:   short,
+			Long:    long,
+			Args:    cobra.NoArgs,
+			PreRunE: initCLIService(cliArgs, noProviderRequired),
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				captain, err := cli.GetService(cmd)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				err = list(captain)(cmd.Context(), cli.ListConfig{
+					Format:              lArgs.output,
+					TestResultsFileGlob: lArgs.testResults,
+				})
+				if _, ok := errors.AsConfigurationError(err); !ok {
+					cmd.SilenceUsage = true
+				}
+
+				return errors.WithStack(err)
+			},
+		}
+	}
+
+	listQuarantinesCmd := newListSubCmd(
+		"quarantines",
+		"Lists the quarantined tests of a test suite",
+		"'captain list quarantines' prints the quarantined tests stored in OSS mode, including their metadata. If "+
+			"test results were stored by a previous run (see 'captain run --update-stored-results'), it also shows "+
+			"whether each quarantine still matches a test, which helps finding stale quarantines.",
+		func(s cli.Service) func(context.Context, cli.ListConfig) error { return s.ListQuarantines },
+	)
+
+	listFlakesCmd := newListSubCmd(
+		"flakes",
+		"Lists the flaky tests of a test suite",
+		"'captain list flakes' prints the flaky tests stored in OSS mode, including their metadata. If test "+
+			"results were stored by a previous run (see 'captain run --update-stored-results'), it also shows whether "+
+			"each flake still matches a test.",
+		func(s cli.Service) func(context.Context, cli.ListConfig) error { return s.ListFlakes },
+	)
+
+	listTimingsCmd := newListSubCmd(
+		"timings",
+		"Lists the test file timings of a test suite",
+		"'captain list timings' prints the test file timings stored in OSS mode and whether each test file "+
+			"still exists.",
+		func(s cli.Service) func(context.Context, cli.ListConfig) error { return s.ListTimings },
+	)
+
+	for _, cmd := range []*cobra.Command{listQuarantinesCmd, listFlakesCmd, listTimingsCmd} {
+		cmd.Flags().StringVarP(&lArgs.output, "output", "o", cli.ListFormatTable,
+			"the output format. One of 'table', 'json', or 'yaml'")
+	}
+
+	for _, cmd := range []*cobra.Command{listQuarantinesCmd, listFlakesCmd} {
+		cmd.Flags().StringVar(&lArgs.testResults, "test-results", "",
+			"a filepath to a test result - supports globs for multiple result files.\n"+
+				"Overrides the test results stored by the latest run")
+		addFrameworkFlags(cmd, &cliArgs.frameworkParams)
+	}
+
+	// listCmd represents the "list" sub-command itself
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"show"},
+		Short:   "Lists resources stored by captain in OSS mode",
+	}
+
+	listCmd.AddCommand(listQuarantinesCmd)
+	listCmd.AddCommand(listFlakesCmd)
+	listCmd.AddCommand(listTimingsCmd)
+	rootCmd.AddCommand(listCmd)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	captainCLI "github.com/rwx-research/captain-cli"
+	"github.com/rwx-research/captain-cli/internal/cli"
 	"github.com/rwx-research/captain-cli/internal/errors"
 )
 
@@ -40,6 +41,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := configureDiffCmd(rootCmd, &cliArgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := configureListCmd(rootCmd, &cliArgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := configureMergeCmd(rootCmd, &cliArgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	if err := configurePartitionCmd(rootCmd, &cliArgs); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -56,6 +72,21 @@ func main() {
 	runCmd.SetUsageTemplate(shortUsageTemplate)
 	rootCmd.AddCommand(runCmd)
 
+	if err := configureSuggestCmd(rootCmd, &cliArgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := configureServerCmd(rootCmd, &cliArgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := configureStorageCmd(rootCmd, &cliArgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	if err := configureUpdateCmd(rootCmd, &cliArgs); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -69,10 +100,29 @@ func main() {
 	// Logging is expected to take place in `internal/cli`, as text output is the primary way of communicating
 	// to a user on the terminal and is therefore one of our main concerns.
 	// This error here is mainly used to communicate any necessary exit Code.
-	if err := rootCmd.Execute(); err != nil {
+	cmd, err := rootCmd.ExecuteC()
+	closeService(cmd)
+	if err != nil {
 		if e, ok := errors.AsExecutionError(err); ok {
 			os.Exit(e.Code)
 		}
 		os.Exit(1)
 	}
 }
+
+// closeService releases the resources of the service of the executed command, if one was set up. Among others, this
+// closes the SQLite database, which checkpoints its write-ahead log into the database file.
+func closeService(cmd *cobra.Command) {
+	if cmd == nil || cmd.Context() == nil {
+		return
+	}
+
+	captain, err := cli.GetService(cmd)
+	if err != nil {
+		return
+	}
+
+	if err := captain.Close(); err != nil {
+		captain.Log.Warnf("Unable to close the storage: %s", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+func configureMergeCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	// mergeTimingsCmd is the "timings" sub-command of "merge".
+	mergeTimingsCmd := &cobra.Command{
+		Use:   "timings [flags] --suite-id=<suite> <args>",
+		Short: "Merges test file timings recorded by multiple partitions",
+		Long: "'captain merge timings' combines the timings files written by 'captain run --update-stored-results' on " +
+			"different partitions into the timings file of the test suite. Only timings that changed compared to the " +
+			"stored timings are merged. If the same test file was updated more than once, the timings file listed last " +
+			"takes precedence.",
+		Example: `  captain merge timings --suite-id="your-project-rspec" partition-*/timings.yaml`,
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: initCLIService(cliArgs, noProviderRequired),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			args := cliArgs.RootCliArgs.positionalArgs
+
+			captain, err := cli.GetService(cmd)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			return errors.WithStack(captain.MergeTimings(cmd.Context(), args))
+		},
+	}
+
+	// mergeCmd represents the "merge" sub-command itself
+	mergeCmd := &cobra.Command{
+		Use:   "merge",
+		Short: "Merges resources of multiple test runs in captain",
+	}
+
+	mergeCmd.AddCommand(mergeTimingsCmd)
+	rootCmd.AddCommand(mergeCmd)
+	return nil
+}
@@ -14,20 +14,45 @@ import (
 type partitionArgs struct {
 	nodes     config.PartitionNodes
 	delimiter string
+	weights   []int
+	matrix    []string
+	exclude   []string
+	filesFrom string
+	selection cli.SelectionConfig
+}
+
+// partitionNodesFromMatrix combines the partitions of multiple dimensions (in the `<index>/<total>` notation) into
+// a single partition.
+func partitionNodesFromMatrix(matrix []string) (config.PartitionNodes, error) {
+	dimensions := make([]config.PartitionNodes, 0, len(matrix))
+
+	for _, rawDimension := range matrix {
+		dimension, err := config.ParsePartitionNodes(rawDimension)
+		if err != nil {
+			return config.PartitionNodes{}, errors.WithStack(err)
+		}
+
+		dimensions = append(dimensions, dimension)
+	}
+
+	return config.CombinePartitionNodes(dimensions), nil
 }
 
 func configurePartitionCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
 	var pArgs partitionArgs
 
 	partitionCmd := &cobra.Command{
-		Use: "partition [--help] [--config-file=<path>] [--delimiter=<delim>] [--sha=<sha>] --suite-id=<suite> --index=<i> " +
-			"--total=<total> <args>",
+		Use: "partition [--help] [--config-file=<path>] [--delimiter=<delim>] [--sha=<sha>] [--exclude=<glob>] " +
+			"[--files-from=<path>] --suite-id=<suite> --index=<i> --total=<total> <args>",
 		Short: "Partitions a test suite using historical file timings recorded by Captain",
 		Long: "'captain partition' can be used to split up your test suite by test file, leveraging test file timings " +
 			"recorded in captain.",
 		Example: "" +
 			"  bundle exec rspec $(captain partition your-project-rspec --index 0 --total 2 spec/**/*_spec.rb)\n" +
-			"  bundle exec rspec $(captain partition your-project-rspec --index 1 --total 2 spec/**/*_spec.rb)",
+			"  bundle exec rspec $(captain partition your-project-rspec --index 1 --total 2 spec/**/*_spec.rb)\n" +
+			"  bundle exec rspec $(captain partition your-project-rspec --index 0 --total 2 spec/**/*_spec.rb " +
+			"--exclude 'spec/**/*_integration_spec.rb')\n" +
+			"  git diff --name-only main -- spec | captain partition your-project-rspec --index 0 --total 2 --files-from -",
 		Args:                  cobra.MinimumNArgs(1),
 		DisableFlagsInUseLine: true,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -46,6 +71,43 @@ func configurePartitionCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
 					return errors.Wrap(err, "failed to construct provider")
 				}
 
+				if suiteConfig, ok := cfg.TestSuites[cliArgs.RootCliArgs.suiteID]; ok {
+					pArgs.selection.AlwaysRun = suiteConfig.Partition.Selection.AlwaysRun
+					pArgs.selection.Rules = suiteConfig.Partition.Selection.Rules
+
+					if pArgs.selection.BaseRef == "" {
+						pArgs.selection.BaseRef = suiteConfig.Partition.Selection.BaseRef
+					}
+
+					// Flags take precedence over the config file, like they do for `captain run`
+					if len(pArgs.exclude) == 0 {
+						pArgs.exclude = suiteConfig.Partition.Exclude
+					}
+
+					if pArgs.filesFrom == "" {
+						pArgs.filesFrom = suiteConfig.Partition.FilesFrom
+					}
+
+					if len(pArgs.weights) == 0 {
+						pArgs.weights = suiteConfig.Partition.Weights
+					}
+				}
+
+				if len(pArgs.matrix) > 0 {
+					if pArgs.nodes.Index >= 0 || pArgs.nodes.Total >= 0 {
+						return errors.NewConfigurationError(
+							"Conflicting partition options",
+							"--matrix cannot be combined with --index or --total.",
+							"Please either specify each dimension of the partition using --matrix, or set the combined "+
+								"partition using --index and --total.",
+						)
+					}
+
+					if pArgs.nodes, err = partitionNodesFromMatrix(pArgs.matrix); err != nil {
+						return errors.WithStack(err)
+					}
+				}
+
 				if pArgs.nodes.Index < 0 {
 					if provider.PartitionNodes.Index < 0 {
 						return errors.NewConfigurationError(
@@ -94,8 +156,12 @@ func configurePartitionCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
 			err = captain.Partition(cmd.Context(), cli.PartitionConfig{
 				SuiteID:        cliArgs.RootCliArgs.suiteID,
 				TestFilePaths:  args,
+				Exclude:        pArgs.exclude,
+				FilesFrom:      pArgs.filesFrom,
+				Selection:      pArgs.selection,
 				PartitionNodes: pArgs.nodes,
 				Delimiter:      pArgs.delimiter,
+				Weights:        pArgs.weights,
 			})
 			return errors.WithStack(err)
 		},
@@ -107,6 +173,22 @@ func configurePartitionCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
 
 	partitionCmd.Flags().IntVar(&pArgs.nodes.Total, "total", -1, "the total number of partitions")
 
+	partitionCmd.Flags().StringArrayVar(&pArgs.exclude, "exclude", []string{},
+		"filepath globs of test files that should not be partitioned")
+
+	partitionCmd.Flags().StringVar(&pArgs.filesFrom, "files-from", "",
+		"a file containing a newline-separated list of test files to partition ('-' reads from stdin)")
+
+	partitionCmd.Flags().StringVar(&pArgs.selection.BaseRef, "changed-since", "",
+		"only partition the test files affected by the changes since the given git ref (e.g. 'origin/main')")
+
+	partitionCmd.Flags().IntSliceVar(&pArgs.weights, "weights", []int{},
+		"the relative capacity of each partition, e.g. '2,1,1' (default: equal capacity)")
+
+	partitionCmd.Flags().StringSliceVar(&pArgs.matrix, "matrix", []string{},
+		"the partition of each dimension of a multi-dimensional split, e.g. '1/2,0/4'.\n"+
+			"The dimensions are combined into a single partition. Cannot be combined with --index or --total.")
+
 	// it's a smell that we're using cliArgs here but I believe it's a major refactor to stop doing that.
 	addShaFlag(partitionCmd, &cliArgs.GenericProvider.Sha)
 
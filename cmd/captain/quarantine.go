@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -44,8 +45,18 @@ func AddQuarantineFlags(rootCmd *cobra.Command, cliArgs *CliArgs) {
 							reporterFuncs[path] = reporting.WriteJSONSummary
 						case "junit-xml":
 							reporterFuncs[path] = reporting.WriteJUnitSummary
+						case "ctrf-json":
+							reporterFuncs[path] = reporting.WriteCTRFSummary
 						case "markdown-summary":
 							reporterFuncs[path] = reporting.WriteMarkdownSummary
+						case "diff-markdown":
+							reporterFuncs[path] = reporting.WriteDiffMarkdownSummary
+						case "timing-report":
+							reporterFuncs[path] = reporting.WriteTimingReport
+						case "text":
+							reporterFuncs[path] = reporting.WriteTextSummary
+						case "html":
+							reporterFuncs[path] = reporting.WriteHTMLSummary
 						case "github-step-summary":
 							stepSummaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
 							if stepSummaryPath == "" {
@@ -57,10 +68,26 @@ func AddQuarantineFlags(rootCmd *cobra.Command, cliArgs *CliArgs) {
 							}
 
 							reporterFuncs[stepSummaryPath] = reporting.WriteMarkdownSummary
+						case "gitlab-code-quality":
+							reporterFuncs[path] = reporting.WriteGitLabCodeQualityReport
+						case "buildkite-annotation":
+							reporterFuncs[path] = buildkiteAnnotationReporter(captain)
 						default:
+							if strings.HasPrefix(name, templateReporterPrefix) {
+								reporter, err := templateReporter(name)
+								if err != nil {
+									return errors.WithStack(err)
+								}
+
+								reporterFuncs[path] = reporter
+								continue
+							}
+
 							return errors.NewConfigurationError(
 								fmt.Sprintf("Unknown reporter %q", name),
-								"Available reporters are 'rwx-v1-json', 'junit-xml', 'markdown-summary', and 'github-step-summary'.",
+								"Available reporters are 'rwx-v1-json', 'junit-xml', 'ctrf-json', 'markdown-summary', "+
+									"'diff-markdown', 'timing-report', 'text', 'html', 'github-step-summary', 'gitlab-code-quality', "+
+									"'buildkite-annotation', and 'template:<path to a Go text/template>'.",
 								"",
 							)
 						}
@@ -70,6 +97,7 @@ func AddQuarantineFlags(rootCmd *cobra.Command, cliArgs *CliArgs) {
 						Args:                args,
 						Command:             suiteConfig.Command,
 						PrintSummary:        suiteConfig.Output.PrintSummary,
+						Progress:            suiteConfig.Output.Progress,
 						Quiet:               suiteConfig.Output.Quiet,
 						Reporters:           reporterFuncs,
 						SuiteID:             cliArgs.RootCliArgs.suiteID,
@@ -128,12 +156,22 @@ func AddQuarantineFlags(rootCmd *cobra.Command, cliArgs *CliArgs) {
 		"prints a summary of all tests to the console",
 	)
 
+	quarantineCmd.Flags().StringVar(
+		&cliArgs.progress,
+		"progress",
+		"",
+		"whether to show a live status line & a compact summary: 'auto' (if stdout is a terminal), 'always', or "+
+			"'never' (default: auto)",
+	)
+
 	quarantineCmd.Flags().StringArrayVar(
 		&cliArgs.reporters,
 		"reporter",
 		[]string{},
 		"one or more `type=output_path` pairs to enable different reporting options.\n"+
-			"Available reporters are 'rwx-v1-json', 'junit-xml', 'markdown-summary', and 'github-step-summary'.",
+			"Available reporters are 'rwx-v1-json', 'junit-xml', 'ctrf-json', 'markdown-summary', "+
+			"'diff-markdown', 'timing-report', 'text', 'html', 'github-step-summary', 'gitlab-code-quality', "+
+			"'buildkite-annotation', and 'template:<path to a Go text/template>'.",
 	)
 
 	quarantineCmd.Flags().BoolVar(
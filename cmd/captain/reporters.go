@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/exec"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// templateReporterPrefix is the prefix of reporters that render a user-defined template, e.g. "template:slack.tmpl"
+const templateReporterPrefix = "template:"
+
+// templateReporter returns a reporter that renders the template referenced by a "template:<path>" reporter name.
+func templateReporter(name string) (cli.Reporter, error) {
+	templatePath := strings.TrimPrefix(name, templateReporterPrefix)
+
+	text, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, errors.NewConfigurationError(
+			fmt.Sprintf("Unable to read report template %q", templatePath),
+			err.Error(),
+			"Please make sure that the path after 'template:' points to a readable file.",
+		)
+	}
+
+	reporter, err := reporting.NewTemplateReporter(templatePath, string(text))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return reporter, nil
+}
+
+// buildkiteAnnotationReporter writes a Buildkite annotation to the output path. When running on Buildkite, the
+// annotation is also added to the build directly if the `buildkite-agent` is available.
+func buildkiteAnnotationReporter(captain cli.Service) cli.Reporter {
+	return func(file fs.File, testResults v1.TestResults, cfg reporting.Configuration) error {
+		if err := reporting.WriteBuildkiteAnnotation(file, testResults, cfg); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if os.Getenv("BUILDKITE") != "true" {
+			return nil
+		}
+
+		agent, err := osexec.LookPath("buildkite-agent")
+		if err != nil {
+			captain.Log.Debugf("Skipping the Buildkite annotation (%s)", err)
+			return nil
+		}
+
+		// The annotation can be up to 1MB, which is too large for a command-line argument
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return errors.WithStack(err)
+		}
+
+		cmd, err := captain.TaskRunner.NewCommand(context.Background(), exec.CommandConfig{
+			Name: agent,
+			Args: []string{
+				"annotate",
+				"--context", "captain-" + cfg.SuiteID,
+				"--style", reporting.BuildkiteAnnotationStyle(testResults),
+			},
+			Stdin:  file,
+			Stderr: os.Stderr,
+			Stdout: io.Discard,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := cmd.Start(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.Wrap(cmd.Wait(), "unable to annotate the Buildkite build")
+	}
+}
@@ -18,29 +18,38 @@ import (
 )
 
 type CliArgs struct {
-	command                   string
-	testResults               string
-	failOnUploadError         bool
-	failRetriesFast           bool
-	flakyRetries              int
-	intermediateArtifactsPath string
-	maxTestsToRetry           string
-	postRetryCommands         []string
-	preRetryCommands          []string
-	printSummary              bool
-	quiet                     bool
-	reporters                 []string
-	Retries                   int
-	retryCommandTemplate      string
-	updateStoredResults       bool
-	GenericProvider           providers.GenericEnv
-	frameworkParams           frameworkParams
-	RootCliArgs               rootCliArgs
-	partitionIndex            int
-	partitionTotal            int
-	partitionDelimiter        string
-	partitionCommandTemplate  string
-	partitionGlobs            []string
+	command                     string
+	testResults                 string
+	durationRegressionThreshold float64
+	failOnDurationRegression    bool
+	failOnUploadError           bool
+	failRetriesFast             bool
+	flakyRetries                int
+	intermediateArtifactsPath   string
+	maxTestsToRetry             string
+	postRetryCommands           []string
+	preRetryCommands            []string
+	printSummary                bool
+	progress                    string
+	quiet                       bool
+	reporters                   []string
+	Retries                     int
+	retryCommandTemplate        string
+	updateStoredResults         bool
+	GenericProvider             providers.GenericEnv
+	frameworkParams             frameworkParams
+	RootCliArgs                 rootCliArgs
+	partitionIndex              int
+	partitionTotal              int
+	partitionDelimiter          string
+	partitionCommandTemplate    string
+	partitionGlobs              []string
+	partitionExclude            []string
+	partitionFilesFrom          string
+	partitionWeights            []int
+	selectChangedSince          string
+	partitionMatrix             []string
+	quarantineMode              string
 }
 
 func createRunCmd(cliArgs *CliArgs) *cobra.Command {
@@ -75,8 +84,18 @@ func createRunCmd(cliArgs *CliArgs) *cobra.Command {
 							reporterFuncs[path] = reporting.WriteJSONSummary
 						case "junit-xml":
 							reporterFuncs[path] = reporting.WriteJUnitSummary
+						case "ctrf-json":
+							reporterFuncs[path] = reporting.WriteCTRFSummary
 						case "markdown-summary":
 							reporterFuncs[path] = reporting.WriteMarkdownSummary
+						case "diff-markdown":
+							reporterFuncs[path] = reporting.WriteDiffMarkdownSummary
+						case "timing-report":
+							reporterFuncs[path] = reporting.WriteTimingReport
+						case "text":
+							reporterFuncs[path] = reporting.WriteTextSummary
+						case "html":
+							reporterFuncs[path] = reporting.WriteHTMLSummary
 						case "github-step-summary":
 							stepSummaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
 							if stepSummaryPath == "" {
@@ -88,10 +107,26 @@ func createRunCmd(cliArgs *CliArgs) *cobra.Command {
 							}
 
 							reporterFuncs[stepSummaryPath] = reporting.WriteMarkdownSummary
+						case "gitlab-code-quality":
+							reporterFuncs[path] = reporting.WriteGitLabCodeQualityReport
+						case "buildkite-annotation":
+							reporterFuncs[path] = buildkiteAnnotationReporter(captain)
 						default:
+							if strings.HasPrefix(name, templateReporterPrefix) {
+								reporter, err := templateReporter(name)
+								if err != nil {
+									return errors.WithStack(err)
+								}
+
+								reporterFuncs[path] = reporter
+								continue
+							}
+
 							return errors.NewConfigurationError(
 								fmt.Sprintf("Unknown reporter %q", name),
-								"Available reporters are 'rwx-v1-json', 'junit-xml', 'markdown-summary', and 'github-step-summary'.",
+								"Available reporters are 'rwx-v1-json', 'junit-xml', 'ctrf-json', 'markdown-summary', "+
+									"'diff-markdown', 'timing-report', 'text', 'html', 'github-step-summary', 'gitlab-code-quality', "+
+									"'buildkite-annotation', and 'template:<path to a Go text/template>'.",
 								"",
 							)
 						}
@@ -104,6 +139,25 @@ func createRunCmd(cliArgs *CliArgs) *cobra.Command {
 						return errors.Wrap(err, "failed to construct provider")
 					}
 
+					if len(cliArgs.partitionMatrix) > 0 {
+						if partitionIndex >= 0 || partitionTotal >= 0 {
+							return errors.NewConfigurationError(
+								"Conflicting partition options",
+								"--partition-matrix cannot be combined with --partition-index or --partition-total.",
+								"Please either specify each dimension of the partition using --partition-matrix, or set the "+
+									"combined partition using --partition-index and --partition-total.",
+							)
+						}
+
+						nodes, err := partitionNodesFromMatrix(cliArgs.partitionMatrix)
+						if err != nil {
+							return errors.WithStack(err)
+						}
+
+						partitionIndex = nodes.Index
+						partitionTotal = nodes.Total
+					}
+
 					if partitionIndex < 0 {
 						partitionIndex = provider.PartitionNodes.Index
 					}
@@ -113,34 +167,46 @@ func createRunCmd(cliArgs *CliArgs) *cobra.Command {
 					}
 
 					runConfig = cli.RunConfig{
-						Args:                      args,
-						Command:                   suiteConfig.Command,
-						FailOnUploadError:         suiteConfig.FailOnUploadError,
-						FailRetriesFast:           suiteConfig.Retries.FailFast,
-						FlakyRetries:              suiteConfig.Retries.FlakyAttempts,
-						IntermediateArtifactsPath: suiteConfig.Retries.IntermediateArtifactsPath,
-						MaxTestsToRetry:           suiteConfig.Retries.MaxTests,
-						PostRetryCommands:         suiteConfig.Retries.PostRetryCommands,
-						PreRetryCommands:          suiteConfig.Retries.PreRetryCommands,
-						PrintSummary:              suiteConfig.Output.PrintSummary,
-						Quiet:                     suiteConfig.Output.Quiet,
-						Reporters:                 reporterFuncs,
-						Retries:                   suiteConfig.Retries.Attempts,
-						RetryCommandTemplate:      suiteConfig.Retries.Command,
-						SubstitutionsByFramework:  targetedretries.SubstitutionsByFramework,
-						SuiteID:                   cliArgs.RootCliArgs.suiteID,
-						TestResultsFileGlob:       os.ExpandEnv(suiteConfig.Results.Path),
-						UpdateStoredResults:       cliArgs.updateStoredResults,
-						UploadResults:             true,
-						PartitionCommandTemplate:  suiteConfig.Partition.Command,
+						Args:                        args,
+						Command:                     suiteConfig.Command,
+						DurationRegressionThreshold: suiteConfig.DurationRegressionThreshold,
+						FailOnDurationRegression:    suiteConfig.FailOnDurationRegression,
+						FailOnUploadError:           suiteConfig.FailOnUploadError,
+						FailRetriesFast:             suiteConfig.Retries.FailFast,
+						FlakyRetries:                suiteConfig.Retries.FlakyAttempts,
+						IntermediateArtifactsPath:   suiteConfig.Retries.IntermediateArtifactsPath,
+						MaxTestsToRetry:             suiteConfig.Retries.MaxTests,
+						PostRetryCommands:           suiteConfig.Retries.PostRetryCommands,
+						PreRetryCommands:            suiteConfig.Retries.PreRetryCommands,
+						PrintSummary:                suiteConfig.Output.PrintSummary,
+						Progress:                    suiteConfig.Output.Progress,
+						Quiet:                       suiteConfig.Output.Quiet,
+						Reporters:                   reporterFuncs,
+						Retries:                     suiteConfig.Retries.Attempts,
+						RetryCommandTemplate:        suiteConfig.Retries.Command,
+						SubstitutionsByFramework:    targetedretries.SubstitutionsByFramework,
+						SuiteID:                     cliArgs.RootCliArgs.suiteID,
+						TestResultsFileGlob:         os.ExpandEnv(suiteConfig.Results.Path),
+						UpdateStoredResults:         cliArgs.updateStoredResults,
+						UploadResults:               true,
+						PartitionCommandTemplate:    suiteConfig.Partition.Command,
+						QuarantineMode:              suiteConfig.Quarantine.Mode,
 						PartitionConfig: cli.PartitionConfig{
 							SuiteID:       cliArgs.RootCliArgs.suiteID,
 							TestFilePaths: suiteConfig.Partition.Globs,
+							Exclude:       suiteConfig.Partition.Exclude,
+							FilesFrom:     suiteConfig.Partition.FilesFrom,
 							PartitionNodes: config.PartitionNodes{
 								Index: partitionIndex,
 								Total: partitionTotal,
 							},
 							Delimiter: suiteConfig.Partition.Delimiter,
+							Weights:   suiteConfig.Partition.Weights,
+							Selection: cli.SelectionConfig{
+								BaseRef:   suiteConfig.Partition.Selection.BaseRef,
+								AlwaysRun: suiteConfig.Partition.Selection.AlwaysRun,
+								Rules:     suiteConfig.Partition.Selection.Rules,
+							},
 						},
 					}
 				}
@@ -183,11 +249,33 @@ func AddFlags(runCmd *cobra.Command, cliArgs *CliArgs) error {
 		"return a non-zero exit code in case the test results upload fails",
 	)
 
+	runCmd.Flags().BoolVar(
+		&cliArgs.failOnDurationRegression,
+		"fail-on-duration-regression",
+		false,
+		"return a non-zero exit code if test files took considerably longer than their stored timings (see "+
+			"'--duration-regression-threshold'). Requires the timings that are stored in OSS mode.",
+	)
+
+	runCmd.Flags().Float64Var(
+		&cliArgs.durationRegressionThreshold,
+		"duration-regression-threshold",
+		0,
+		fmt.Sprintf(
+			"how much longer than before (in percent) a test file needs to take in order to count as a duration "+
+				"regression. Defaults to %v. Test files also need to take at least %v longer, which keeps noise "+
+				"from fast tests out.",
+			reporting.DefaultDurationRegressionThreshold, reporting.DurationRegressionMinIncrease,
+		),
+	)
+
 	runCmd.Flags().StringVar(
 		&cliArgs.intermediateArtifactsPath,
 		"intermediate-artifacts-path",
 		"",
-		"the path to store intermediate artifacts under. Intermediate artifacts will be removed if not set.",
+		"the path to store intermediate artifacts under. Intermediate artifacts will be removed if not set.\n"+
+			"Attachments of tests (e.g. screenshots) are only kept for every attempt if this is set, as frameworks\n"+
+			"like Playwright remove them before retrying.",
 	)
 
 	runCmd.Flags().StringArrayVar(
@@ -211,12 +299,24 @@ func AddFlags(runCmd *cobra.Command, cliArgs *CliArgs) error {
 		"prints a summary of all tests to the console",
 	)
 
+	runCmd.Flags().StringVar(
+		&cliArgs.progress,
+		"progress",
+		"",
+		"whether to show a live status line & to print the summary of '--print-summary' in a compact form: "+
+			"'auto' (if stdout is a terminal), 'always', or 'never' (default: auto).\n"+
+			"The status line is drawn in the bottom row of the terminal, while the command keeps writing to the\n"+
+			"terminal directly.",
+	)
+
 	runCmd.Flags().StringArrayVar(
 		&cliArgs.reporters,
 		"reporter",
 		[]string{},
 		"one or more `type=output_path` pairs to enable different reporting options.\n"+
-			"Available reporters are 'rwx-v1-json', 'junit-xml', 'markdown-summary', and 'github-step-summary'.",
+			"Available reporters are 'rwx-v1-json', 'junit-xml', 'ctrf-json', 'markdown-summary', "+
+			"'diff-markdown', 'timing-report', 'text', 'html', 'github-step-summary', 'gitlab-code-quality', "+
+			"'buildkite-annotation', and 'template:<path to a Go text/template>'.",
 	)
 
 	runCmd.Flags().IntVar(
@@ -282,6 +382,57 @@ func AddFlags(runCmd *cobra.Command, cliArgs *CliArgs) error {
 		"Filepath globs used to identify the test files you wish to partition",
 	)
 
+	runCmd.Flags().StringArrayVar(
+		&cliArgs.partitionExclude,
+		"partition-exclude",
+		[]string{},
+		"Filepath globs used to identify test files that should not be partitioned (and therefore not run)",
+	)
+
+	runCmd.Flags().StringVar(
+		&cliArgs.partitionFilesFrom,
+		"partition-files-from",
+		"",
+		"A file containing a newline-separated list of test files to partition, in addition to --partition-globs.\n"+
+			"Use '-' to read the list from stdin. Blank lines, lines starting with '#', and missing files are ignored.",
+	)
+
+	runCmd.Flags().StringVar(
+		&cliArgs.selectChangedSince,
+		"select-changed-since",
+		"",
+		"Only partition the test files affected by the changes since the given git ref (e.g. 'origin/main').\n"+
+			"Changed files are mapped to test files using the selection rules of the suite configuration. The full\n"+
+			"suite is run if a changed file doesn't map to any test files.",
+	)
+
+	runCmd.Flags().StringVar(
+		&cliArgs.quarantineMode,
+		"quarantine-mode",
+		"",
+		"How to handle quarantined tests. 'run' (the default) runs them and ignores their failures, 'skip' excludes\n"+
+			"them from the run by substituting the '{{ quarantineExclusions }}' keyword of the command with the\n"+
+			"framework's exclusion arguments. Skipped tests are reported as quarantined. Quarantined tests that the\n"+
+			"framework can't select exactly (e.g. glob or regex quarantines) still run.",
+	)
+
+	runCmd.Flags().IntSliceVar(
+		&cliArgs.partitionWeights,
+		"partition-weights",
+		[]int{},
+		"The relative capacity of each partition, e.g. '2,1,1' for a runner that is twice as fast as the other two.\n"+
+			"Requires one weight per partition. By default, all partitions have the same capacity.",
+	)
+
+	runCmd.Flags().StringSliceVar(
+		&cliArgs.partitionMatrix,
+		"partition-matrix",
+		[]string{},
+		"The partition of each dimension of a multi-dimensional split in the '<index>/<total>' notation, e.g.\n"+
+			"'--partition-matrix 1/2,0/4'. The dimensions are combined into a single partition, with the first\n"+
+			"dimension being the most significant one. Cannot be combined with --partition-index or --partition-total.",
+	)
+
 	runCmd.Flags().StringVar(
 		&cliArgs.partitionCommandTemplate,
 		"partition-command",
@@ -289,6 +440,7 @@ func AddFlags(runCmd *cobra.Command, cliArgs *CliArgs) error {
 		fmt.Sprintf(
 			"The command that will be run to execute a subset of your tests while partitioning\n"+
 				"(required if --partition-index or --partition-total is passed)\n"+
+				"Available keywords: testFiles, testFilesPath, testFilesJSON, partitionIndex, partitionTotal\n"+
 				"Examples:\n  Custom: --partition-command \"%v\"",
 			runpartition.DelimiterSubstitution{}.Example(),
 		),
@@ -353,6 +505,14 @@ func bindRunCmdFlags(cfg Config, cliArgs CliArgs) Config {
 			suiteConfig.FailOnUploadError = true
 		}
 
+		if cliArgs.failOnDurationRegression {
+			suiteConfig.FailOnDurationRegression = true
+		}
+
+		if cliArgs.durationRegressionThreshold != 0 {
+			suiteConfig.DurationRegressionThreshold = cliArgs.durationRegressionThreshold
+		}
+
 		if cliArgs.testResults != "" {
 			suiteConfig.Results.Path = cliArgs.testResults
 		}
@@ -382,6 +542,10 @@ func bindRunCmdFlags(cfg Config, cliArgs CliArgs) Config {
 			suiteConfig.Output.PrintSummary = true
 		}
 
+		if cliArgs.progress != "" {
+			suiteConfig.Output.Progress = cliArgs.progress
+		}
+
 		if cliArgs.quiet {
 			suiteConfig.Output.Quiet = true
 		}
@@ -424,6 +588,26 @@ func bindRunCmdFlags(cfg Config, cliArgs CliArgs) Config {
 			suiteConfig.Partition.Globs = cliArgs.partitionGlobs
 		}
 
+		if len(cliArgs.partitionExclude) != 0 {
+			suiteConfig.Partition.Exclude = cliArgs.partitionExclude
+		}
+
+		if cliArgs.partitionFilesFrom != "" {
+			suiteConfig.Partition.FilesFrom = cliArgs.partitionFilesFrom
+		}
+
+		if cliArgs.selectChangedSince != "" {
+			suiteConfig.Partition.Selection.BaseRef = cliArgs.selectChangedSince
+		}
+
+		if len(cliArgs.partitionWeights) != 0 {
+			suiteConfig.Partition.Weights = cliArgs.partitionWeights
+		}
+
+		if cliArgs.quarantineMode != "" {
+			suiteConfig.Quarantine.Mode = cliArgs.quarantineMode
+		}
+
 		cfg.TestSuites[cliArgs.RootCliArgs.suiteID] = suiteConfig
 
 		cfg.ProvidersEnv.Generic = providers.MergeGeneric(cfg.ProvidersEnv.Generic, cliArgs.GenericProvider)
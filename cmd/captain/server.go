@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/logging"
+	"github.com/rwx-research/captain-cli/internal/server"
+)
+
+type serverArgs struct {
+	address  string
+	token    string
+	certFile string
+	keyFile  string
+}
+
+func configureServerCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	var args serverArgs
+
+	serverCmd := &cobra.Command{
+		Use:   "server [flags]",
+		Short: "Serves the Captain API on top of the local storage",
+		Long: "'captain server' is a self-hosted backend for teams that don't use Captain Cloud. It implements the " +
+			"parts of the Captain API that the CLI relies on and stores flakes, quarantines, timings, and test " +
+			"results in the local storage of the server (see 'captain storage'). Flakes & quarantines are managed " +
+			"on the server using 'captain add' & 'captain remove'.\n\n" +
+			"CI nodes share the storage of the server by setting CAPTAIN_HOST to its address and RWX_ACCESS_TOKEN " +
+			"to the token set using --token. If the server doesn't use TLS, 'cloud.insecure' needs to be set in the " +
+			"config file of the CI nodes as well.",
+		Example: `  CAPTAIN_SERVER_TOKEN="secret" captain server --address ":8080"` + "\n" +
+			`  captain server --address ":8443" --tls-cert server.crt --tls-key server.key`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			err := func() error {
+				cfg, err := InitConfig(cmd, *cliArgs)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				logger := logging.NewProductionLogger()
+				if cfg.Output.Debug {
+					logger = logging.NewDebugLogger()
+				}
+
+				// Fail early on an invalid storage configuration rather than on the first request
+				if err := validateStorageConfig(cfg); err != nil {
+					return errors.WithStack(err)
+				}
+
+				if (args.certFile == "") != (args.keyFile == "") {
+					return errors.NewConfigurationError(
+						"Incomplete TLS configuration",
+						"Only one of --tls-cert and --tls-key was set.",
+						"Please set both flags to enable TLS, or neither of them to serve plain HTTP.",
+					)
+				}
+
+				if args.token == "" {
+					logger.Warnf("No --token was set. The server will accept requests from anyone that can reach it.")
+				}
+
+				s, err := server.New(server.Config{
+					Log: logger,
+					Storage: func(suiteID string) (local.Client, error) {
+						return makeLocalClient(cfg, logger, suiteID)
+					},
+					Token: args.token,
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				cmd.SilenceUsage = true
+				logger.Infof("Serving the Captain API on %s", args.address)
+				return errors.WithStack(s.ListenAndServe(ctx, args.address, args.certFile, args.keyFile))
+			}()
+			if err != nil {
+				return errors.WithDecoration(err)
+			}
+			return nil
+		},
+	}
+
+	serverCmd.Flags().StringVar(&args.address, "address", ":8080", "the address to listen on")
+	serverCmd.Flags().StringVar(&args.token, "token", os.Getenv("CAPTAIN_SERVER_TOKEN"),
+		"the access token that clients need to present. Also set with environment variable CAPTAIN_SERVER_TOKEN")
+	serverCmd.Flags().StringVar(&args.certFile, "tls-cert", "", "the TLS certificate to serve HTTPS with")
+	serverCmd.Flags().StringVar(&args.keyFile, "tls-key", "", "the private key of the TLS certificate")
+
+	rootCmd.AddCommand(serverCmd)
+	return nil
+}
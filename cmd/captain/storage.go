@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+func configureStorageCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	newStorageSubCmd := func(
+		use, short, long string,
+		transfer func(cli.Service) func(context.Context, local.Client) error,
+	) *cobra.Command {
+		return &cobra.Command{
+			Use:     use + " [flags] --suite-id=<suite>",
+			Short:   short,
+			Long:    long,
+			Args:    cobra.NoArgs,
+			PreRunE: initCLIService(cliArgs, noProviderRequired),
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				captain, err := cli.GetService(cmd)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				yamlStorage, err := makeYAMLClient(captain.Log, cliArgs.RootCliArgs.suiteID)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				err = transfer(captain)(cmd.Context(), yamlStorage)
+				if _, ok := errors.AsConfigurationError(err); !ok {
+					cmd.SilenceUsage = true
+				}
+
+				return errors.WithStack(err)
+			},
+		}
+	}
+
+	storageImportCmd := newStorageSubCmd(
+		"import",
+		"Imports the YAML files of a suite into the SQLite database",
+		"'captain storage import' copies the flakes, quarantines, timings, and history of a suite from its YAML "+
+			"files under '.captain' into the SQLite database. Flakes & quarantines in the database are replaced, "+
+			"timings are merged, and only runs that are newer than the latest run in the database are added.",
+		func(s cli.Service) func(context.Context, local.Client) error { return s.ImportStorage },
+	)
+
+	storageExportCmd := newStorageSubCmd(
+		"export",
+		"Exports the SQLite database of a suite to YAML files",
+		"'captain storage export' writes the flakes, quarantines, timings, and history of a suite from the SQLite "+
+			"database to its YAML files under '.captain', e.g. in order to commit them. Flakes & quarantines in the "+
+			"YAML files are replaced, timings are merged, and only runs that are newer than the latest run in the "+
+			"history file are added.",
+		func(s cli.Service) func(context.Context, local.Client) error { return s.ExportStorage },
+	)
+
+	// storageCmd represents the "storage" sub-command itself
+	storageCmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Manages the local storage of captain in OSS mode",
+		Long: "In OSS mode, Captain stores flakes, quarantines, timings, and history in YAML files under '.captain' " +
+			"by default. Setting 'storage.backend' to 'sqlite' in the config file stores them in a SQLite database " +
+			"instead ('.captain/captain.db' unless 'storage.path' is set). The sub-commands of 'captain storage' " +
+			"copy data between the two.",
+	}
+
+	storageCmd.AddCommand(storageImportCmd)
+	storageCmd.AddCommand(storageExportCmd)
+	rootCmd.AddCommand(storageCmd)
+	return nil
+}
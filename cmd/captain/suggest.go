@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+func configureSuggestCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	var suggestConfig cli.SuggestConfig
+
+	newSuggestSubCmd := func(
+		use, short, long string,
+		suggest func(cli.Service) func(context.Context, cli.SuggestConfig) error,
+	) *cobra.Command {
+		cmd := &cobra.Command{
+			Use:     use + " [flags] --suite-id=<suite>",
+			Short:   short,
+			Long:    long,
+			Args:    cobra.NoArgs,
+			PreRunE: initCLIService(cliArgs, noProviderRequired),
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				captain, err := cli.GetService(cmd)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				err = suggest(captain)(cmd.Context(), suggestConfig)
+				if _, ok := errors.AsConfigurationError(err); !ok {
+					cmd.SilenceUsage = true
+				}
+
+				return errors.WithStack(err)
+			},
+		}
+
+		cmd.Flags().IntVar(&suggestConfig.MinFlakes, "min-flakes", 2,
+			"the number of times a test needs to have flaked in order to be suggested")
+		cmd.Flags().IntVar(&suggestConfig.Uploads, "uploads", 20,
+			"the number of most recent uploads of test results to take into account. Partitioned runs upload the "+
+				"results of every partition separately")
+		cmd.Flags().BoolVar(&suggestConfig.Apply, "apply", false, "write the suggested entries instead of printing them")
+
+		return cmd
+	}
+
+	suggestFlakesCmd := newSuggestSubCmd(
+		"flakes",
+		"Suggests tests to mark as flaky",
+		"'captain suggest flakes' proposes flakes based on the tests that flaked (i.e. failed and then passed on a "+
+			"retry) in recent uploads of test results recorded in OSS mode. Tests are identified using the identity "+
+			"components that suit their test framework. Using --apply, the suggestions are added to the flakes file.",
+		func(s cli.Service) func(context.Context, cli.SuggestConfig) error { return s.SuggestFlakes },
+	)
+
+	suggestQuarantinesCmd := newSuggestSubCmd(
+		"quarantines",
+		"Suggests tests to quarantine",
+		"'captain suggest quarantines' proposes quarantines based on the tests that flaked (i.e. failed and then "+
+			"passed on a retry) in recent uploads of test results recorded in OSS mode. Tests are identified using the "+
+			"identity components that suit their test framework. Using --apply, the suggestions are added to the "+
+			"quarantines file.",
+		func(s cli.Service) func(context.Context, cli.SuggestConfig) error { return s.SuggestQuarantines },
+	)
+
+	// suggestCmd represents the "suggest" sub-command itself
+	suggestCmd := &cobra.Command{
+		Use:   "suggest",
+		Short: "Suggests flakes or quarantines based on recent test results in OSS mode",
+	}
+
+	suggestCmd.AddCommand(suggestFlakesCmd)
+	suggestCmd.AddCommand(suggestQuarantinesCmd)
+	rootCmd.AddCommand(suggestCmd)
+	return nil
+}
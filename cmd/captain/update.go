@@ -11,17 +11,46 @@ import (
 )
 
 func configureUpdateCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
+	var replaySpool bool
+
 	// updateResultsCmd is the "results" sub-command of "update".
 	updateResultsCmd := &cobra.Command{
 		Use:   "results [flags] --suite-id=<suite> <args>",
 		Short: "Updates captain with new test-results",
 		Long: "'captain update results' will parse a test-results file and updates captain's internal storage " +
-			"accordingly.",
-		Example: `captain update results --suite-id="JUnit" *.xml`,
-		Args:    cobra.MinimumNArgs(1),
-		PreRunE: initCLIService(cliArgs, providers.Validate),
+			"accordingly.\n\n" +
+			"If 'cloud.spool-directory' is set in the config file, test results that couldn't be uploaded are kept " +
+			"in that directory. 'captain update results --replay-spool' re-sends them on behalf of the original run.",
+		Example: `  captain update results --suite-id="JUnit" *.xml` + "\n" +
+			`  captain update results --suite-id="JUnit" --replay-spool`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if replaySpool {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		PreRunE: initCLIService(cliArgs, func(provider providers.Provider) error {
+			// Spooled uploads are sent with the provider metadata of the run that produced them
+			if replaySpool {
+				return nil
+			}
+			return providers.Validate(provider)
+		}),
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			args := cliArgs.RootCliArgs.positionalArgs
+
+			if replaySpool {
+				if len(args) > 0 {
+					return errors.NewInputError("--replay-spool can't be combined with test results files")
+				}
+
+				captain, err := cli.GetService(cmd)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				return errors.WithStack(captain.ReplaySpool(cmd.Context(), cliArgs.RootCliArgs.suiteID))
+			}
+
 			// TODO: Should also support reading from stdin
 			artifacts := args
 
@@ -58,6 +87,9 @@ func configureUpdateCmd(rootCmd *cobra.Command, cliArgs *CliArgs) error {
 		return errors.WithStack(err)
 	}
 
+	updateResultsCmd.Flags().BoolVar(&replaySpool, "replay-spool", false,
+		"re-send the spooled test results of the suite instead of parsing test results files")
+
 	addFrameworkFlags(updateResultsCmd, &cliArgs.frameworkParams)
 
 	// updateCmd represents the "update" sub-command itself
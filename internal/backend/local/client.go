@@ -2,11 +2,13 @@ package local
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"io"
 	"os"
 	"time"
 
+	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 
 	"github.com/rwx-research/captain-cli/internal/backend"
@@ -18,6 +20,7 @@ import (
 
 type Client struct {
 	fs              fs.FileSystem
+	log             *zap.SugaredLogger
 	Flakes          []yaml.Node
 	flakesPath      string
 	Quarantines     []yaml.Node
@@ -25,15 +28,27 @@ type Client struct {
 	quarantinesTime time.Time
 	Timings         map[string]time.Duration
 	timingsPath     string
+	historyPath     string
+	// partition keeps the stored results of concurrent partitions apart, see `WithPartition`
+	partition string
+	// db & suiteID are only set if the client is backed by a SQLite database, see `NewSQLiteClient`
+	db      *sql.DB
+	suiteID string
 }
 
-func NewClient(fileSystem fs.FileSystem, flakesPath, quarantinesPath, timingsPath string) (Client, error) {
+func NewClient(
+	fileSystem fs.FileSystem,
+	log *zap.SugaredLogger,
+	flakesPath, quarantinesPath, timingsPath, historyPath string,
+) (Client, error) {
 	c := Client{
 		fs:              fileSystem,
+		log:             log,
 		flakesPath:      flakesPath,
 		quarantinesPath: quarantinesPath,
 		Timings:         make(map[string]time.Duration),
 		timingsPath:     timingsPath,
+		historyPath:     historyPath,
 	}
 
 	openOrCreate := func(path string, v any) (fs.File, error) {
@@ -102,26 +117,119 @@ func NewClient(fileSystem fs.FileSystem, flakesPath, quarantinesPath, timingsPat
 }
 
 func (c Client) Flush() error {
-	write := func(filepath string, data any) error {
-		file, err := c.fs.OpenFile(filepath, os.O_WRONLY|os.O_TRUNC, 0)
-		if err != nil {
-			return errors.NewSystemError("unable to open %q: %s", filepath, err)
-		}
-		defer file.Close()
+	if c.db != nil {
+		return c.flushDatabase()
+	}
+
+	if err := c.write(c.flakesPath, c.Flakes); err != nil {
+		return err
+	}
+
+	return c.write(c.quarantinesPath, c.Quarantines)
+}
+
+// AddFlakes appends `entries` to the stored flakes. Unlike `Flush`, this leaves any other stored flakes untouched,
+// which means that concurrent updates aren't lost.
+func (c Client) AddFlakes(entries ...yaml.Node) error {
+	return c.addEntries(entryKindFlake, entries)
+}
+
+// AddQuarantines appends `entries` to the stored quarantines, see `AddFlakes`.
+func (c Client) AddQuarantines(entries ...yaml.Node) error {
+	return c.addEntries(entryKindQuarantine, entries)
+}
 
-		encoder := yaml.NewEncoder(file)
-		if err := encoder.Encode(data); err != nil {
-			return errors.NewSystemError("unable to write to %q: %s", filepath, err)
+// RemoveFlakes removes all stored flakes of the test with the given identity. Any other stored flakes are left
+// untouched, which means that concurrent updates aren't lost.
+func (c Client) RemoveFlakes(identity Map) error {
+	return c.removeEntries(entryKindFlake, identity)
+}
+
+// RemoveQuarantines removes all stored quarantines of the test with the given identity, see `RemoveFlakes`.
+func (c Client) RemoveQuarantines(identity Map) error {
+	return c.removeEntries(entryKindQuarantine, identity)
+}
+
+func (c Client) addEntries(kind string, entries []yaml.Node) error {
+	if c.db != nil {
+		return c.addDatabaseEntries(kind, entries)
+	}
+
+	return c.updateEntriesFile(kind, func(current []yaml.Node) []yaml.Node {
+		return append(current, entries...)
+	})
+}
+
+func (c Client) removeEntries(kind string, identity Map) error {
+	if c.db != nil {
+		return c.removeDatabaseEntries(kind, identity)
+	}
+
+	return c.updateEntriesFile(kind, func(current []yaml.Node) []yaml.Node {
+		remaining := make([]yaml.Node, 0, len(current))
+		for _, entry := range current {
+			if !identity.Equals(NewMapFromYAML(entry)) {
+				remaining = append(remaining, entry)
+			}
 		}
+		return remaining
+	})
+}
 
-		return nil
+// updateEntriesFile re-reads the flakes or quarantines file while holding its lock & writes back the result of
+// `update`, which means that entries added or removed by another process in the meantime are kept as-is.
+func (c Client) updateEntriesFile(kind string, update func([]yaml.Node) []yaml.Node) error {
+	filepath := c.flakesPath
+	if kind == entryKindQuarantine {
+		filepath = c.quarantinesPath
 	}
 
-	if err := write(c.flakesPath, c.Flakes); err != nil {
-		return err
+	unlock, err := c.lock(filepath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer unlock()
+
+	file, err := c.fs.Open(filepath)
+	if err != nil {
+		return errors.NewSystemError("unable to open %q: %s", filepath, err)
 	}
 
-	return write(c.quarantinesPath, c.Quarantines)
+	entries := make([]yaml.Node, 0)
+	err = yaml.NewDecoder(file).Decode(&entries)
+	_ = file.Close()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return errors.NewInputError("unable to parse %q: %s", filepath, err)
+	}
+
+	return c.encode(filepath, update(entries))
+}
+
+// write encodes `data` as YAML into the file at `filepath`, holding the lock of that file while doing so.
+func (c Client) write(filepath string, data any) error {
+	unlock, err := c.lock(filepath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer unlock()
+
+	return c.encode(filepath, data)
+}
+
+// encode encodes `data` as YAML into the file at `filepath`. The caller needs to hold the lock of that file.
+func (c Client) encode(filepath string, data any) error {
+	file, err := c.fs.OpenFile(filepath, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return errors.NewSystemError("unable to open %q: %s", filepath, err)
+	}
+	defer file.Close()
+
+	encoder := yaml.NewEncoder(file)
+	if err := encoder.Encode(data); err != nil {
+		return errors.NewSystemError("unable to write to %q: %s", filepath, err)
+	}
+
+	return nil
 }
 
 func (c Client) GetTestTimingManifest(_ context.Context, _ string) ([]testing.TestFileTiming, error) {
@@ -138,7 +246,15 @@ func (c Client) GetTestTimingManifest(_ context.Context, _ string) ([]testing.Te
 }
 
 func (c Client) GetRunConfiguration(_ context.Context, _ string) (backend.RunConfiguration, error) {
-	return makeRunConfiguration(c.Flakes, c.Quarantines, c.quarantinesTime)
+	return makeRunConfiguration(c.Flakes, c.Quarantines, c.quarantinesTime, time.Now(), c.logger())
+}
+
+func (c Client) logger() *zap.SugaredLogger {
+	if c.log == nil {
+		return zap.NewNop().Sugar()
+	}
+
+	return c.log
 }
 
 func (c Client) UpdateTestResults(
@@ -146,10 +262,6 @@ func (c Client) UpdateTestResults(
 	_ string,
 	testResults v1.TestResults,
 ) ([]backend.TestResultsUploadResult, error) {
-	if c.Timings == nil {
-		c.Timings = make(map[string]time.Duration)
-	}
-
 	newTimings := make(map[string]time.Duration)
 
 	for _, test := range testResults.Tests {
@@ -164,19 +276,23 @@ func (c Client) UpdateTestResults(
 		}
 	}
 
-	for file, duration := range newTimings {
-		c.Timings[file] = duration
+	if err := c.MergeTimings(newTimings); err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	timingsFile, err := c.fs.OpenFile(c.timingsPath, os.O_WRONLY|os.O_TRUNC, 0)
-	if err != nil {
-		return nil, errors.NewSystemError("unable to open %q: %s", c.timingsPath, err)
+	// The history is only used for suggestions, which is why failing to record it shouldn't fail the run
+	if c.db != nil {
+		if err := c.recordDatabaseRun(testResults, time.Now()); err != nil {
+			c.logger().Warnf("Unable to record this run: %s", err)
+		}
+	} else if err := c.RecordHistory(NewHistoryEntry(testResults, time.Now())); err != nil {
+		c.logger().Warnf("Unable to record the flaky tests of this run: %s", err)
 	}
-	defer timingsFile.Close()
 
-	timingsEncoder := yaml.NewEncoder(timingsFile)
-	if err := timingsEncoder.Encode(c.Timings); err != nil {
-		return nil, errors.NewSystemError("unable to write to %q: %s", c.timingsPath, err)
+	// The stored results are only used as a baseline for diffs, which is why failing to store them shouldn't fail the
+	// run either
+	if err := c.StoreResults(testResults, time.Now()); err != nil {
+		c.logger().Warnf("Unable to store the test results of this run: %s", err)
 	}
 
 	originalPaths := make([]string, len(testResults.DerivedFrom))
@@ -189,3 +305,82 @@ func (c Client) UpdateTestResults(
 		Uploaded:      true,
 	}}, nil
 }
+
+// MergeTimings updates the stored timings of all files in `timings`. Timings of any other files are left untouched.
+// The timings file is re-read while holding its lock, which means that concurrent partitions sharing the same file
+// only ever update the timings of the test files they actually ran.
+func (c Client) MergeTimings(timings map[string]time.Duration) error {
+	if c.db != nil {
+		return c.mergeDatabaseTimings(timings)
+	}
+
+	unlock, err := c.lock(c.timingsPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer unlock()
+
+	currentTimings, err := c.readTimings()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for file, duration := range timings {
+		currentTimings[file] = duration
+	}
+
+	timingsFile, err := c.fs.OpenFile(c.timingsPath, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return errors.NewSystemError("unable to open %q: %s", c.timingsPath, err)
+	}
+	defer timingsFile.Close()
+
+	if err := yaml.NewEncoder(timingsFile).Encode(currentTimings); err != nil {
+		return errors.NewSystemError("unable to write to %q: %s", c.timingsPath, err)
+	}
+
+	if c.Timings != nil {
+		for file := range c.Timings {
+			delete(c.Timings, file)
+		}
+		for file, duration := range currentTimings {
+			c.Timings[file] = duration
+		}
+	}
+
+	return nil
+}
+
+// readTimings reads the latest timings from disk, falling back to the ones loaded on start-up if the file disappeared
+// in the meantime.
+func (c Client) readTimings() (map[string]time.Duration, error) {
+	fd, err := c.fs.Open(c.timingsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		timings := make(map[string]time.Duration, len(c.Timings))
+		for file, duration := range c.Timings {
+			timings[file] = duration
+		}
+		return timings, nil
+	}
+	if err != nil {
+		return nil, errors.NewSystemError("unable to open %q: %s", c.timingsPath, err)
+	}
+	defer fd.Close()
+
+	return DecodeTimings(fd)
+}
+
+// DecodeTimings reads a timings file as it is written by this client.
+func DecodeTimings(r io.Reader) (map[string]time.Duration, error) {
+	timings := make(map[string]time.Duration)
+
+	if err := yaml.NewDecoder(r).Decode(&timings); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.NewInputError("unable to parse timings: %s", err)
+	}
+
+	if timings == nil {
+		timings = make(map[string]time.Duration)
+	}
+
+	return timings, nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 
 	"github.com/rwx-research/captain-cli/internal/backend"
@@ -24,6 +25,7 @@ var _ = Describe("local backend client", func() {
 		flakesPath      = "flakes.yaml"
 		quarantinesPath = "quarantines.yaml"
 		timingsPath     = "timings.yaml"
+		historyPath     = "history.yaml"
 	)
 
 	var (
@@ -31,6 +33,8 @@ var _ = Describe("local backend client", func() {
 		client                       local.Client
 		fileSystem                   mocks.FileSystem
 		flakes, quarantines, timings mocks.File
+		history, results             mocks.File
+		partitionResults             mocks.File
 	)
 
 	BeforeEach(func() {
@@ -51,7 +55,9 @@ var _ = Describe("local backend client", func() {
 			}
 		}
 
-		client, err = local.NewClient(&fileSystem, flakesPath, quarantinesPath, timingsPath)
+		client, err = local.NewClient(
+			&fileSystem, zap.NewNop().Sugar(), flakesPath, quarantinesPath, timingsPath, historyPath,
+		)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -69,6 +75,9 @@ var _ = Describe("local backend client", func() {
 			flakes.Builder = new(strings.Builder)
 			quarantines.Builder = new(strings.Builder)
 			timings.Builder = new(strings.Builder)
+			history.Builder = new(strings.Builder)
+			results.Builder = new(strings.Builder)
+			partitionResults.Builder = new(strings.Builder)
 
 			fileSystem.MockOpenFile = func(name string, flags int, perm os.FileMode) (fs.File, error) {
 				switch name {
@@ -78,10 +87,21 @@ var _ = Describe("local backend client", func() {
 					return &quarantines, nil
 				case timingsPath:
 					return &timings, nil
+				case historyPath:
+					return &history, nil
+				case "results.json":
+					return &results, nil
+				case "results-1-of-4.json":
+					return &partitionResults, nil
+				case timingsPath + ".lock", historyPath + ".lock", "results.json.lock", "results-1-of-4.json.lock":
+					return new(mocks.File), nil
 				default:
 					return nil, os.ErrNotExist
 				}
 			}
+			fileSystem.MockRemove = func(name string) error {
+				return nil
+			}
 
 			testResults = v1.TestResults{
 				Framework: v1.Framework{
@@ -115,5 +135,272 @@ var _ = Describe("local backend client", func() {
 			Expect(result).To(HaveKey(fmt.Sprintf("%d", GinkgoRandomSeed())))
 			Expect(result[fmt.Sprintf("%d", GinkgoRandomSeed())]).To(Equal(time.Second * time.Duration(GinkgoRandomSeed())))
 		})
+
+		It("records the run in the history file", func() {
+			var result []local.HistoryEntry
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yaml.Unmarshal([]byte(history.Builder.String()), &result)).To(Succeed())
+			Expect(result).To(HaveLen(1))
+			Expect(result[0].FlakyTests).To(BeEmpty())
+		})
+
+		It("stores the test results as a baseline for the next run", func() {
+			Expect(err).ToNot(HaveOccurred())
+
+			fileSystem.MockOpen = func(name string) (fs.File, error) {
+				if name == "results.json" {
+					return &mocks.File{Reader: strings.NewReader(results.Builder.String())}, nil
+				}
+
+				return nil, os.ErrNotExist
+			}
+
+			storedResults, err := client.StoredResults()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storedResults).NotTo(BeNil())
+			Expect(storedResults.Tests).To(HaveLen(1))
+			Expect(storedResults.Tests[0].Location.File).To(Equal(fmt.Sprintf("%d", GinkgoRandomSeed())))
+		})
+
+		Context("when running a partition", func() {
+			BeforeEach(func() {
+				client = client.WithPartition("1/4")
+			})
+
+			It("stores the test results separately from the ones of other partitions", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results.Builder.String()).To(BeEmpty())
+				Expect(partitionResults.Builder.String()).NotTo(BeEmpty())
+			})
+		})
+
+		Context("when a test flaked", func() {
+			BeforeEach(func() {
+				testResults.Tests[0].Name = "flaky test"
+				testResults.Tests[0].PastAttempts = []v1.TestAttempt{{Status: v1.NewFailedTestStatus(nil, nil, nil)}}
+			})
+
+			It("records the identity of the flaky test", func() {
+				var result []local.HistoryEntry
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(yaml.Unmarshal([]byte(history.Builder.String()), &result)).To(Succeed())
+				Expect(result).To(HaveLen(1))
+				Expect(result[0].FlakyTests).To(HaveLen(1))
+				Expect(local.NewMapFromYAML(result[0].FlakyTests[0])).To(Equal(local.Map{
+					Order:  []string{"description", "file"},
+					Values: map[string]string{"description": "flaky test", "file": fmt.Sprintf("%d", GinkgoRandomSeed())},
+				}))
+			})
+		})
+
+		Context("when another partition updated the timings file in the meantime", func() {
+			BeforeEach(func() {
+				fileSystem.MockOpen = func(name string) (fs.File, error) {
+					if name == timingsPath {
+						return &mocks.File{Reader: strings.NewReader("other_spec.rb: 3s\n")}, nil
+					}
+
+					return nil, os.ErrNotExist
+				}
+			})
+
+			It("only updates the timings of its own test files", func() {
+				var result map[string]time.Duration
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(yaml.Unmarshal([]byte(timings.Builder.String()), &result)).To(Succeed())
+				Expect(result).To(HaveKeyWithValue("other_spec.rb", 3*time.Second))
+				Expect(result).To(HaveKey(fmt.Sprintf("%d", GinkgoRandomSeed())))
+			})
+		})
+
+		Context("when releasing the lock on the timings file", func() {
+			var (
+				lockFile    mocks.File
+				lockOwner   string
+				removedLock bool
+			)
+
+			BeforeEach(func() {
+				lockFile = mocks.File{Builder: new(strings.Builder)}
+				lockOwner = ""
+				removedLock = false
+				openFile := fileSystem.MockOpenFile
+				open := fileSystem.MockOpen
+
+				fileSystem.MockOpenFile = func(name string, flags int, perm os.FileMode) (fs.File, error) {
+					if name == timingsPath+".lock" {
+						return &lockFile, nil
+					}
+
+					return openFile(name, flags, perm)
+				}
+				fileSystem.MockOpen = func(name string) (fs.File, error) {
+					if name == timingsPath+".lock" {
+						owner := lockOwner
+						if owner == "" {
+							owner = lockFile.Builder.String()
+						}
+
+						return &mocks.File{Reader: strings.NewReader(owner)}, nil
+					}
+
+					return open(name)
+				}
+				fileSystem.MockRemove = func(name string) error {
+					removedLock = removedLock || name == timingsPath+".lock"
+					return nil
+				}
+			})
+
+			It("writes an owner token into the lock file & removes it again", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lockFile.Builder.String()).NotTo(BeEmpty())
+				Expect(removedLock).To(BeTrue())
+			})
+
+			Context("after another process took the lock over", func() {
+				BeforeEach(func() {
+					lockOwner = "some-other-token"
+				})
+
+				It("leaves the lock of the other process alone", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(removedLock).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when the timings file is locked", func() {
+			var movedLock, removedLock bool
+
+			BeforeEach(func() {
+				movedLock, removedLock = false, false
+				openFile := fileSystem.MockOpenFile
+
+				fileSystem.MockOpenFile = func(name string, flags int, perm os.FileMode) (fs.File, error) {
+					if name == timingsPath+".lock" && !removedLock {
+						return nil, os.ErrExist
+					}
+
+					return openFile(name, flags, perm)
+				}
+				fileSystem.MockStat = func(name string) (os.FileInfo, error) {
+					return &mocks.File{MockModTime: func() time.Time { return time.Now().Add(-time.Hour) }}, nil
+				}
+				fileSystem.MockRename = func(oldname, newname string) error {
+					movedLock = oldname == timingsPath+".lock" && strings.HasSuffix(newname, ".stale")
+					return nil
+				}
+				fileSystem.MockRemove = func(name string) error {
+					removedLock = removedLock || (movedLock && strings.HasPrefix(name, timingsPath+".lock."))
+					return nil
+				}
+			})
+
+			It("takes over stale locks by moving them aside first", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(removedLock).To(BeTrue())
+				Expect(timings.Builder.String()).To(ContainSubstring(fmt.Sprintf("%d", GinkgoRandomSeed())))
+			})
+		})
+	})
+
+	Describe("GetRunConfiguration", func() {
+		var runConfiguration backend.RunConfiguration
+
+		BeforeEach(func() {
+			flakes.Reader = strings.NewReader(
+				"- name: expired flake\n  expires_at: 2000-01-01\n" +
+					"- name: current flake\n  owner: me\n  strict: true\n",
+			)
+			quarantines.Reader = strings.NewReader(
+				"- name: expired quarantine\n  expires_at: 2000-01-01T00:00:00Z\n" +
+					"- name: current quarantine\n  reason: flaky on CI\n  issue: JIRA-1\n" +
+					"  quarantined_at: 2023-05-01T10:00:00Z\n  expires_at: 2999-01-01\n",
+			)
+
+			client, err = local.NewClient(
+				&fileSystem, zap.NewNop().Sugar(), flakesPath, quarantinesPath, timingsPath, historyPath,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			runConfiguration, err = client.GetRunConfiguration(context.Background(), "suite-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not apply expired entries", func() {
+			Expect(runConfiguration.FlakyTests).To(HaveLen(1))
+			Expect(runConfiguration.QuarantinedTests).To(HaveLen(1))
+		})
+
+		It("excludes the metadata from the identity", func() {
+			Expect(runConfiguration.FlakyTests[0]).To(Equal(backend.Test{
+				CompositeIdentifier: "current flake",
+				IdentityComponents:  []string{"name"},
+				StrictIdentity:      true,
+			}))
+			Expect(runConfiguration.QuarantinedTests[0].Test).To(Equal(backend.Test{
+				CompositeIdentifier: "current quarantine",
+				IdentityComponents:  []string{"name"},
+				StrictIdentity:      false,
+			}))
+		})
+
+		It("uses the quarantine timestamp of the entry", func() {
+			Expect(runConfiguration.QuarantinedTests[0].QuarantinedAt).To(Equal("2023-05-01T10:00:00Z"))
+		})
+
+		Context("with pattern entries", func() {
+			BeforeEach(func() {
+				flakes.Reader = strings.NewReader("- name: test_upload[*]\n  file: spec/features/**\n  match: glob\n")
+				quarantines.Reader = strings.NewReader("")
+
+				client, err = local.NewClient(
+					&fileSystem, zap.NewNop().Sugar(), flakesPath, quarantinesPath, timingsPath, historyPath,
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				runConfiguration, err = client.GetRunConfiguration(context.Background(), "suite-id")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("includes the patterns of every identity component", func() {
+				Expect(runConfiguration.FlakyTests).To(Equal([]backend.Test{{
+					CompositeIdentifier: "test_upload[*] -captain- spec/features/**",
+					IdentityComponents:  []string{"name", "file"},
+					Match:               backend.MatchGlob,
+					IdentityValues:      []string{"test_upload[*]", "spec/features/**"},
+				}}))
+			})
+
+			It("treats brackets in glob patterns literally", func() {
+				pattern, err := backend.CompilePattern(backend.MatchGlob, "test_upload[*]")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pattern.MatchString("test_upload[large file]")).To(BeTrue())
+				Expect(pattern.MatchString("test_upload_large_file")).To(BeFalse())
+
+				pattern, err = backend.CompilePattern(backend.MatchGlob, "spec/features/**")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pattern.MatchString("spec/features/admin/users_spec.rb")).To(BeTrue())
+				Expect(pattern.MatchString("spec/models/user_spec.rb")).To(BeFalse())
+			})
+		})
+
+		Context("with an invalid regular expression", func() {
+			It("reports the entry as invalid", func() {
+				_, err := local.NewEntryFromYAML(yamlNode("name: \"(unclosed\"\nmatch: regex\n"))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid regex pattern"))
+			})
+		})
 	})
 })
+
+func yamlNode(document string) yaml.Node {
+	var node yaml.Node
+	Expect(yaml.Unmarshal([]byte(document), &node)).To(Succeed())
+	return *node.Content[0]
+}
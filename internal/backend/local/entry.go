@@ -0,0 +1,159 @@
+package local
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rwx-research/captain-cli/internal/backend"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+const (
+	keyStrict        = "strict"
+	keyQuarantinedAt = "quarantined_at"
+	keyExpiresAt     = "expires_at"
+	keyOwner         = "owner"
+	keyReason        = "reason"
+	keyIssue         = "issue"
+	keyMatch         = "match"
+)
+
+// metadataKeys are the keys of a flake or quarantine entry that describe the entry itself rather than identify a test.
+var metadataKeys = []string{keyStrict, keyMatch, keyQuarantinedAt, keyExpiresAt, keyOwner, keyReason, keyIssue}
+
+// IsMetadataKey returns whether `key` is a metadata field of a flake or quarantine entry, i.e. whether it is excluded
+// from the identity of the test. Hyphenated spellings (e.g. "expires-at") are accepted as well.
+func IsMetadataKey(key string) bool {
+	return metadataKey(key) != ""
+}
+
+func metadataKey(key string) string {
+	normalized := strings.ReplaceAll(key, "-", "_")
+
+	for _, metadataKey := range metadataKeys {
+		if normalized == metadataKey {
+			return metadataKey
+		}
+	}
+
+	return ""
+}
+
+// Entry is a single flake or quarantine as it is stored in `flakes.yaml` or `quarantines.yaml`.
+type Entry struct {
+	Identity      Map
+	Strict        bool
+	Match         string
+	QuarantinedAt *time.Time
+	ExpiresAt     *time.Time
+	Owner         string
+	Reason        string
+	Issue         string
+}
+
+// NewEntryFromYAML splits a YAML map into the identity of a test and the metadata of the entry. Timestamps that can't
+// be parsed are reported as error, but the rest of the entry is still returned.
+func NewEntryFromYAML(node yaml.Node) (Entry, error) {
+	identity, metadata := NewMapFromYAML(node).Split()
+
+	entry := Entry{
+		Identity: identity,
+		Strict:   metadata.Values[keyStrict] == "true",
+		Match:    metadata.Values[keyMatch],
+		Owner:    metadata.Values[keyOwner],
+		Reason:   metadata.Values[keyReason],
+		Issue:    metadata.Values[keyIssue],
+	}
+
+	timestamps := []struct {
+		key    string
+		target **time.Time
+	}{
+		{keyQuarantinedAt, &entry.QuarantinedAt},
+		{keyExpiresAt, &entry.ExpiresAt},
+	}
+
+	var err error
+	for _, timestamp := range timestamps {
+		value, ok := metadata.Values[timestamp.key]
+		if !ok || value == "" {
+			continue
+		}
+
+		parsed, parseErr := ParseTimestamp(value)
+		if parseErr != nil {
+			err = errors.NewInputError("invalid %s of %q: %s", timestamp.key, newCompositeID(identity), parseErr)
+			continue
+		}
+
+		*timestamp.target = &parsed
+	}
+
+	if matchErr := entry.validatePatterns(); matchErr != nil {
+		err = matchErr
+	}
+
+	return entry, err
+}
+
+// validatePatterns ensures that the identity values of pattern matches compile. Invalid patterns never match a test.
+func (e Entry) validatePatterns() error {
+	if err := backend.ValidateMatch(e.Match); err != nil {
+		return errors.Wrapf(err, "invalid entry %q", newCompositeID(e.Identity))
+	}
+
+	if !backend.IsPattern(e.Match) {
+		return nil
+	}
+
+	for _, component := range e.Identity.Order {
+		if _, err := backend.CompilePattern(e.Match, e.Identity.Values[component]); err != nil {
+			return errors.Wrapf(err, "invalid entry %q", newCompositeID(e.Identity))
+		}
+	}
+
+	return nil
+}
+
+// Expired returns whether the entry has an expiry date that lies before `now`.
+func (e Entry) Expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// Test returns the test identified by this entry.
+func (e Entry) Test() backend.Test {
+	test := backend.Test{
+		CompositeIdentifier: newCompositeID(e.Identity),
+		IdentityComponents:  e.Identity.Order,
+		StrictIdentity:      e.Strict,
+	}
+
+	if backend.IsPattern(e.Match) {
+		test.Match = e.Match
+		test.IdentityValues = make([]string, len(e.Identity.Order))
+		for i, component := range e.Identity.Order {
+			test.IdentityValues[i] = e.Identity.Values[component]
+		}
+	}
+
+	return test
+}
+
+// ParseTimestamp parses timestamps in the formats supported by flake & quarantine entries, i.e. RFC 3339
+// ("2023-05-01T12:00:00Z") or plain dates ("2023-05-01", interpreted as midnight UTC).
+func ParseTimestamp(value string) (time.Time, error) {
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp, nil
+	}
+
+	timestamp, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.NewInputError(
+			"%q is neither an RFC 3339 timestamp nor a date in the YYYY-MM-DD format", value,
+		)
+	}
+
+	return timestamp, nil
+}
@@ -0,0 +1,142 @@
+package local
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// maxHistoryEntries is the number of runs that are kept in the history file. Older runs are dropped.
+const maxHistoryEntries = 200
+
+// HistoryEntry records the flaky tests of a single run. Runs without any flaky tests are recorded as well, as they are
+// necessary to tell how often a test flaked in the last couple of runs.
+type HistoryEntry struct {
+	RecordedAt time.Time `yaml:"recorded_at"`
+	// FlakyTests holds the identities of the flaky tests of this run, in the same format as `flakes.yaml`
+	FlakyTests []yaml.Node `yaml:"flaky_tests"`
+}
+
+// NewHistoryEntry assembles the history entry of a run. Tests are identified using the identity components of their
+// framework (see `v1.Framework.IdentityComponents`).
+func NewHistoryEntry(testResults v1.TestResults, recordedAt time.Time) HistoryEntry {
+	entry := HistoryEntry{RecordedAt: recordedAt.UTC(), FlakyTests: make([]yaml.Node, 0)}
+	seen := make([]Map, 0)
+
+	for _, test := range testResults.Tests {
+		if !test.Flaky() {
+			continue
+		}
+
+		identity := IdentifyTest(test, testResults.Framework)
+		if len(identity.Order) == 0 {
+			continue
+		}
+
+		duplicate := false
+		for _, other := range seen {
+			if identity.Equals(other) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		seen = append(seen, identity)
+		entry.FlakyTests = append(entry.FlakyTests, identity.ToYAML())
+	}
+
+	return entry
+}
+
+// IdentifyTest returns the identity of `test` in the format of `flakes.yaml` & `quarantines.yaml`. Identity components
+// that aren't available for this specific test are omitted.
+func IdentifyTest(test v1.Test, framework v1.Framework) Map {
+	identity := Map{Order: make([]string, 0), Values: make(map[string]string)}
+
+	for _, component := range framework.IdentityComponents() {
+		value, err := test.Identify([]string{component}, true)
+		if err != nil {
+			continue
+		}
+
+		identity.Order = append(identity.Order, component)
+		identity.Values[component] = value
+	}
+
+	return identity
+}
+
+// History returns the recorded runs, oldest first.
+func (c Client) History() ([]HistoryEntry, error) {
+	if c.db != nil {
+		return c.databaseHistory()
+	}
+
+	if c.historyPath == "" {
+		return []HistoryEntry{}, nil
+	}
+
+	fd, err := c.fs.Open(c.historyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, errors.NewSystemError("unable to open %q: %s", c.historyPath, err)
+	}
+	defer fd.Close()
+
+	history := make([]HistoryEntry, 0)
+	if err := yaml.NewDecoder(fd).Decode(&history); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.NewInputError("unable to parse %q: %s", c.historyPath, err)
+	}
+
+	return history, nil
+}
+
+// RecordHistory appends `entry` to the history file. Like timings, the history file is updated while holding its lock
+// as concurrent partitions might record their runs at the same time.
+func (c Client) RecordHistory(entry HistoryEntry) error {
+	if c.db != nil {
+		return c.recordDatabaseHistory(entry)
+	}
+
+	if c.historyPath == "" {
+		return nil
+	}
+
+	unlock, err := c.lock(c.historyPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer unlock()
+
+	history, err := c.History()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	history = append(history, entry)
+	if len(history) > maxHistoryEntries {
+		history = history[len(history)-maxHistoryEntries:]
+	}
+
+	file, err := c.fs.OpenFile(c.historyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.NewSystemError("unable to open %q: %s", c.historyPath, err)
+	}
+	defer file.Close()
+
+	if err := yaml.NewEncoder(file).Encode(history); err != nil {
+		return errors.NewSystemError("unable to write to %q: %s", c.historyPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package local
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+const (
+	lockRetryInterval = 100 * time.Millisecond
+	lockTimeout       = 30 * time.Second
+	staleLockAge      = 2 * time.Minute
+)
+
+// lock acquires an exclusive, advisory lock on the file at `path` by atomically creating a sibling `.lock` file.
+// Unlike `flock` & co, this works across platforms and on most network file-systems, which is where concurrent
+// partitions usually end up sharing their storage files.
+// The lock file holds a random token that identifies its owner. The returned function releases the lock again, unless
+// it was taken over by another process in the meantime (see `removeStaleLock`).
+func (c Client) lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	token := uuid.NewString()
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		fd, err := c.fs.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = fd.Write([]byte(token))
+			_ = fd.Close()
+
+			if err != nil {
+				_ = c.fs.Remove(lockPath)
+				return nil, errors.NewSystemError("unable to write to lock file %q: %s", lockPath, err)
+			}
+
+			return func() { c.unlock(lockPath, token) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, errors.NewSystemError("unable to create lock file %q: %s", lockPath, err)
+		}
+
+		// A lock file that is this old was most likely left behind by a Captain process that didn't exit cleanly.
+		if c.isStaleLock(lockPath) && c.removeStaleLock(lockPath, token) {
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.NewSystemError(
+				"timed out waiting for the lock on %q. If no other Captain process is running, please remove %q",
+				path, lockPath,
+			)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// unlock removes the lock file, but only if it still holds `token`. Otherwise, the lock was deemed stale & belongs to
+// another process by now.
+func (c Client) unlock(lockPath, token string) {
+	if owner := c.lockToken(lockPath); owner != token {
+		if owner != "" {
+			c.logger().Warnf("Unable to release the lock %q, as it was taken over by another process", lockPath)
+		}
+		return
+	}
+
+	_ = c.fs.Remove(lockPath)
+}
+
+// removeStaleLock removes a stale lock file by first moving it aside, which is atomic. Out of several processes that
+// noticed the stale lock at the same time, only one can move it. If another process replaced it with a fresh lock
+// in the meantime, that lock is moved back instead. It returns whether the stale lock was removed.
+func (c Client) removeStaleLock(lockPath, token string) bool {
+	asidePath := fmt.Sprintf("%s.%s.stale", lockPath, token)
+	if err := c.fs.Rename(lockPath, asidePath); err != nil {
+		return false
+	}
+
+	if !c.isStaleLock(asidePath) {
+		_ = c.fs.Rename(asidePath, lockPath)
+		return false
+	}
+
+	_ = c.fs.Remove(asidePath)
+	return true
+}
+
+func (c Client) isStaleLock(lockPath string) bool {
+	info, err := c.fs.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) > staleLockAge
+}
+
+// lockToken returns the token of the owner of a lock, or an empty string if the lock file can't be read.
+func (c Client) lockToken(lockPath string) string {
+	fd, err := c.fs.Open(lockPath)
+	if err != nil {
+		return ""
+	}
+	defer fd.Close()
+
+	token, err := io.ReadAll(fd)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(token))
+}
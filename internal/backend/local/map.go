@@ -1,6 +1,10 @@
 package local
 
-import "gopkg.in/yaml.v3"
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/rwx-research/captain-cli/internal/backend"
+)
 
 type Map struct {
 	Order  []string
@@ -23,10 +27,14 @@ func NewMapFromYAML(node yaml.Node) Map {
 }
 
 func (m Map) Equals(n Map) bool {
-	filteredM, strictM := m.withoutKey("strict")
-	filteredN, strictN := n.withoutKey("strict")
+	filteredM, metadataM := m.Split()
+	filteredN, metadataN := n.Split()
+
+	if metadataM.Values[keyStrict] != metadataN.Values[keyStrict] {
+		return false
+	}
 
-	if strictM != strictN {
+	if matchOf(metadataM) != matchOf(metadataN) {
 		return false
 	}
 
@@ -47,6 +55,15 @@ func (m Map) Equals(n Map) bool {
 	return true
 }
 
+// matchOf returns the match kind of an entry, which defaults to exact matches.
+func matchOf(metadata Map) string {
+	if match := metadata.Values[keyMatch]; match != "" {
+		return match
+	}
+
+	return backend.MatchExact
+}
+
 func (m Map) ToYAML() yaml.Node {
 	node := yaml.Node{
 		Kind:    yaml.MappingNode,
@@ -62,24 +79,39 @@ func (m Map) ToYAML() yaml.Node {
 	return node
 }
 
-func (m Map) withoutKey(key string) (Map, string) {
-	var value string
-
-	filteredOrder := make([]string, 0)
-	filteredValues := make(map[string]string)
+// Split separates the identity of a test from the metadata of a flake or quarantine entry (see `metadataKeys`).
+// Hyphenated metadata keys are normalized, e.g. "expires-at" becomes "expires_at".
+func (m Map) Split() (identity Map, metadata Map) {
+	identity = Map{Order: make([]string, 0), Values: make(map[string]string)}
+	metadata = Map{Order: make([]string, 0), Values: make(map[string]string)}
 
 	for _, element := range m.Order {
-		if element != key {
-			filteredOrder = append(filteredOrder, element)
-			filteredValues[element] = m.Values[element]
+		if key := metadataKey(element); key != "" {
+			metadata.Order = append(metadata.Order, key)
+			metadata.Values[key] = m.Values[element]
 
 			continue
 		}
 
-		if v, ok := m.Values[key]; ok {
-			value = v
+		identity.Order = append(identity.Order, element)
+		identity.Values[element] = m.Values[element]
+	}
+
+	return identity, metadata
+}
+
+// Merge returns a map with the keys of `m` followed by the keys of `n`. Values of `n` take precedence.
+func (m Map) Merge(n Map) Map {
+	merged := Map{Order: make([]string, 0, len(m.Order)+len(n.Order)), Values: make(map[string]string)}
+
+	for _, source := range []Map{m, n} {
+		for _, key := range source.Order {
+			if _, ok := merged.Values[key]; !ok {
+				merged.Order = append(merged.Order, key)
+			}
+			merged.Values[key] = source.Values[key]
 		}
 	}
 
-	return Map{filteredOrder, filteredValues}, value
+	return merged
 }
@@ -0,0 +1,240 @@
+package local
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	// resultsFileName is the name of the file that holds the test results of the most recent run. Like the history,
+	// it's only written by Captain itself, which is why it's kept next to the history.
+	resultsFileName = "results.json"
+
+	// partitionedResultsFilePrefix & partitionedResultsFileSuffix make up the name of the file that holds the test
+	// results of the most recent run of a partition, e.g. `results-1-of-4.json`
+	partitionedResultsFilePrefix = "results-"
+	partitionedResultsFileSuffix = ".json"
+)
+
+// WithPartition returns a copy of the client that stores test results separately for the given partition (in the
+// `<index>/<total>` notation, see `config.PartitionNodes`). This way, concurrent partitions that share their storage
+// don't overwrite each other's results. An empty partition refers to runs that weren't partitioned.
+func (c Client) WithPartition(partition string) Client {
+	c.partition = partition
+	return c
+}
+
+func (c Client) resultsPath() string {
+	return c.resultsPathOf(c.partition)
+}
+
+func (c Client) resultsPathOf(partition string) string {
+	if c.historyPath == "" {
+		return ""
+	}
+
+	fileName := resultsFileName
+	if partition != "" {
+		fileName = partitionedResultsFilePrefix + strings.ReplaceAll(partition, "/", "-of-") + partitionedResultsFileSuffix
+	}
+
+	return filepath.Join(filepath.Dir(c.historyPath), fileName)
+}
+
+// StoredResults returns the test results of the most recent run of the partition of the client that updated the
+// stored results, or nil if there is no such run.
+func (c Client) StoredResults() (*v1.TestResults, error) {
+	if c.db == nil {
+		return c.readResultsFile(c.resultsPath())
+	}
+
+	var buf []byte
+	err := c.db.QueryRow(
+		"SELECT results FROM results WHERE suite_id = ? AND partition = ?", c.suiteID, c.partition,
+	).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewSystemError("unable to read the stored results: %s", err)
+	}
+
+	return decodeStoredResults(buf)
+}
+
+// AllStoredResults returns the stored test results of every partition, keyed by partition. Results of runs that
+// weren't partitioned are keyed by an empty string.
+func (c Client) AllStoredResults() (map[string]v1.TestResults, error) {
+	allResults := make(map[string]v1.TestResults)
+
+	if c.db == nil {
+		if c.historyPath == "" {
+			return allResults, nil
+		}
+
+		paths, err := c.fs.Glob(filepath.Join(filepath.Dir(c.historyPath), "results*.json"))
+		if err != nil {
+			return nil, errors.NewSystemError("unable to find the stored results: %s", err)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			partition, ok := partitionOfResultsFile(filepath.Base(path))
+			if !ok {
+				continue
+			}
+
+			testResults, err := c.readResultsFile(path)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if testResults != nil {
+				allResults[partition] = *testResults
+			}
+		}
+
+		return allResults, nil
+	}
+
+	rows, err := c.db.Query("SELECT partition, results FROM results WHERE suite_id = ?", c.suiteID)
+	if err != nil {
+		return nil, errors.NewSystemError("unable to read the stored results: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var partition string
+		var buf []byte
+		if err := rows.Scan(&partition, &buf); err != nil {
+			return nil, errors.NewSystemError("unable to read the stored results: %s", err)
+		}
+
+		testResults, err := decodeStoredResults(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		allResults[partition] = *testResults
+	}
+
+	return allResults, errors.WithStack(rows.Err())
+}
+
+// partitionOfResultsFile is the inverse of `resultsPathOf`
+func partitionOfResultsFile(fileName string) (string, bool) {
+	if fileName == resultsFileName {
+		return "", true
+	}
+
+	if !strings.HasPrefix(fileName, partitionedResultsFilePrefix) ||
+		!strings.HasSuffix(fileName, partitionedResultsFileSuffix) {
+		return "", false
+	}
+
+	partition := strings.TrimPrefix(fileName, partitionedResultsFilePrefix)
+	partition = strings.TrimSuffix(partition, partitionedResultsFileSuffix)
+	if !strings.Contains(partition, "-of-") {
+		return "", false
+	}
+
+	return strings.Replace(partition, "-of-", "/", 1), true
+}
+
+// readResultsFile reads stored test results while holding the lock of the file, which means it never observes a
+// partially written file. It returns nil if the file doesn't exist.
+func (c Client) readResultsFile(resultsPath string) (*v1.TestResults, error) {
+	if resultsPath == "" {
+		return nil, nil
+	}
+
+	// There's nothing to lock if there are no stored results, which also avoids creating the lock file in a directory
+	// that might not exist
+	if _, err := c.fs.Stat(resultsPath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	unlock, err := c.lock(resultsPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer unlock()
+
+	fd, err := c.fs.Open(resultsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewSystemError("unable to open %q: %s", resultsPath, err)
+	}
+	defer fd.Close()
+
+	testResults := new(v1.TestResults)
+	if err := json.NewDecoder(fd).Decode(testResults); err != nil {
+		return nil, errors.NewInputError("unable to parse %q: %s", resultsPath, err)
+	}
+
+	return testResults, nil
+}
+
+func decodeStoredResults(buf []byte) (*v1.TestResults, error) {
+	testResults := new(v1.TestResults)
+	if err := json.Unmarshal(buf, testResults); err != nil {
+		return nil, errors.NewInputError("unable to parse the stored results: %s", err)
+	}
+
+	return testResults, nil
+}
+
+// StoreResults replaces the stored test results of the partition of the client with the ones of the current run.
+func (c Client) StoreResults(testResults v1.TestResults, recordedAt time.Time) error {
+	buf, err := json.Marshal(testResults)
+	if err != nil {
+		return errors.NewInternalError("unable to encode test results: %s", err)
+	}
+
+	if c.db != nil {
+		_, err := c.db.Exec(
+			"INSERT INTO results (suite_id, partition, recorded_at, results) VALUES (?, ?, ?, ?) "+
+				"ON CONFLICT (suite_id, partition) DO UPDATE SET "+
+				"recorded_at = excluded.recorded_at, results = excluded.results",
+			c.suiteID, c.partition, recordedAt.UTC().Format(time.RFC3339Nano), buf,
+		)
+		if err != nil {
+			return errors.NewSystemError("unable to store the test results: %s", err)
+		}
+
+		return nil
+	}
+
+	resultsPath := c.resultsPath()
+	if resultsPath == "" {
+		return nil
+	}
+
+	unlock, err := c.lock(resultsPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer unlock()
+
+	file, err := c.fs.OpenFile(resultsPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.NewSystemError("unable to open %q: %s", resultsPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf); err != nil {
+		return errors.NewSystemError("unable to write to %q: %s", resultsPath, err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 
 	"github.com/rwx-research/captain-cli/internal/backend"
@@ -19,34 +20,60 @@ func newCompositeID(identity Map) string {
 	return strings.Join(components, " -captain- ")
 }
 
-func makeRunConfiguration(flakes, quarantines []yaml.Node, modTime time.Time) (backend.RunConfiguration, error) {
+// makeRunConfiguration assembles the run configuration from the flake & quarantine entries. Expired entries are
+// skipped. Quarantines without a `quarantined_at` timestamp fall back to the modification time of the file.
+func makeRunConfiguration(
+	flakes, quarantines []yaml.Node,
+	modTime time.Time,
+	now time.Time,
+	log *zap.SugaredLogger,
+) (backend.RunConfiguration, error) {
 	config := backend.RunConfiguration{
-		GeneratedAt:      time.Now().Format(time.RFC3339),
-		QuarantinedTests: make([]backend.QuarantinedTest, len(quarantines)),
-		FlakyTests:       make([]backend.Test, len(flakes)),
+		GeneratedAt:      now.Format(time.RFC3339),
+		QuarantinedTests: make([]backend.QuarantinedTest, 0, len(quarantines)),
+		FlakyTests:       make([]backend.Test, 0, len(flakes)),
 	}
 
-	for i, flake := range flakes {
-		identity, strict := NewMapFromYAML(flake).withoutKey("strict")
+	for _, flake := range flakes {
+		entry, err := NewEntryFromYAML(flake)
+		if err != nil {
+			log.Warnf("Unable to read flake: %s", err)
+		}
 
-		config.FlakyTests[i] = backend.Test{
-			CompositeIdentifier: newCompositeID(identity),
-			IdentityComponents:  identity.Order,
-			StrictIdentity:      strict == "true",
+		if entry.Expired(now) {
+			log.Warnf(
+				"The flake %q expired on %s and is no longer applied", newCompositeID(entry.Identity),
+				entry.ExpiresAt.Format(time.RFC3339),
+			)
+			continue
 		}
+
+		config.FlakyTests = append(config.FlakyTests, entry.Test())
 	}
 
-	for i, quarantine := range quarantines {
-		identity, strict := NewMapFromYAML(quarantine).withoutKey("strict")
+	for _, quarantine := range quarantines {
+		entry, err := NewEntryFromYAML(quarantine)
+		if err != nil {
+			log.Warnf("Unable to read quarantine: %s", err)
+		}
+
+		if entry.Expired(now) {
+			log.Warnf(
+				"The quarantine of %q expired on %s and is no longer applied", newCompositeID(entry.Identity),
+				entry.ExpiresAt.Format(time.RFC3339),
+			)
+			continue
+		}
 
-		config.QuarantinedTests[i] = backend.QuarantinedTest{
-			Test: backend.Test{
-				CompositeIdentifier: newCompositeID(identity),
-				IdentityComponents:  identity.Order,
-				StrictIdentity:      strict == "true",
-			},
-			QuarantinedAt: modTime.Format(time.RFC3339),
+		quarantinedAt := modTime
+		if entry.QuarantinedAt != nil {
+			quarantinedAt = *entry.QuarantinedAt
 		}
+
+		config.QuarantinedTests = append(config.QuarantinedTests, backend.QuarantinedTest{
+			Test:          entry.Test(),
+			QuarantinedAt: quarantinedAt.Format(time.RFC3339),
+		})
 	}
 
 	return config, nil
@@ -0,0 +1,662 @@
+package local
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	// Registers the pure-Go "sqlite" driver, which means Captain doesn't require cgo
+	_ "modernc.org/sqlite"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	// maxStoredRuns is the number of runs per suite that are kept in the database. Older runs are deleted together
+	// with their tests & attempts.
+	maxStoredRuns = 1000
+
+	entryKindFlake      = "flake"
+	entryKindQuarantine = "quarantine"
+
+	// sqliteBusyTimeout is how long SQLite waits for concurrent writers (e.g. other partitions) to finish
+	sqliteBusyTimeout = 30 * time.Second
+)
+
+// sqliteMigrations holds the schema of the database. Each migration is applied exactly once, in order. The index of
+// the last applied migration is tracked using SQLite's `user_version`.
+var sqliteMigrations = []string{
+	`
+	CREATE TABLE suites (
+		id TEXT PRIMARY KEY,
+		quarantines_updated_at TEXT
+	);
+
+	CREATE TABLE entries (
+		suite_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		entry TEXT NOT NULL,
+		PRIMARY KEY (suite_id, kind, position)
+	);
+
+	CREATE TABLE timings (
+		suite_id TEXT NOT NULL,
+		file TEXT NOT NULL,
+		duration_ns INTEGER NOT NULL,
+		PRIMARY KEY (suite_id, file)
+	);
+
+	CREATE TABLE runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		suite_id TEXT NOT NULL,
+		recorded_at TEXT NOT NULL
+	);
+	CREATE INDEX runs_suite_id ON runs (suite_id, id);
+
+	CREATE TABLE tests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+		identity TEXT NOT NULL,
+		name TEXT,
+		file TEXT,
+		status TEXT,
+		flaky INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX tests_run_id ON tests (run_id);
+
+	CREATE TABLE attempts (
+		test_id INTEGER NOT NULL REFERENCES tests (id) ON DELETE CASCADE,
+		number INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		duration_ns INTEGER,
+		PRIMARY KEY (test_id, number)
+	);
+	`,
+	`
+	CREATE TABLE results (
+		suite_id TEXT NOT NULL,
+		partition TEXT NOT NULL,
+		recorded_at TEXT NOT NULL,
+		results BLOB NOT NULL,
+		PRIMARY KEY (suite_id, partition)
+	);
+	`,
+}
+
+// NewSQLiteClient returns a client that stores flakes, quarantines, timings, and the history of runs of the given
+// suite in the SQLite database at `databasePath`. The database is created if it doesn't exist yet. Several suites
+// can share the same database.
+// Unlike the YAML files, the database is updated in transactions, which makes it safe to use from concurrent
+// partitions.
+func NewSQLiteClient(log *zap.SugaredLogger, databasePath, suiteID string) (Client, error) {
+	c := Client{
+		log:     log,
+		suiteID: suiteID,
+		Timings: make(map[string]time.Duration),
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?%s", databasePath, query.Encode()))
+	if err != nil {
+		return c, errors.NewSystemError("unable to open %q: %s", databasePath, err)
+	}
+	c.db = db
+
+	if err := c.migrate(); err != nil {
+		_ = db.Close()
+		return c, errors.Wrapf(err, "unable to migrate %q", databasePath)
+	}
+
+	if _, err := db.Exec("INSERT OR IGNORE INTO suites (id) VALUES (?)", suiteID); err != nil {
+		_ = db.Close()
+		return c, errors.NewSystemError("unable to register suite %q: %s", suiteID, err)
+	}
+
+	if err := c.loadDatabase(); err != nil {
+		_ = db.Close()
+		return c, errors.Wrapf(err, "unable to read %q", databasePath)
+	}
+
+	return c, nil
+}
+
+// IsDatabase returns whether the client is backed by a SQLite database rather than by YAML files.
+func (c Client) IsDatabase() bool {
+	return c.db != nil
+}
+
+// Close releases the database of the client, if any.
+func (c Client) Close() error {
+	if c.db == nil {
+		return nil
+	}
+
+	return errors.WithStack(c.db.Close())
+}
+
+func (c Client) migrate() error {
+	var version int
+	if err := c.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return errors.NewSystemError("unable to read schema version: %s", err)
+	}
+
+	if version > len(sqliteMigrations) {
+		return errors.NewConfigurationError(
+			"Unsupported database",
+			fmt.Sprintf("The database was created by a newer version of Captain (schema version %d).", version),
+			"Please upgrade Captain or use a different database.",
+		)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		err := c.transaction(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+				return errors.WithStack(err)
+			}
+
+			// PRAGMA statements don't support placeholders
+			_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+			return errors.WithStack(err)
+		})
+		if err != nil {
+			return errors.NewSystemError("unable to apply migration %d: %s", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// transaction runs `f` in a transaction, which is committed if `f` returns without error and rolled back otherwise.
+func (c Client) transaction(f func(tx *sql.Tx) error) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return errors.NewSystemError("unable to start transaction: %s", err)
+	}
+
+	if err := f(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewSystemError("unable to commit transaction: %s", err)
+	}
+
+	return nil
+}
+
+func (c *Client) loadDatabase() error {
+	var err error
+
+	if c.Flakes, err = c.readEntries(c.db, entryKindFlake); err != nil {
+		return err
+	}
+
+	if c.Quarantines, err = c.readEntries(c.db, entryKindQuarantine); err != nil {
+		return err
+	}
+
+	var quarantinesUpdatedAt sql.NullString
+	err = c.db.QueryRow("SELECT quarantines_updated_at FROM suites WHERE id = ?", c.suiteID).Scan(&quarantinesUpdatedAt)
+	if err != nil {
+		return errors.NewSystemError("unable to read suite %q: %s", c.suiteID, err)
+	}
+	if quarantinesUpdatedAt.Valid {
+		if c.quarantinesTime, err = time.Parse(time.RFC3339Nano, quarantinesUpdatedAt.String); err != nil {
+			return errors.NewInputError("invalid quarantine timestamp %q: %s", quarantinesUpdatedAt.String, err)
+		}
+	}
+
+	timings, err := c.readDatabaseTimings(c.db)
+	if err != nil {
+		return err
+	}
+	c.Timings = timings
+
+	return nil
+}
+
+// queryer is implemented by both `*sql.DB` and `*sql.Tx`
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func (c Client) readEntries(db queryer, kind string) ([]yaml.Node, error) {
+	rows, err := db.Query(
+		"SELECT entry FROM entries WHERE suite_id = ? AND kind = ? ORDER BY position", c.suiteID, kind,
+	)
+	if err != nil {
+		return nil, errors.NewSystemError("unable to read %s entries: %s", kind, err)
+	}
+	defer rows.Close()
+
+	entries := make([]yaml.Node, 0)
+	for rows.Next() {
+		var encoded string
+		if err := rows.Scan(&encoded); err != nil {
+			return nil, errors.NewSystemError("unable to read %s entry: %s", kind, err)
+		}
+
+		node, err := decodeYAMLMapping(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s entry", kind)
+		}
+
+		entries = append(entries, node)
+	}
+
+	return entries, errors.WithStack(rows.Err())
+}
+
+func (c Client) readDatabaseTimings(db queryer) (map[string]time.Duration, error) {
+	rows, err := db.Query("SELECT file, duration_ns FROM timings WHERE suite_id = ?", c.suiteID)
+	if err != nil {
+		return nil, errors.NewSystemError("unable to read timings: %s", err)
+	}
+	defer rows.Close()
+
+	timings := make(map[string]time.Duration)
+	for rows.Next() {
+		var file string
+		var duration int64
+		if err := rows.Scan(&file, &duration); err != nil {
+			return nil, errors.NewSystemError("unable to read timing: %s", err)
+		}
+
+		timings[file] = time.Duration(duration)
+	}
+
+	return timings, errors.WithStack(rows.Err())
+}
+
+// flushDatabase replaces the stored flakes & quarantines with the ones of the client. The modification time of the
+// quarantines is only updated if they actually changed.
+func (c Client) flushDatabase() error {
+	return c.transaction(func(tx *sql.Tx) error {
+		if _, err := c.replaceEntries(tx, entryKindFlake, c.Flakes); err != nil {
+			return err
+		}
+
+		changed, err := c.replaceEntries(tx, entryKindQuarantine, c.Quarantines)
+		if err != nil {
+			return err
+		}
+
+		if changed {
+			return c.touchQuarantines(tx)
+		}
+
+		return nil
+	})
+}
+
+// touchQuarantines updates the modification time of the quarantines.
+func (c Client) touchQuarantines(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"UPDATE suites SET quarantines_updated_at = ? WHERE id = ?",
+		time.Now().UTC().Format(time.RFC3339Nano), c.suiteID,
+	)
+	if err != nil {
+		return errors.NewSystemError("unable to update suite %q: %s", c.suiteID, err)
+	}
+
+	return nil
+}
+
+// addDatabaseEntries inserts `nodes` after the last stored entry of the given kind. As SQLite serializes writes, the
+// positions of entries added concurrently can't collide.
+func (c Client) addDatabaseEntries(kind string, nodes []yaml.Node) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	return c.transaction(func(tx *sql.Tx) error {
+		for i := range nodes {
+			encoded, err := encodeYAML(&nodes[i])
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(
+				"INSERT INTO entries (suite_id, kind, position, entry) "+
+					"SELECT ?, ?, COALESCE(MAX(position), -1) + 1, ? FROM entries WHERE suite_id = ? AND kind = ?",
+				c.suiteID, kind, encoded, c.suiteID, kind,
+			)
+			if err != nil {
+				return errors.NewSystemError("unable to store %s entry: %s", kind, err)
+			}
+		}
+
+		if kind == entryKindQuarantine {
+			return c.touchQuarantines(tx)
+		}
+
+		return nil
+	})
+}
+
+// removeDatabaseEntries deletes the stored entries of the given kind that match `identity`, leaving all others as-is.
+func (c Client) removeDatabaseEntries(kind string, identity Map) error {
+	return c.transaction(func(tx *sql.Tx) error {
+		rows, err := tx.Query(
+			"SELECT position, entry FROM entries WHERE suite_id = ? AND kind = ? ORDER BY position", c.suiteID, kind,
+		)
+		if err != nil {
+			return errors.NewSystemError("unable to read %s entries: %s", kind, err)
+		}
+
+		positions := make([]int64, 0)
+		for rows.Next() {
+			var position int64
+			var encoded string
+			if err := rows.Scan(&position, &encoded); err != nil {
+				rows.Close()
+				return errors.NewSystemError("unable to read %s entry: %s", kind, err)
+			}
+
+			node, err := decodeYAMLMapping(encoded)
+			if err != nil {
+				rows.Close()
+				return errors.Wrapf(err, "invalid %s entry", kind)
+			}
+
+			if identity.Equals(NewMapFromYAML(node)) {
+				positions = append(positions, position)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for _, position := range positions {
+			_, err := tx.Exec(
+				"DELETE FROM entries WHERE suite_id = ? AND kind = ? AND position = ?", c.suiteID, kind, position,
+			)
+			if err != nil {
+				return errors.NewSystemError("unable to delete %s entry: %s", kind, err)
+			}
+		}
+
+		if kind == entryKindQuarantine && len(positions) > 0 {
+			return c.touchQuarantines(tx)
+		}
+
+		return nil
+	})
+}
+
+// replaceEntries replaces all entries of the given kind & returns whether they differ from the previous ones.
+func (c Client) replaceEntries(tx *sql.Tx, kind string, nodes []yaml.Node) (bool, error) {
+	previousNodes, err := c.readEntries(tx, kind)
+	if err != nil {
+		return false, err
+	}
+
+	previous := make([]string, len(previousNodes))
+	for i := range previousNodes {
+		if previous[i], err = encodeYAML(&previousNodes[i]); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM entries WHERE suite_id = ? AND kind = ?", c.suiteID, kind); err != nil {
+		return false, errors.NewSystemError("unable to delete %s entries: %s", kind, err)
+	}
+
+	changed := len(previous) != len(nodes)
+	for i := range nodes {
+		encoded, err := encodeYAML(&nodes[i])
+		if err != nil {
+			return false, err
+		}
+
+		if i >= len(previous) || previous[i] != encoded {
+			changed = true
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO entries (suite_id, kind, position, entry) VALUES (?, ?, ?, ?)", c.suiteID, kind, i, encoded,
+		)
+		if err != nil {
+			return false, errors.NewSystemError("unable to store %s entry: %s", kind, err)
+		}
+	}
+
+	return changed, nil
+}
+
+func (c Client) mergeDatabaseTimings(timings map[string]time.Duration) error {
+	var currentTimings map[string]time.Duration
+
+	err := c.transaction(func(tx *sql.Tx) error {
+		for file, duration := range timings {
+			_, err := tx.Exec(
+				"INSERT INTO timings (suite_id, file, duration_ns) VALUES (?, ?, ?) "+
+					"ON CONFLICT (suite_id, file) DO UPDATE SET duration_ns = excluded.duration_ns",
+				c.suiteID, file, int64(duration),
+			)
+			if err != nil {
+				return errors.NewSystemError("unable to store timing of %q: %s", file, err)
+			}
+		}
+
+		var err error
+		currentTimings, err = c.readDatabaseTimings(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.Timings != nil {
+		for file := range c.Timings {
+			delete(c.Timings, file)
+		}
+		for file, duration := range currentTimings {
+			c.Timings[file] = duration
+		}
+	}
+
+	return nil
+}
+
+// databaseHistory assembles the history from the flaky tests of the last `maxHistoryEntries` runs.
+func (c Client) databaseHistory() ([]HistoryEntry, error) {
+	rows, err := c.db.Query(
+		"SELECT runs.id, runs.recorded_at, tests.identity FROM "+
+			"(SELECT id, recorded_at FROM runs WHERE suite_id = ? ORDER BY id DESC LIMIT ?) AS runs "+
+			"LEFT JOIN tests ON tests.run_id = runs.id AND tests.flaky = 1 "+
+			"ORDER BY runs.id, tests.id",
+		c.suiteID, maxHistoryEntries,
+	)
+	if err != nil {
+		return nil, errors.NewSystemError("unable to read history: %s", err)
+	}
+	defer rows.Close()
+
+	history := make([]HistoryEntry, 0)
+	lastRunID := int64(-1)
+	for rows.Next() {
+		var runID int64
+		var recordedAt string
+		var identity sql.NullString
+		if err := rows.Scan(&runID, &recordedAt, &identity); err != nil {
+			return nil, errors.NewSystemError("unable to read history: %s", err)
+		}
+
+		if runID != lastRunID {
+			timestamp, err := time.Parse(time.RFC3339Nano, recordedAt)
+			if err != nil {
+				return nil, errors.NewInputError("invalid run timestamp %q: %s", recordedAt, err)
+			}
+
+			history = append(history, HistoryEntry{RecordedAt: timestamp, FlakyTests: make([]yaml.Node, 0)})
+			lastRunID = runID
+		}
+
+		if identity.Valid {
+			node, err := decodeYAMLMapping(identity.String)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid test identity")
+			}
+
+			entry := &history[len(history)-1]
+			entry.FlakyTests = append(entry.FlakyTests, node)
+		}
+	}
+
+	return history, errors.WithStack(rows.Err())
+}
+
+// recordDatabaseHistory stores a history entry as a run that consists of its flaky tests only. This is used when
+// importing the history of the YAML files.
+func (c Client) recordDatabaseHistory(entry HistoryEntry) error {
+	return c.transaction(func(tx *sql.Tx) error {
+		runID, err := c.insertRun(tx, entry.RecordedAt)
+		if err != nil {
+			return err
+		}
+
+		for i := range entry.FlakyTests {
+			identity, err := encodeYAML(&entry.FlakyTests[i])
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("INSERT INTO tests (run_id, identity, flaky) VALUES (?, ?, 1)", runID, identity)
+			if err != nil {
+				return errors.NewSystemError("unable to store flaky test: %s", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// recordDatabaseRun stores every test of a run together with all of its attempts.
+func (c Client) recordDatabaseRun(testResults v1.TestResults, recordedAt time.Time) error {
+	return c.transaction(func(tx *sql.Tx) error {
+		runID, err := c.insertRun(tx, recordedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, test := range testResults.Tests {
+			if err := insertTest(tx, runID, test, testResults.Framework); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c Client) insertRun(tx *sql.Tx, recordedAt time.Time) (int64, error) {
+	result, err := tx.Exec(
+		"INSERT INTO runs (suite_id, recorded_at) VALUES (?, ?)", c.suiteID, recordedAt.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return 0, errors.NewSystemError("unable to store run: %s", err)
+	}
+
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return 0, errors.NewSystemError("unable to store run: %s", err)
+	}
+
+	_, err = tx.Exec(
+		"DELETE FROM runs WHERE suite_id = ? AND id NOT IN "+
+			"(SELECT id FROM runs WHERE suite_id = ? ORDER BY id DESC LIMIT ?)",
+		c.suiteID, c.suiteID, maxStoredRuns,
+	)
+	if err != nil {
+		return 0, errors.NewSystemError("unable to delete old runs: %s", err)
+	}
+
+	return runID, nil
+}
+
+func insertTest(tx *sql.Tx, runID int64, test v1.Test, framework v1.Framework) error {
+	identityNode := IdentifyTest(test, framework).ToYAML()
+	identity, err := encodeYAML(&identityNode)
+	if err != nil {
+		return err
+	}
+
+	var file sql.NullString
+	if test.Location != nil {
+		file = sql.NullString{String: test.Location.File, Valid: true}
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO tests (run_id, identity, name, file, status, flaky) VALUES (?, ?, ?, ?, ?, ?)",
+		runID, identity, test.Name, file, string(test.Attempt.Status.Kind), test.Flaky(),
+	)
+	if err != nil {
+		return errors.NewSystemError("unable to store test %q: %s", test.Name, err)
+	}
+
+	testID, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewSystemError("unable to store test %q: %s", test.Name, err)
+	}
+
+	attempts := append(append([]v1.TestAttempt{}, test.PastAttempts...), test.Attempt)
+	for i, attempt := range attempts {
+		var duration sql.NullInt64
+		if attempt.Duration != nil {
+			duration = sql.NullInt64{Int64: int64(*attempt.Duration), Valid: true}
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO attempts (test_id, number, status, duration_ns) VALUES (?, ?, ?, ?)",
+			testID, i+1, string(attempt.Status.Kind), duration,
+		)
+		if err != nil {
+			return errors.NewSystemError("unable to store attempt of test %q: %s", test.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// encodeYAML encodes a node using the flow style, e.g. `{description: My test, file: ./test.rb}`
+func encodeYAML(node *yaml.Node) (string, error) {
+	flowNode := *node
+	flowNode.Style = yaml.FlowStyle
+
+	encoded, err := yaml.Marshal(&flowNode)
+	if err != nil {
+		return "", errors.NewInternalError("unable to encode YAML: %s", err)
+	}
+
+	return strings.TrimSpace(string(encoded)), nil
+}
+
+func decodeYAMLMapping(encoded string) (yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(encoded), &document); err != nil {
+		return yaml.Node{}, errors.NewInputError("unable to parse %q: %s", encoded, err)
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) != 1 || document.Content[0].Kind != yaml.MappingNode {
+		return yaml.Node{}, errors.NewInputError("%q is not a map", encoded)
+	}
+
+	node := *document.Content[0]
+	node.Style = 0
+
+	return node, nil
+}
@@ -0,0 +1,252 @@
+package local_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQLite backend client", func() {
+	const suiteID = "suite-id"
+
+	var (
+		err          error
+		client       local.Client
+		databasePath string
+	)
+
+	open := func(suiteID string) local.Client {
+		client, err := local.NewSQLiteClient(zap.NewNop().Sugar(), databasePath, suiteID)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(client.Close)
+		return client
+	}
+
+	entry := func(values ...string) local.Map {
+		m := local.Map{Order: make([]string, 0), Values: make(map[string]string)}
+		for i := 0; i < len(values); i += 2 {
+			m.Order = append(m.Order, values[i])
+			m.Values[values[i]] = values[i+1]
+		}
+		return m
+	}
+
+	BeforeEach(func() {
+		databasePath = filepath.Join(GinkgoT().TempDir(), "captain.db")
+		client = open(suiteID)
+	})
+
+	It("starts out empty", func() {
+		Expect(client.IsDatabase()).To(BeTrue())
+		Expect(client.Flakes).To(BeEmpty())
+		Expect(client.Quarantines).To(BeEmpty())
+		Expect(client.Timings).To(BeEmpty())
+
+		history, err := client.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(BeEmpty())
+	})
+
+	Describe("Flush", func() {
+		BeforeEach(func() {
+			client.Flakes = append(client.Flakes, entry("description", "flaky: test", "file", "./test.rb").ToYAML())
+			client.Quarantines = append(
+				client.Quarantines,
+				entry("description", "quarantined test", "expires_at", "2099-01-01").ToYAML(),
+			)
+			err = client.Flush()
+		})
+
+		It("persists flakes & quarantines in order", func() {
+			Expect(err).ToNot(HaveOccurred())
+
+			reopened := open(suiteID)
+			Expect(reopened.Flakes).To(HaveLen(1))
+			Expect(local.NewMapFromYAML(reopened.Flakes[0])).To(Equal(entry(
+				"description", "flaky: test", "file", "./test.rb",
+			)))
+
+			runConfiguration, err := reopened.GetRunConfiguration(context.Background(), suiteID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runConfiguration.FlakyTests).To(HaveLen(1))
+			Expect(runConfiguration.FlakyTests[0].CompositeIdentifier).To(Equal("flaky: test -captain- ./test.rb"))
+			Expect(runConfiguration.QuarantinedTests).To(HaveLen(1))
+			Expect(runConfiguration.QuarantinedTests[0].CompositeIdentifier).To(Equal("quarantined test"))
+			Expect(runConfiguration.QuarantinedTests[0].QuarantinedAt).NotTo(BeEmpty())
+		})
+
+		It("keeps the entries of different suites apart", func() {
+			other := open("other-suite")
+			Expect(other.Flakes).To(BeEmpty())
+			Expect(other.Quarantines).To(BeEmpty())
+		})
+
+		It("replaces the previously stored entries", func() {
+			reopened := open(suiteID)
+			reopened.Flakes = reopened.Flakes[:0]
+			Expect(reopened.Flush()).To(Succeed())
+
+			Expect(open(suiteID).Flakes).To(BeEmpty())
+			Expect(open(suiteID).Quarantines).To(HaveLen(1))
+		})
+	})
+
+	Describe("adding & removing entries", func() {
+		It("keeps the entries that other clients added in the meantime", func() {
+			other := open(suiteID)
+
+			Expect(client.AddQuarantines(entry("description", "first test").ToYAML())).To(Succeed())
+			Expect(other.AddQuarantines(entry("description", "second test").ToYAML())).To(Succeed())
+
+			quarantines := open(suiteID).Quarantines
+			Expect(quarantines).To(HaveLen(2))
+			Expect(local.NewMapFromYAML(quarantines[0])).To(Equal(entry("description", "first test")))
+			Expect(local.NewMapFromYAML(quarantines[1])).To(Equal(entry("description", "second test")))
+		})
+
+		It("only removes the entries of the given test", func() {
+			Expect(client.AddFlakes(
+				entry("description", "first test").ToYAML(),
+				entry("description", "second test", "owner", "me").ToYAML(),
+			)).To(Succeed())
+
+			other := open(suiteID)
+			Expect(other.AddFlakes(entry("description", "third test").ToYAML())).To(Succeed())
+			Expect(client.RemoveFlakes(entry("description", "second test"))).To(Succeed())
+
+			flakes := open(suiteID).Flakes
+			Expect(flakes).To(HaveLen(2))
+			Expect(local.NewMapFromYAML(flakes[0])).To(Equal(entry("description", "first test")))
+			Expect(local.NewMapFromYAML(flakes[1])).To(Equal(entry("description", "third test")))
+		})
+
+		It("updates the modification time of the quarantines", func() {
+			Expect(client.AddQuarantines(entry("description", "quarantined test").ToYAML())).To(Succeed())
+
+			runConfiguration, err := open(suiteID).GetRunConfiguration(context.Background(), suiteID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runConfiguration.QuarantinedTests).To(HaveLen(1))
+			Expect(runConfiguration.QuarantinedTests[0].QuarantinedAt).NotTo(BeEmpty())
+		})
+	})
+
+	Describe("UpdateTestResults", func() {
+		BeforeEach(func() {
+			firstDuration := time.Second
+			secondDuration := 2 * time.Second
+
+			_, err = client.UpdateTestResults(context.Background(), suiteID, v1.TestResults{
+				Framework: v1.RubyRSpecFramework,
+				Tests: []v1.Test{
+					{
+						Name:     "flaky test",
+						Location: &v1.Location{File: "./flaky_spec.rb"},
+						Attempt: v1.TestAttempt{
+							Duration: &secondDuration,
+							Status:   v1.NewSuccessfulTestStatus(),
+						},
+						PastAttempts: []v1.TestAttempt{{
+							Duration: &firstDuration,
+							Status:   v1.NewFailedTestStatus(nil, nil, nil),
+						}},
+					},
+					{
+						Name:     "stable test",
+						Location: &v1.Location{File: "./stable_spec.rb"},
+						Attempt: v1.TestAttempt{
+							Duration: &firstDuration,
+							Status:   v1.NewSuccessfulTestStatus(),
+						},
+					},
+				},
+			})
+		})
+
+		It("stores the timings", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.Timings).To(Equal(map[string]time.Duration{
+				"./flaky_spec.rb":  2 * time.Second,
+				"./stable_spec.rb": time.Second,
+			}))
+			Expect(open(suiteID).Timings).To(Equal(client.Timings))
+		})
+
+		It("records the flaky tests of the run", func() {
+			history, err := client.History()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].FlakyTests).To(HaveLen(1))
+			Expect(local.NewMapFromYAML(history[0].FlakyTests[0])).To(Equal(entry(
+				"description", "flaky test", "file", "./flaky_spec.rb",
+			)))
+		})
+
+		It("stores the test results of the latest run per suite", func() {
+			storedResults, err := open(suiteID).StoredResults()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storedResults).NotTo(BeNil())
+			Expect(storedResults.Tests).To(HaveLen(2))
+
+			storedResults, err = open("other-suite-id").StoredResults()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storedResults).To(BeNil())
+		})
+
+		It("records runs without flaky tests as well", func() {
+			_, err = client.UpdateTestResults(context.Background(), suiteID, v1.TestResults{})
+			Expect(err).ToNot(HaveOccurred())
+
+			history, err := client.History()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(2))
+			Expect(history[1].FlakyTests).To(BeEmpty())
+		})
+	})
+
+	Describe("StoreResults", func() {
+		It("keeps the results of different partitions apart", func() {
+			first := v1.TestResults{Tests: []v1.Test{{Name: "first partition"}}}
+			second := v1.TestResults{Tests: []v1.Test{{Name: "second partition"}}}
+			Expect(client.WithPartition("0/2").StoreResults(first, time.Now())).To(Succeed())
+			Expect(client.WithPartition("1/2").StoreResults(second, time.Now())).To(Succeed())
+
+			storedResults, err := open(suiteID).WithPartition("0/2").StoredResults()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storedResults.Tests).To(Equal(first.Tests))
+
+			storedResults, err = open(suiteID).StoredResults()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storedResults).To(BeNil())
+
+			allStoredResults, err := open(suiteID).AllStoredResults()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allStoredResults).To(HaveLen(2))
+			Expect(allStoredResults["1/2"].Tests).To(Equal(second.Tests))
+		})
+	})
+
+	Describe("RecordHistory", func() {
+		It("stores the flaky tests of the entry", func() {
+			recordedAt := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+			Expect(client.RecordHistory(local.HistoryEntry{
+				RecordedAt: recordedAt,
+				FlakyTests: []yaml.Node{entry("description", "some test").ToYAML()},
+			})).To(Succeed())
+
+			history, err := client.History()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].RecordedAt).To(Equal(recordedAt))
+			Expect(local.NewMapFromYAML(history[0].FlakyTests[0])).To(Equal(entry("description", "some test")))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+const (
+	// MatchExact requires identity values to be equal to the ones of a test. This is the default.
+	MatchExact = "exact"
+	// MatchGlob treats identity values as glob patterns. `*` matches any sequence of characters except `/`, `**`
+	// matches any sequence of characters, and `?` matches any single character except `/`.
+	MatchGlob = "glob"
+	// MatchRegex treats identity values as regular expressions. These need to match the entire value.
+	MatchRegex = "regex"
+)
+
+var compiledPatterns sync.Map
+
+// IsPattern returns whether identity values of the given match kind are patterns rather than exact values.
+func IsPattern(match string) bool {
+	return match == MatchGlob || match == MatchRegex
+}
+
+// ValidateMatch returns an error if `match` is not a supported match kind.
+func ValidateMatch(match string) error {
+	switch match {
+	case "", MatchExact, MatchGlob, MatchRegex:
+		return nil
+	default:
+		return errors.NewInputError("unsupported match %q, expected one of %q, %q, or %q", match, MatchExact,
+			MatchGlob, MatchRegex)
+	}
+}
+
+// CompilePattern compiles a glob or regex pattern into a regular expression that matches entire values. Compiled
+// patterns are cached, as the same patterns are usually evaluated against every test of a suite.
+func CompilePattern(match, pattern string) (*regexp.Regexp, error) {
+	key := match + "\x00" + pattern
+	if compiled, ok := compiledPatterns.Load(key); ok {
+		return compiled.(*regexp.Regexp), nil
+	}
+
+	if !IsPattern(match) {
+		return nil, errors.NewInternalError("%q is not a pattern match", match)
+	}
+
+	compiled, err := regexp.Compile("^(?:" + PatternExpression(match, pattern) + ")$")
+	if err != nil {
+		return nil, errors.NewInputError("invalid %s pattern %q: %s", match, pattern, err)
+	}
+
+	compiledPatterns.Store(key, compiled)
+	return compiled, nil
+}
+
+// PatternExpression returns the unanchored regular expression that is equivalent to an identity value of the given
+// match kind. Exact values are quoted.
+func PatternExpression(match, pattern string) string {
+	switch match {
+	case MatchGlob:
+		return globToRegex(pattern)
+	case MatchRegex:
+		return pattern
+	default:
+		return regexp.QuoteMeta(pattern)
+	}
+}
+
+func globToRegex(glob string) string {
+	var expression strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expression.WriteString("[^/]*")
+		case glob[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	return expression.String()
+}
+
+// IdentityValue returns the value of the given identity component, which is a pattern in case of pattern matches.
+func (t Test) IdentityValue(component string) (string, bool) {
+	values := t.IdentityValues
+	if len(values) == 0 {
+		values = strings.Split(t.CompositeIdentifier, " -captain- ")
+	}
+
+	if len(values) != len(t.IdentityComponents) {
+		return "", false
+	}
+
+	for i, identityComponent := range t.IdentityComponents {
+		if identityComponent == component {
+			return values[i], true
+		}
+	}
+
+	return "", false
+}
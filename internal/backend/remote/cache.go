@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+// cacheEntry is the on-disk representation of a cached API response.
+type cacheEntry struct {
+	CachedAt time.Time       `json:"cached_at"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// writeCache stores a successful API response of a test suite. Caching is best-effort, which is why errors are only
+// logged.
+func (c Client) writeCache(testSuite, name string, data any) {
+	if err := c.writeCacheFile(testSuite, name, data); err != nil {
+		c.Log.Debugf("Unable to update the cache at %q: %s", c.CacheDirectory, err)
+	}
+}
+
+func (c Client) writeCacheFile(testSuite, name string, data any) error {
+	encodedData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(cacheEntry{CachedAt: time.Now().UTC(), Data: encodedData})
+	if err != nil {
+		return err
+	}
+
+	cacheDirectory := filepath.Join(c.CacheDirectory, testSuite)
+	if err := os.MkdirAll(cacheDirectory, 0o755); err != nil {
+		return err
+	}
+
+	// Writing to a temporary file first ensures that concurrent runs never read a partially written cache
+	fd, err := os.CreateTemp(cacheDirectory, name+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = fd.Write(buf)
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(fd.Name())
+		return err
+	}
+
+	return os.Rename(fd.Name(), filepath.Join(cacheDirectory, name))
+}
+
+// unavailable returns whether a request failed because Captain couldn't be reached or was unavailable, see
+// `statusError`. Only then is the cache used, as other failures (e.g. an invalid access token or an unknown suite) are
+// unlikely to go away by themselves and shouldn't be masked by stale data.
+func unavailable(err error) bool {
+	_, ok := errors.AsSystemError(err)
+	return ok
+}
+
+// readCache decodes the cached API response of a test suite into `data` after a request failed with `fetchErr`. It
+// returns false if there is no cached response or if it's older than the configured maximum age.
+func (c Client) readCache(testSuite, name, description string, fetchErr error, data any) bool {
+	buf, err := os.ReadFile(filepath.Join(c.CacheDirectory, testSuite, name))
+	if err != nil {
+		c.Log.Debugf("Unable to read the cached %s: %s", description, err)
+		return false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(buf, &entry); err != nil {
+		c.Log.Debugf("Unable to parse the cached %s: %s", description, err)
+		return false
+	}
+
+	if c.CacheMaxAge > 0 && time.Since(entry.CachedAt) > c.CacheMaxAge {
+		c.Log.Warnf(
+			"Unable to fetch the %s from Captain. The cached %s from %s is too old to be used instead.",
+			description, description, entry.CachedAt.Format(time.RFC3339),
+		)
+		return false
+	}
+
+	if err := json.Unmarshal(entry.Data, data); err != nil {
+		c.Log.Debugf("Unable to parse the cached %s: %s", description, err)
+		return false
+	}
+
+	c.Log.Warnf("Unable to fetch the %s from Captain: %s", description, fetchErr)
+	c.Log.Warnf("Captain is using the cached %s from %s instead.", description, entry.CachedAt.Format(time.RFC3339))
+	return true
+}
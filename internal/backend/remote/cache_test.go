@@ -0,0 +1,161 @@
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/rwx-research/captain-cli/internal/backend/remote"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Caching API responses", func() {
+	const suiteID = "suite-id"
+
+	var (
+		apiClient      remote.Client
+		apiAvailable   bool
+		statusCode     int
+		cacheDirectory string
+		recordedLogs   *observer.ObservedLogs
+	)
+
+	mockRoundTripper := func(req *http.Request) (*http.Response, error) {
+		if !apiAvailable {
+			return nil, errors.NewSystemError("unable to perform HTTP request")
+		}
+
+		body := `{"file_timings":[{"file_path":"./a_spec.rb","duration_in_nanoseconds":1000000000}]}`
+		if strings.HasSuffix(req.URL.Path, "/run_configuration") {
+			body = `{"quarantined_tests":[{"composite_identifier":"quarantined test","identity_components":["name"]}]}`
+		}
+
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+
+	BeforeEach(func() {
+		apiAvailable = true
+		statusCode = http.StatusOK
+		cacheDirectory = GinkgoT().TempDir()
+
+		var core zapcore.Core
+		core, recordedLogs = observer.New(zapcore.InfoLevel)
+		apiClient = remote.Client{ClientConfig: remote.ClientConfig{
+			Log: zaptest.NewLogger(GinkgoT(), zaptest.WrapOptions(
+				zap.WrapCore(func(original zapcore.Core) zapcore.Core { return core }),
+			)).Sugar(),
+			CacheDirectory: cacheDirectory,
+			CacheMaxAge:    time.Hour,
+		}, RoundTrip: mockRoundTripper}
+	})
+
+	Context("after a successful request", func() {
+		BeforeEach(func() {
+			_, err := apiClient.GetRunConfiguration(context.Background(), suiteID)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = apiClient.GetTestTimingManifest(context.Background(), suiteID)
+			Expect(err).NotTo(HaveOccurred())
+
+			apiAvailable = false
+		})
+
+		It("falls back to the cached run configuration", func() {
+			runConfiguration, err := apiClient.GetRunConfiguration(context.Background(), suiteID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runConfiguration.QuarantinedTests).To(HaveLen(1))
+			Expect(runConfiguration.QuarantinedTests[0].CompositeIdentifier).To(Equal("quarantined test"))
+
+			messages := make([]string, 0)
+			for _, log := range recordedLogs.All() {
+				messages = append(messages, log.Message)
+			}
+			Expect(messages).To(ContainElement(HavePrefix("Unable to fetch the run configuration from Captain")))
+			Expect(messages).To(ContainElement(MatchRegexp(
+				`^Captain is using the cached run configuration from \d{4}-\d{2}-\d{2}T`,
+			)))
+		})
+
+		It("falls back to the cached test timings", func() {
+			fileTimings, err := apiClient.GetTestTimingManifest(context.Background(), suiteID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileTimings).To(Equal([]testing.TestFileTiming{{Filepath: "./a_spec.rb", Duration: time.Second}}))
+		})
+
+		It("falls back to the cache if Captain is unavailable", func() {
+			apiAvailable = true
+
+			for _, statusCode = range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+				runConfiguration, err := apiClient.GetRunConfiguration(context.Background(), suiteID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runConfiguration.QuarantinedTests).To(HaveLen(1))
+			}
+		})
+
+		It("doesn't fall back to the cache if the request was rejected", func() {
+			apiAvailable = true
+
+			for _, statusCode = range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+				_, err := apiClient.GetRunConfiguration(context.Background(), suiteID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("Status Code %d", statusCode)))
+
+				_, err = apiClient.GetTestTimingManifest(context.Background(), suiteID)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("doesn't use the cache of other suites", func() {
+			_, err := apiClient.GetRunConfiguration(context.Background(), "other-suite")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("doesn't use caches that exceed the maximum age", func() {
+			cacheFile := filepath.Join(cacheDirectory, suiteID, "run_configuration.json")
+			buf, err := os.ReadFile(cacheFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			entry := make(map[string]any)
+			Expect(json.Unmarshal(buf, &entry)).To(Succeed())
+			entry["cached_at"] = time.Now().Add(-2 * time.Hour)
+			buf, err = json.Marshal(entry)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(cacheFile, buf, 0o600)).To(Succeed())
+
+			_, err = apiClient.GetRunConfiguration(context.Background(), suiteID)
+			Expect(err).To(HaveOccurred())
+			Expect(recordedLogs.All()[0].Message).To(ContainSubstring("is too old to be used instead"))
+		})
+	})
+
+	It("returns the original error without a cache", func() {
+		apiAvailable = false
+		_, err := apiClient.GetRunConfiguration(context.Background(), suiteID)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to perform HTTP request"))
+	})
+
+	It("doesn't cache anything without a cache directory", func() {
+		apiClient.CacheDirectory = ""
+		_, err := apiClient.GetRunConfiguration(context.Background(), suiteID)
+		Expect(err).NotTo(HaveOccurred())
+
+		entries, err := os.ReadDir(cacheDirectory)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+})
@@ -33,7 +33,15 @@ func NewClient(cfg ClientConfig) (Client, error) {
 		return Client{}, err
 	}
 
-	client := &http.Client{}
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return Client{}, err
+	}
+
+	client := &http.Client{Timeout: cfg.Timeout, Transport: transport}
+	// The timeout only applies to the Captain API. Uploads of large test results can legitimately take longer, which is
+	// why they are bounded far more generously.
+	uploadClient := &http.Client{Timeout: uploadTimeout, Transport: transport}
 
 	roundTrip := func(req *http.Request) (*http.Response, error) {
 		// This is a bit hacky. In theory, this roundtripper should solely be used for accessing Captain's own API.
@@ -44,7 +52,8 @@ func NewClient(cfg ClientConfig) (Client, error) {
 		// c) move all of this sequental logic out of the API layer
 		// None of these options are great - having this special case for the S3 upload seems the least bad (given
 		// that there is only a single occurrence)
-		if !strings.HasSuffix(req.URL.Host, "amazonaws.com") {
+		isUpload := strings.HasSuffix(req.URL.Host, "amazonaws.com")
+		if !isUpload {
 			req.URL.Scheme = "https"
 			if cfg.Insecure {
 				req.URL.Scheme = "http"
@@ -62,7 +71,12 @@ func NewClient(cfg ClientConfig) (Client, error) {
 			cfg.Log.Debugf("Executing following HTTP request:\n\n%s\n", sanitizedDump)
 		}
 
-		resp, err := client.Do(req)
+		httpClient := client
+		if isUpload {
+			httpClient = uploadClient
+		}
+
+		resp, err := httpClient.Do(req)
 		if err != nil {
 			return resp, errors.NewSystemError("unable to perform HTTP request to %q: %s", req.URL, err)
 		}
@@ -79,9 +93,37 @@ func NewClient(cfg ClientConfig) (Client, error) {
 	return Client{cfg, roundTrip}, nil
 }
 
+// GetTestTimingManifest returns the test file timings of a test suite. If Captain is unavailable, the cached timings of
+// a previous call are returned instead (if a cache directory is configured).
 func (c Client) GetTestTimingManifest(
 	ctx context.Context,
 	testSuiteIdentifier string,
+) ([]testing.TestFileTiming, error) {
+	fileTimings, err := c.fetchTestTimingManifest(ctx, testSuiteIdentifier)
+	if c.CacheDirectory == "" {
+		return fileTimings, err
+	}
+
+	if err == nil {
+		c.writeCache(testSuiteIdentifier, timingManifestCacheFile, fileTimings)
+		return fileTimings, nil
+	}
+
+	if !unavailable(err) {
+		return nil, err
+	}
+
+	var cachedFileTimings []testing.TestFileTiming
+	if !c.readCache(testSuiteIdentifier, timingManifestCacheFile, "test timings", err, &cachedFileTimings) {
+		return nil, err
+	}
+
+	return cachedFileTimings, nil
+}
+
+func (c Client) fetchTestTimingManifest(
+	ctx context.Context,
+	testSuiteIdentifier string,
 ) ([]testing.TestFileTiming, error) {
 	endpoint := hostEndpointCompat(c, "/api/test_suites/timing_manifest")
 
@@ -95,18 +137,14 @@ func (c Client) GetTestTimingManifest(
 	queryValues.Add("commit_sha", c.Provider.CommitSha)
 	req.URL.RawQuery = queryValues.Encode()
 
-	resp, err := c.RoundTrip(req)
+	resp, err := c.roundTripWithRetries(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return nil, errors.NewInternalError(
-			"API backend encountered an error. Endpoint was %q, Status Code %d",
-			endpoint,
-			resp.StatusCode,
-		)
+		return nil, statusError(endpoint, resp)
 	}
 
 	respBody := struct {
@@ -125,6 +163,17 @@ func (c Client) GetTestTimingManifest(
 	return respBody.FileTimings, nil
 }
 
+// statusError reports an unsuccessful response of the Captain API. Responses that are retried (see `retryable`) mean
+// that Captain is unavailable, which is reported as a system error rather than an internal one.
+func statusError(endpoint string, resp *http.Response) error {
+	newError := errors.NewInternalError
+	if retryable(resp, nil) {
+		newError = errors.NewSystemError
+	}
+
+	return newError("API backend encountered an error. Endpoint was %q, Status Code %d", endpoint, resp.StatusCode)
+}
+
 func (c Client) logError(err error) error {
 	c.Log.Errorf(err.Error())
 	return err
@@ -164,7 +213,7 @@ func (c Client) putJSON(ctx context.Context, endpoint string, body any) (*http.R
 
 	req.Header.Set(headerContentType, contentTypeJSON)
 
-	resp, err := c.RoundTrip(req)
+	resp, err := c.roundTripWithRetries(req)
 	if err != nil {
 		return nil, err
 	}
@@ -172,10 +221,38 @@ func (c Client) putJSON(ctx context.Context, endpoint string, body any) (*http.R
 	return resp, nil
 }
 
-// GetRunConfiguration returns the runtime configuration for the run command (e.g. quarantined and flaky tests)
+// GetRunConfiguration returns the runtime configuration for the run command (e.g. quarantined and flaky tests). If
+// Captain is unavailable, the cached configuration of a previous call is returned instead (if a cache directory is
+// configured).
 func (c Client) GetRunConfiguration(
 	ctx context.Context,
 	testSuiteIdentifier string,
+) (backend.RunConfiguration, error) {
+	runConfiguration, err := c.fetchRunConfiguration(ctx, testSuiteIdentifier)
+	if c.CacheDirectory == "" {
+		return runConfiguration, err
+	}
+
+	if err == nil {
+		c.writeCache(testSuiteIdentifier, runConfigurationCacheFile, runConfiguration)
+		return runConfiguration, nil
+	}
+
+	if !unavailable(err) {
+		return backend.RunConfiguration{}, err
+	}
+
+	var cachedRunConfiguration backend.RunConfiguration
+	if !c.readCache(testSuiteIdentifier, runConfigurationCacheFile, "run configuration", err, &cachedRunConfiguration) {
+		return backend.RunConfiguration{}, err
+	}
+
+	return cachedRunConfiguration, nil
+}
+
+func (c Client) fetchRunConfiguration(
+	ctx context.Context,
+	testSuiteIdentifier string,
 ) (backend.RunConfiguration, error) {
 	endpoint := hostEndpointCompat(c, "/api/test_suites/run_configuration")
 
@@ -188,18 +265,14 @@ func (c Client) GetRunConfiguration(
 	queryValues.Add("test_suite_identifier", testSuiteIdentifier)
 	req.URL.RawQuery = queryValues.Encode()
 
-	resp, err := c.RoundTrip(req)
+	resp, err := c.roundTripWithRetries(req)
 	if err != nil {
 		return backend.RunConfiguration{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return backend.RunConfiguration{}, errors.NewInternalError(
-			"API backend encountered an error. Endpoint was %q, Status Code %d",
-			endpoint,
-			resp.StatusCode,
-		)
+		return backend.RunConfiguration{}, statusError(endpoint, resp)
 	}
 
 	runConfiguration := backend.RunConfiguration{}
@@ -1,6 +1,8 @@
 package remote
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
@@ -17,6 +19,28 @@ type ClientConfig struct {
 	Token    string
 	Provider providers.Provider
 	NewUUID  func() (uuid.UUID, error)
+	// SpoolDirectory is where test results are kept if they can't be uploaded. Spooling is disabled if empty.
+	SpoolDirectory string
+	// CacheDirectory is where the run configuration & test timings are cached. They are used as a fallback whenever
+	// the Captain API is unavailable. Caching is disabled if empty.
+	CacheDirectory string
+	// CacheMaxAge is the maximum age of cached data that is still used as a fallback.
+	CacheMaxAge time.Duration
+	// Timeout limits the duration of a single HTTP request to the Captain API, including reading the response body. It
+	// doesn't apply to uploads of test results to S3.
+	Timeout time.Duration
+	// MaxRetries is the number of times idempotent requests are retried after a connection error or a 5xx response.
+	MaxRetries int
+	// RetryBackoff is the base delay between retries. It doubles with every retry & is jittered.
+	RetryBackoff time.Duration
+	// Proxy is the URL of the HTTP(S) proxy to use. If empty, the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment
+	// variables are respected.
+	Proxy string
+	// CACertFile is a PEM bundle of certificate authorities that are trusted in addition to the system ones.
+	CACertFile string
+	// ClientCertFile & ClientKeyFile are a PEM encoded certificate & key that are presented to the server (mTLS).
+	ClientCertFile string
+	ClientKeyFile  string
 }
 
 // Validate checks the configuration for errors
@@ -34,6 +58,22 @@ func (cfg ClientConfig) Validate() error {
 		)
 	}
 
+	if (cfg.ClientCertFile == "") != (cfg.ClientKeyFile == "") {
+		return errors.NewConfigurationError(
+			"Incomplete client certificate configuration",
+			"Only one of a client certificate and a client key was set.",
+			"Please set both 'cloud.client-cert' and 'cloud.client-key' in the config file, or neither of them.",
+		)
+	}
+
+	if cfg.MaxRetries < 0 {
+		return errors.NewConfigurationError(
+			"Invalid number of retries",
+			"The number of retries for requests to the Captain API can't be negative.",
+			"Please set 'cloud.retries' in the config file to 0 or a positive number.",
+		)
+	}
+
 	return nil
 }
 
@@ -47,5 +87,17 @@ func (cfg ClientConfig) WithDefaults() ClientConfig {
 		cfg.NewUUID = uuid.NewRandom
 	}
 
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	if cfg.CacheMaxAge == 0 {
+		cfg.CacheMaxAge = defaultCacheMaxAge
+	}
+
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+
 	return cfg
 }
@@ -1,9 +1,25 @@
 package remote
 
-import "regexp"
+import (
+	"regexp"
+	"time"
+)
+
+// DefaultMaxRetries is the number of retries that the CLI uses unless configured otherwise.
+const DefaultMaxRetries = 3
 
 const (
-	defaultHost = "cloud.rwx.com"
+	defaultHost         = "cloud.rwx.com"
+	defaultTimeout      = time.Minute
+	defaultRetryBackoff = time.Second
+	defaultCacheMaxAge  = 7 * 24 * time.Hour
+
+	// uploadTimeout is a generous upper bound for uploads of test results to S3, which only keeps stalled uploads
+	// from hanging forever
+	uploadTimeout = 15 * time.Minute
+
+	runConfigurationCacheFile = "run_configuration.json"
+	timingManifestCacheFile   = "timing_manifest.json"
 
 	contentTypeJSON   = "application/json"
 	headerContentType = "Content-Type"
@@ -0,0 +1,68 @@
+package remote
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+// roundTripWithRetries performs an idempotent request. Connection errors & 5xx responses are retried up to
+// `MaxRetries` times, with an exponential & jittered backoff in between.
+func (c Client) roundTripWithRetries(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for retry := 0; ; retry++ {
+		attempt := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.NewInternalError("unable to rewind HTTP request body: %s", err)
+			}
+			attempt.Body = body
+		}
+
+		resp, err := c.RoundTrip(attempt)
+		if retry >= c.MaxRetries || !retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		if err != nil {
+			c.Log.Debugf("Retrying HTTP request after error: %s", err)
+		} else {
+			c.Log.Debugf("Retrying HTTP request after receiving status code %d", resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(c.backoff(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.NewSystemError("unable to perform HTTP request to %q: %s", req.URL, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// backoff returns a random delay between half & the full exponential backoff, which keeps CI nodes that failed at the
+// same time from retrying in lockstep.
+func (c Client) backoff(retry int) time.Duration {
+	backoff := c.RetryBackoff << retry
+	if backoff <= 0 {
+		return 0
+	}
+
+	//nolint:gosec // The jitter doesn't need to be cryptographically secure
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
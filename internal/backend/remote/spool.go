@@ -0,0 +1,179 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/backend"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+// spooledUpload is the on-disk representation of test results that couldn't be uploaded. It keeps the registration
+// payload around, which means that a replay is attributed to the original run (branch, commit, author, etc.) rather
+// than to the one performing the replay.
+type spooledUpload struct {
+	SpooledAt     time.Time               `json:"spooled_at"`
+	Registration  testResultsRegistration `json:"registration"`
+	OriginalPaths []string                `json:"original_paths"`
+	TestResults   json.RawMessage         `json:"test_results"`
+}
+
+// SpoolReplayResult summarizes a replay of the spool directory of a test suite.
+type SpoolReplayResult struct {
+	// Replayed is the number of spooled uploads that were re-sent successfully & removed from the spool.
+	Replayed int
+	// Remaining is the number of spooled uploads that still couldn't be re-sent. These stay in the spool.
+	Remaining int
+}
+
+func uploaded(uploadResults []backend.TestResultsUploadResult, err error) bool {
+	if err != nil || len(uploadResults) == 0 {
+		return false
+	}
+
+	for _, uploadResult := range uploadResults {
+		if !uploadResult.Uploaded {
+			return false
+		}
+	}
+
+	return true
+}
+
+// spool writes test results to the spool directory of their test suite & returns the path of the spooled file.
+func (c Client) spool(registration testResultsRegistration, buf []byte, originalPaths []string) (string, error) {
+	spoolDirectory := filepath.Join(c.SpoolDirectory, registration.TestSuiteIdentifier)
+	if err := os.MkdirAll(spoolDirectory, 0o755); err != nil {
+		return "", errors.NewSystemError("unable to create spool directory %q: %s", spoolDirectory, err)
+	}
+
+	spooledAt := time.Now().UTC()
+	buf, err := json.Marshal(spooledUpload{
+		SpooledAt:     spooledAt,
+		Registration:  registration,
+		OriginalPaths: originalPaths,
+		TestResults:   buf,
+	})
+	if err != nil {
+		return "", errors.NewInternalError("unable to encode spooled upload: %s", err)
+	}
+
+	// The timestamp prefix keeps spooled uploads in chronological order when listing the directory
+	fd, err := os.CreateTemp(spoolDirectory, fmt.Sprintf("%d-*.json", spooledAt.UnixNano()))
+	if err != nil {
+		return "", errors.NewSystemError("unable to create spool file: %s", err)
+	}
+	defer fd.Close()
+
+	if _, err := fd.Write(buf); err != nil {
+		_ = os.Remove(fd.Name())
+		return "", errors.NewSystemError("unable to write spool file %q: %s", fd.Name(), err)
+	}
+
+	return fd.Name(), nil
+}
+
+// ReplaySpool re-sends the test results that were spooled for a test suite, oldest first. Spooled uploads are removed
+// once they were re-sent successfully. If `ctx` is cancelled, the spooled uploads that weren't replayed yet are kept.
+func (c Client) ReplaySpool(ctx context.Context, testSuite string) (SpoolReplayResult, error) {
+	var result SpoolReplayResult
+
+	if testSuite == "" {
+		return result, errors.NewInputError("test suite name required")
+	}
+
+	if c.SpoolDirectory == "" {
+		return result, errors.NewConfigurationError(
+			"Missing spool directory",
+			"Replaying spooled uploads requires a spool directory.",
+			"The spool directory can be set using 'cloud.spool-directory' in the config file or the "+
+				"CAPTAIN_SPOOL_DIRECTORY environment variable.",
+		)
+	}
+
+	spoolDirectory := filepath.Join(c.SpoolDirectory, testSuite)
+	entries, err := os.ReadDir(spoolDirectory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return result, errors.NewSystemError("unable to read spool directory %q: %s", spoolDirectory, err)
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		paths = append(paths, filepath.Join(spoolDirectory, entry.Name()))
+	}
+	sort.Strings(paths)
+
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			result.Remaining += len(paths) - i
+			return result, errors.NewSystemError("replaying spooled uploads was interrupted: %s", err)
+		}
+
+		if err := c.replaySpooledUpload(ctx, path); err != nil {
+			c.Log.Warnf("unable to replay %q: %s", path, err)
+			result.Remaining++
+			continue
+		}
+
+		result.Replayed++
+	}
+
+	return result, nil
+}
+
+// replaySpooledUpload re-sends a single spooled upload. The spool file is moved aside while doing so, which means
+// that it can't be replayed again in case it can't be removed afterwards (or by a concurrent replay).
+func (c Client) replaySpooledUpload(ctx context.Context, path string) error {
+	sendingPath := path + ".sending"
+	if err := os.Rename(path, sendingPath); err != nil {
+		return errors.NewSystemError("unable to move spool file aside: %s", err)
+	}
+
+	if err := c.sendSpooledUpload(ctx, sendingPath); err != nil {
+		if renameErr := os.Rename(sendingPath, path); renameErr != nil {
+			c.Log.Warnf("unable to move spool file %q back: %s", sendingPath, renameErr)
+		}
+		return err
+	}
+
+	// The test results were sent, so a spool file that's left behind is merely garbage
+	if err := os.Remove(sendingPath); err != nil {
+		c.Log.Warnf("unable to remove spool file %q after replaying it: %s", sendingPath, err)
+	}
+
+	return nil
+}
+
+func (c Client) sendSpooledUpload(ctx context.Context, path string) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return errors.NewSystemError("unable to read spool file: %s", err)
+	}
+
+	var spooled spooledUpload
+	if err := json.Unmarshal(buf, &spooled); err != nil {
+		return errors.NewInputError("unable to parse spool file: %s", err)
+	}
+
+	uploadResults, err := c.uploadTestResults(ctx, spooled.Registration, spooled.TestResults, spooled.OriginalPaths)
+	if !uploaded(uploadResults, err) {
+		if err != nil {
+			return err
+		}
+		return errors.NewSystemError("test results were not uploaded")
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/rwx-research/captain-cli/internal/backend/remote"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/providers"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spooling Test Results", func() {
+	const suiteID = "suite-id"
+
+	var (
+		spoolDirectory string
+		registrations  []map[string]any
+		uploads        []string
+		apiAvailable   bool
+	)
+
+	mockUUID := uuid.MustParse("fff24366-af1d-43cc-ab32-8c9ed137cf09")
+
+	mockRoundTripper := func(req *http.Request) (*http.Response, error) {
+		if !apiAvailable {
+			return nil, errors.NewSystemError("unable to perform HTTP request")
+		}
+
+		resp := http.Response{Body: io.NopCloser(strings.NewReader(""))}
+		body, err := io.ReadAll(req.Body)
+		Expect(err).ToNot(HaveOccurred())
+
+		switch {
+		case req.Method == http.MethodPost:
+			registration := make(map[string]any)
+			Expect(json.Unmarshal(body, &registration)).To(Succeed())
+			registrations = append(registrations, registration)
+			resp.Body = io.NopCloser(strings.NewReader(fmt.Sprintf(
+				"{\"test_results_uploads\":[{\"id\":\"captain-id\",\"external_identifier\":%q,\"upload_url\":\"upload\"}]}",
+				mockUUID,
+			)))
+		case req.URL.Path == "upload":
+			uploads = append(uploads, string(body))
+		}
+
+		return &resp, nil
+	}
+
+	newClient := func(provider providers.Provider) remote.Client {
+		return remote.Client{ClientConfig: remote.ClientConfig{
+			Log:            zap.NewNop().Sugar(),
+			NewUUID:        func() (uuid.UUID, error) { return mockUUID, nil },
+			Provider:       provider,
+			SpoolDirectory: spoolDirectory,
+		}, RoundTrip: mockRoundTripper}
+	}
+
+	originalProvider := providers.Provider{
+		AttemptedBy:  "original-author",
+		BranchName:   "original-branch",
+		CommitSha:    "original-sha",
+		ProviderName: "github",
+	}
+
+	testResults := v1.TestResults{
+		Framework: v1.RubyRSpecFramework,
+		Tests:     []v1.Test{{Name: "some test", Attempt: v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()}}},
+	}
+
+	spooledFiles := func() []string {
+		files, err := filepath.Glob(filepath.Join(spoolDirectory, suiteID, "*.json"))
+		Expect(err).ToNot(HaveOccurred())
+		return files
+	}
+
+	BeforeEach(func() {
+		spoolDirectory = filepath.Join(GinkgoT().TempDir(), "spool")
+		registrations = nil
+		uploads = nil
+		apiAvailable = true
+	})
+
+	It("doesn't spool uploaded test results", func() {
+		_, err := newClient(originalProvider).UpdateTestResults(context.Background(), suiteID, testResults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spooledFiles()).To(BeEmpty())
+	})
+
+	Context("when the API is unreachable", func() {
+		BeforeEach(func() {
+			apiAvailable = false
+			_, err := newClient(originalProvider).UpdateTestResults(context.Background(), suiteID, testResults)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("spools the test results", func() {
+			Expect(spooledFiles()).To(HaveLen(1))
+		})
+
+		It("doesn't spool anything without a spool directory", func() {
+			client := newClient(originalProvider)
+			client.SpoolDirectory = ""
+			_, err := client.UpdateTestResults(context.Background(), suiteID, testResults)
+			Expect(err).To(HaveOccurred())
+			Expect(spooledFiles()).To(HaveLen(1))
+		})
+
+		Describe("ReplaySpool", func() {
+			It("re-sends the test results on behalf of the original run", func() {
+				apiAvailable = true
+				result, err := newClient(providers.Provider{
+					AttemptedBy:  "someone-else",
+					BranchName:   "main",
+					CommitSha:    "other-sha",
+					ProviderName: "generic",
+				}).ReplaySpool(context.Background(), suiteID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(remote.SpoolReplayResult{Replayed: 1}))
+
+				Expect(registrations).To(HaveLen(1))
+				Expect(registrations[0]).To(HaveKeyWithValue("attempted_by", "original-author"))
+				Expect(registrations[0]).To(HaveKeyWithValue("branch", "original-branch"))
+				Expect(registrations[0]).To(HaveKeyWithValue("commit_sha", "original-sha"))
+				Expect(registrations[0]).To(HaveKeyWithValue("provider", "github"))
+				Expect(registrations[0]).To(HaveKeyWithValue("test_suite_identifier", suiteID))
+
+				Expect(uploads).To(HaveLen(1))
+				Expect(uploads[0]).To(ContainSubstring("some test"))
+				Expect(spooledFiles()).To(BeEmpty())
+			})
+
+			It("keeps the test results if they still can't be uploaded", func() {
+				result, err := newClient(originalProvider).ReplaySpool(context.Background(), suiteID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(remote.SpoolReplayResult{Remaining: 1}))
+				Expect(spooledFiles()).To(HaveLen(1))
+			})
+
+			It("doesn't replay anything once cancelled", func() {
+				apiAvailable = true
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				result, err := newClient(originalProvider).ReplaySpool(ctx, suiteID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("interrupted"))
+				Expect(result).To(Equal(remote.SpoolReplayResult{Remaining: 1}))
+				Expect(uploads).To(BeEmpty())
+				Expect(spooledFiles()).To(HaveLen(1))
+			})
+
+			It("doesn't replay test results again that are being replayed already", func() {
+				apiAvailable = true
+				spooled := spooledFiles()
+				Expect(os.Rename(spooled[0], spooled[0]+".sending")).To(Succeed())
+
+				result, err := newClient(originalProvider).ReplaySpool(context.Background(), suiteID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(remote.SpoolReplayResult{}))
+				Expect(uploads).To(BeEmpty())
+			})
+
+			It("only replays the test results of the given suite", func() {
+				apiAvailable = true
+				result, err := newClient(originalProvider).ReplaySpool(context.Background(), "other-suite")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(remote.SpoolReplayResult{}))
+				Expect(spooledFiles()).To(HaveLen(1))
+			})
+		})
+	})
+
+	It("requires a spool directory to replay", func() {
+		client := newClient(originalProvider)
+		client.SpoolDirectory = ""
+		_, err := client.ReplaySpool(context.Background(), suiteID)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Missing spool directory"))
+	})
+
+	It("doesn't fail on an empty spool", func() {
+		Expect(os.MkdirAll(spoolDirectory, 0o755)).To(Succeed())
+		result, err := newClient(originalProvider).ReplaySpool(context.Background(), suiteID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(remote.SpoolReplayResult{}))
+	})
+})
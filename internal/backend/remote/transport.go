@@ -0,0 +1,75 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+// newTransport returns the HTTP transport for the configured proxy, certificate authorities, and client certificate.
+func newTransport(cfg ClientConfig) (*http.Transport, error) {
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.NewInternalError("the default HTTP transport is of an unexpected type")
+	}
+	transport := defaultTransport.Clone()
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, errors.NewConfigurationError(
+				"Invalid proxy",
+				"The proxy needs to be a URL like \"http://proxy.example.com:3128\".",
+				"Please check the value of 'cloud.proxy' in the config file.",
+			)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CACertFile == "" && cfg.ClientCertFile == "" {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CACertFile != "" {
+		bundle, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, errors.NewSystemError("unable to read CA bundle %q: %s", cfg.CACertFile, err)
+		}
+
+		// The bundle extends the system certificate authorities, as test results are also uploaded to S3
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			return nil, errors.NewConfigurationError(
+				"Invalid CA bundle",
+				"The CA bundle doesn't contain any PEM encoded certificates.",
+				"Please check the file that 'cloud.ca-file' in the config file points to.",
+			)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if cfg.ClientCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, errors.NewConfigurationError(
+				"Invalid client certificate",
+				err.Error(),
+				"Please check the files that 'cloud.client-cert' and 'cloud.client-key' in the config file point to.",
+			)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,338 @@
+package remote_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/rwx-research/captain-cli/internal/backend/remote"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP transport", func() {
+	const runConfiguration = `{"quarantined_tests":[],"flaky_tests":[]}`
+
+	var (
+		requests int32
+		handler  http.HandlerFunc
+		cfg      remote.ClientConfig
+	)
+
+	writePEM := func(blockType string, bytes []byte) string {
+		file, err := os.CreateTemp(GinkgoT().TempDir(), "*.pem")
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		Expect(pem.Encode(file, &pem.Block{Type: blockType, Bytes: bytes})).To(Succeed())
+		return file.Name()
+	}
+
+	hostOf := func(server *httptest.Server) string {
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		return serverURL.Host
+	}
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&requests, 0)
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(runConfiguration))
+		}
+		cfg = remote.ClientConfig{
+			Insecure:     true,
+			Log:          zap.NewNop().Sugar(),
+			Token:        "token",
+			MaxRetries:   2,
+			RetryBackoff: time.Millisecond,
+		}
+	})
+
+	newServer := func() *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			handler(w, r)
+		}))
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	newClient := func() remote.Client {
+		client, err := remote.NewClient(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	Describe("retries", func() {
+		BeforeEach(func() {
+			cfg.Host = hostOf(newServer())
+		})
+
+		It("retries idempotent requests after a server error", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if atomic.LoadInt32(&requests) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(runConfiguration))
+			}
+
+			_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+		})
+
+		It("gives up after the configured number of retries", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+
+			_, err := newClient().GetTestTimingManifest(context.Background(), "suite-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Status Code 502"))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+		})
+
+		It("doesn't retry client errors", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+			_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+			Expect(err).To(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+
+		It("doesn't retry the registration of test results", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			_, err := newClient().UpdateTestResults(context.Background(), "suite-id", v1.TestResults{})
+			Expect(err).To(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+
+		It("retries the status update of uploaded test results", func() {
+			var uploadURL string
+			var statusUpdates int32
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost:
+					_, _ = w.Write([]byte(`{"test_results_uploads":[{"id":"1","external_identifier":"` +
+						"fff24366-af1d-43cc-ab32-8c9ed137cf09" + `","upload_url":"` + uploadURL + `"}]}`))
+				case r.URL.Path == "/upload":
+					w.WriteHeader(http.StatusOK)
+				case atomic.AddInt32(&statusUpdates, 1) == 1:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				default:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}
+			uploadURL = "http://" + cfg.Host + "/upload"
+			cfg.NewUUID = func() (uuid.UUID, error) {
+				return uuid.MustParse("fff24366-af1d-43cc-ab32-8c9ed137cf09"), nil
+			}
+
+			uploadResults, err := newClient().UpdateTestResults(context.Background(), "suite-id", v1.TestResults{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploadResults[0].Uploaded).To(BeTrue())
+			Expect(atomic.LoadInt32(&statusUpdates)).To(Equal(int32(2)))
+		})
+
+		It("retries connection errors", func() {
+			server := newServer()
+			cfg.Host = hostOf(server)
+			server.Close()
+
+			_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to perform HTTP request"))
+		})
+	})
+
+	It("times out slow requests", func() {
+		done := make(chan struct{})
+		DeferCleanup(func() { close(done) })
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		}
+		cfg.Host = hostOf(newServer())
+		cfg.Timeout = 50 * time.Millisecond
+		cfg.MaxRetries = 0
+
+		_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Client.Timeout exceeded"))
+	})
+
+	It("doesn't time out slow uploads of test results", func() {
+		uploadURL := "http://bucket.s3.amazonaws.com/upload"
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.String() == uploadURL:
+				time.Sleep(100 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			case r.Method == http.MethodPost:
+				_, _ = w.Write([]byte(`{"test_results_uploads":[{"id":"1","external_identifier":"` +
+					"fff24366-af1d-43cc-ab32-8c9ed137cf09" + `","upload_url":"` + uploadURL + `"}]}`))
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}))
+		DeferCleanup(proxy.Close)
+
+		cfg.Host = "captain.example.com"
+		cfg.Proxy = proxy.URL
+		cfg.Timeout = 50 * time.Millisecond
+		cfg.NewUUID = func() (uuid.UUID, error) {
+			return uuid.MustParse("fff24366-af1d-43cc-ab32-8c9ed137cf09"), nil
+		}
+
+		uploadResults, err := newClient().UpdateTestResults(context.Background(), "suite-id", v1.TestResults{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploadResults[0].Uploaded).To(BeTrue())
+	})
+
+	It("sends requests through the configured proxy", func() {
+		var proxiedHost string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxiedHost = r.URL.Host
+			_, _ = w.Write([]byte(runConfiguration))
+		}))
+		DeferCleanup(proxy.Close)
+
+		cfg.Host = "captain.example.com"
+		cfg.Proxy = proxy.URL
+
+		_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxiedHost).To(Equal("captain.example.com"))
+	})
+
+	It("rejects invalid proxies", func() {
+		cfg.Proxy = "not a proxy"
+		_, err := remote.NewClient(cfg)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid proxy"))
+	})
+
+	Describe("TLS", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(runConfiguration))
+			}))
+			DeferCleanup(server.Close)
+
+			cfg.Insecure = false
+		})
+
+		JustBeforeEach(func() {
+			server.StartTLS()
+			cfg.Host = hostOf(server)
+		})
+
+		It("trusts the certificate authorities of the CA bundle", func() {
+			cfg.CACertFile = writePEM("CERTIFICATE", server.Certificate().Raw)
+
+			_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("doesn't trust unknown certificate authorities", func() {
+			_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("certificate"))
+		})
+
+		It("rejects invalid CA bundles", func() {
+			cfg.CACertFile = filepath.Join(GinkgoT().TempDir(), "ca.pem")
+			Expect(os.WriteFile(cfg.CACertFile, []byte("not a certificate"), 0o600)).To(Succeed())
+
+			_, err := remote.NewClient(cfg)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid CA bundle"))
+		})
+
+		Context("with client certificates", func() {
+			BeforeEach(func() {
+				key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				template := &x509.Certificate{
+					SerialNumber: big.NewInt(1),
+					Subject:      pkix.Name{CommonName: "captain-cli"},
+					NotBefore:    time.Now().Add(-time.Hour),
+					NotAfter:     time.Now().Add(time.Hour),
+					KeyUsage:     x509.KeyUsageDigitalSignature,
+					ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+				}
+				certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+				Expect(err).NotTo(HaveOccurred())
+				encodedKey, err := x509.MarshalECPrivateKey(key)
+				Expect(err).NotTo(HaveOccurred())
+
+				clientCAs := x509.NewCertPool()
+				parsedCertificate, err := x509.ParseCertificate(certificate)
+				Expect(err).NotTo(HaveOccurred())
+				clientCAs.AddCert(parsedCertificate)
+
+				server.TLS = &tls.Config{
+					ClientAuth: tls.RequireAndVerifyClientCert,
+					ClientCAs:  clientCAs,
+					MinVersion: tls.VersionTLS12,
+				}
+
+				cfg.ClientCertFile = writePEM("CERTIFICATE", certificate)
+				cfg.ClientKeyFile = writePEM("EC PRIVATE KEY", encodedKey)
+			})
+
+			JustBeforeEach(func() {
+				cfg.CACertFile = writePEM("CERTIFICATE", server.Certificate().Raw)
+			})
+
+			It("presents the client certificate", func() {
+				_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails without a client certificate", func() {
+				cfg.ClientCertFile = ""
+				cfg.ClientKeyFile = ""
+
+				_, err := newClient().GetRunConfiguration(context.Background(), "suite-id")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("requires both a certificate and a key", func() {
+				cfg.ClientKeyFile = ""
+
+				_, err := remote.NewClient(cfg)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Incomplete client certificate configuration"))
+			})
+		})
+	})
+})
@@ -15,44 +15,51 @@ import (
 	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
 )
 
-func (c Client) registerTestResults(
-	ctx context.Context,
-	testSuite string,
-	testResultsFiles []TestResultsFile,
-) ([]TestResultsFile, error) {
-	endpoint := hostEndpointCompat(c, "/api/test_suites/bulk_test_results")
+// testResultsRegistration is the request body for registering test results files with the Captain API. Next to the
+// files themselves, it holds the provider metadata of the run that produced them.
+type testResultsRegistration struct {
+	AttemptedBy         string            `json:"attempted_by"`
+	Provider            string            `json:"provider"`
+	BranchName          string            `json:"branch"`
+	CommitMessage       *string           `json:"commit_message"`
+	CommitSha           string            `json:"commit_sha"`
+	TestSuiteIdentifier string            `json:"test_suite_identifier"`
+	TestResultsFiles    []TestResultsFile `json:"test_results_files"`
+	Title               *string           `json:"title"`
+	JobTags             map[string]any    `json:"job_tags"`
+}
 
-	reqBody := struct {
-		AttemptedBy         string            `json:"attempted_by"`
-		Provider            string            `json:"provider"`
-		BranchName          string            `json:"branch"`
-		CommitMessage       *string           `json:"commit_message"`
-		CommitSha           string            `json:"commit_sha"`
-		TestSuiteIdentifier string            `json:"test_suite_identifier"`
-		TestResultsFiles    []TestResultsFile `json:"test_results_files"`
-		Title               *string           `json:"title"`
-		JobTags             map[string]any    `json:"job_tags"`
-	}{
+func (c Client) newTestResultsRegistration(testSuite string) testResultsRegistration {
+	registration := testResultsRegistration{
 		AttemptedBy:         c.Provider.AttemptedBy,
 		Provider:            c.Provider.ProviderName,
 		BranchName:          c.Provider.BranchName,
 		CommitSha:           c.Provider.CommitSha,
 		TestSuiteIdentifier: testSuite,
-		TestResultsFiles:    testResultsFiles,
 		JobTags:             c.Provider.JobTags,
 	}
 
 	commitMessage := c.Provider.CommitMessage
 	if commitMessage != "" {
-		reqBody.CommitMessage = &commitMessage
+		registration.CommitMessage = &commitMessage
 	}
 
 	title := c.Provider.Title
 	if title != "" {
-		reqBody.Title = &title
+		registration.Title = &title
 	}
 
-	resp, err := c.postJSON(ctx, endpoint, reqBody)
+	return registration
+}
+
+func (c Client) registerTestResults(
+	ctx context.Context,
+	registration testResultsRegistration,
+) ([]TestResultsFile, error) {
+	endpoint := hostEndpointCompat(c, "/api/test_suites/bulk_test_results")
+	testResultsFiles := registration.TestResultsFiles
+
+	resp, err := c.postJSON(ctx, endpoint, registration)
 	if err != nil {
 		return nil, err
 	}
@@ -147,6 +154,8 @@ func (c Client) updateTestResultsStatuses(
 // UpdateTestResults uploads test results files to Captain.
 // This method is not atomic - data-loss can occur silently. To verify that this operation was successful,
 // the Captain database has to be queried manually.
+// If a spool directory is configured, test results that couldn't be uploaded are written to it instead. These can be
+// re-sent later on using `ReplaySpool`.
 func (c Client) UpdateTestResults(
 	ctx context.Context,
 	testSuite string,
@@ -156,14 +165,45 @@ func (c Client) UpdateTestResults(
 		return nil, errors.NewInputError("test suite name required")
 	}
 
-	id, err := c.NewUUID()
+	buf, err := json.Marshal(testResults)
 	if err != nil {
-		return nil, c.logError(errors.NewInternalError("Unable to generate new UUID: %s", err))
+		return nil, c.logError(errors.NewInternalError("Unable to output test results as JSON: %s", err))
 	}
 
-	buf, err := json.Marshal(testResults)
+	originalPaths := make([]string, len(testResults.DerivedFrom))
+	for i, originalTestResult := range testResults.DerivedFrom {
+		originalPaths[i] = originalTestResult.OriginalFilePath
+	}
+
+	registration := c.newTestResultsRegistration(testSuite)
+	uploadResults, err := c.uploadTestResults(ctx, registration, buf, originalPaths)
+
+	if c.SpoolDirectory != "" && !uploaded(uploadResults, err) {
+		spoolPath, spoolErr := c.spool(registration, buf, originalPaths)
+		if spoolErr != nil {
+			c.Log.Warnf("unable to spool test results: %s", spoolErr)
+		} else {
+			c.Log.Warnf(
+				"Unable to upload test results. They were spooled to %q and can be re-sent using "+
+					"'captain update results --suite-id %s --replay-spool'",
+				spoolPath, testSuite,
+			)
+		}
+	}
+
+	return uploadResults, err
+}
+
+// uploadTestResults registers a single RWX test results file with Captain & uploads it afterwards.
+func (c Client) uploadTestResults(
+	ctx context.Context,
+	registration testResultsRegistration,
+	buf []byte,
+	originalPaths []string,
+) ([]backend.TestResultsUploadResult, error) {
+	id, err := c.NewUUID()
 	if err != nil {
-		return nil, c.logError(errors.NewInternalError("Unable to output test results as JSON: %s", err))
+		return nil, c.logError(errors.NewInternalError("Unable to generate new UUID: %s", err))
 	}
 
 	f := fs.VirtualReadOnlyFile{
@@ -171,11 +211,6 @@ func (c Client) UpdateTestResults(
 		FileName: "rwx-test-results",
 	}
 
-	originalPaths := make([]string, len(testResults.DerivedFrom))
-	for i, originalTestResult := range testResults.DerivedFrom {
-		originalPaths[i] = originalTestResult.OriginalFilePath
-	}
-
 	testResultsFiles := []TestResultsFile{{
 		ExternalID:    id,
 		FD:            f,
@@ -193,24 +228,28 @@ func (c Client) UpdateTestResults(
 		fileSizeLookup[testResultsFile.ExternalID] = fileInfo.Size()
 	}
 
-	testResultsFiles, err = c.registerTestResults(ctx, testSuite, testResultsFiles)
+	registration.TestResultsFiles = testResultsFiles
+	testResultsFiles, err = c.registerTestResults(ctx, registration)
 	if err != nil {
 		return nil, err
 	}
-
 	uploadResults := make([]backend.TestResultsUploadResult, 0)
 	for i, testResultsFile := range testResultsFiles {
 		if testResultsFile.UploadURL == nil {
 			return nil, errors.NewInternalError("endpoint failed to return upload destination url")
 		}
-		req := http.Request{
-			Method:        http.MethodPut,
-			URL:           testResultsFile.UploadURL,
-			Body:          testResultsFile.FD,
-			ContentLength: fileSizeLookup[testResultsFile.ExternalID],
+		req, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPut,
+			testResultsFile.UploadURL.String(),
+			testResultsFile.FD,
+		)
+		if err != nil {
+			return nil, errors.NewInternalError("unable to construct HTTP request: %s", err)
 		}
+		req.ContentLength = fileSizeLookup[testResultsFile.ExternalID]
 
-		resp, err := c.RoundTrip(&req)
+		resp, err := c.RoundTrip(req)
 		if err != nil {
 			c.Log.Warnf("unable to upload test results file to S3: %s", err)
 			uploadResults = append(uploadResults, backend.TestResultsUploadResult{
@@ -228,7 +267,7 @@ func (c Client) UpdateTestResults(
 		})
 	}
 
-	if err := c.updateTestResultsStatuses(ctx, testSuite, testResultsFiles); err != nil {
+	if err := c.updateTestResultsStatuses(ctx, registration.TestSuiteIdentifier, testResultsFiles); err != nil {
 		c.Log.Warnf("unable to update test results file status: %s", err)
 	}
 
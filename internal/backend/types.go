@@ -29,6 +29,10 @@ type Test struct {
 	CompositeIdentifier string   `json:"composite_identifier"`
 	IdentityComponents  []string `json:"identity_components"`
 	StrictIdentity      bool     `json:"strict_identity"`
+	// Match is one of MatchExact, MatchGlob, or MatchRegex. An empty value is equivalent to MatchExact.
+	Match string `json:"match,omitempty"`
+	// IdentityValues holds the value of each identity component. These are only required for pattern matches.
+	IdentityValues []string `json:"identity_values,omitempty"`
 }
 
 type TestResultsUploadResult struct {
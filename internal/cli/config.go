@@ -15,27 +15,31 @@ import (
 
 // RunConfig holds the configuration for running a test suite (used by `RunSuite`)
 type RunConfig struct {
-	Args                      []string
-	Command                   string
-	TestResultsFileGlob       string
-	FailOnUploadError         bool
-	FailRetriesFast           bool
-	FlakyRetries              int
-	IntermediateArtifactsPath string
-	MaxTestsToRetry           string
-	PostRetryCommands         []string
-	PreRetryCommands          []string
-	PrintSummary              bool
-	Quiet                     bool
-	Reporters                 map[string]Reporter
-	Retries                   int
-	RetryCommandTemplate      string
-	SuiteID                   string
-	SubstitutionsByFramework  map[v1.Framework]targetedretries.Substitution
-	UpdateStoredResults       bool
-	UploadResults             bool
-	PartitionCommandTemplate  string
-	PartitionConfig           PartitionConfig
+	Args                        []string
+	Command                     string
+	TestResultsFileGlob         string
+	DurationRegressionThreshold float64
+	FailOnDurationRegression    bool
+	FailOnUploadError           bool
+	FailRetriesFast             bool
+	FlakyRetries                int
+	IntermediateArtifactsPath   string
+	MaxTestsToRetry             string
+	PostRetryCommands           []string
+	PreRetryCommands            []string
+	PrintSummary                bool
+	Progress                    string
+	QuarantineMode              string
+	Quiet                       bool
+	Reporters                   map[string]Reporter
+	Retries                     int
+	RetryCommandTemplate        string
+	SuiteID                     string
+	SubstitutionsByFramework    map[v1.Framework]targetedretries.Substitution
+	UpdateStoredResults         bool
+	UploadResults               bool
+	PartitionCommandTemplate    string
+	PartitionConfig             PartitionConfig
 }
 
 var maxTestsToRetryRegexp = regexp.MustCompile(
@@ -74,10 +78,54 @@ func (rc RunConfig) Validate(log *zap.SugaredLogger) error {
 		log.Warn("The --max-tests-to-retry flag has no effect as no retries are otherwise configured.")
 	}
 
+	if rc.DurationRegressionThreshold < 0 {
+		return errors.NewConfigurationError(
+			"Unsupported --duration-regression-threshold value",
+			"Tests can't get slower by a negative percentage.",
+			"Please set --duration-regression-threshold to a positive number, e.g. 50 for test files that took 50% "+
+				"longer than their stored timings.",
+		)
+	}
+
+	if rc.DurationRegressionThreshold > 0 && !rc.FailOnDurationRegression {
+		log.Debug("The --duration-regression-threshold only affects reports, --fail-on-duration-regression is not set.")
+	}
+
 	if rc.PartitionCommandTemplate != "" && rc.PartitionConfig.PartitionNodes.Total <= 1 {
 		log.Warnf("There is a partition command configured for this test suite, but partitioning is disabled.")
 	}
 
+	switch rc.Progress {
+	case "", ProgressAuto, ProgressAlways, ProgressNever:
+	default:
+		return errors.NewConfigurationError(
+			fmt.Sprintf("Unsupported progress mode %q", rc.Progress),
+			"The live status line can either be shown automatically when stdout is a terminal (the default), "+
+				"always, or never.",
+			"Please set --progress to either 'auto', 'always', or 'never'.",
+		)
+	}
+
+	switch rc.QuarantineMode {
+	case "", QuarantineModeRun:
+	case QuarantineModeSkip:
+		if !hasQuarantineExclusionsPlaceholder(append([]string{rc.Command, rc.PartitionCommandTemplate}, rc.Args...)...) {
+			return errors.NewConfigurationError(
+				"Missing quarantine exclusions",
+				"Quarantined tests are configured to be skipped, however the command doesn't specify where the "+
+					"arguments that exclude them should go.",
+				"Please add the '{{ quarantineExclusions }}' keyword to your command, e.g. "+
+					"'npx jest {{ quarantineExclusions }}'.",
+			)
+		}
+	default:
+		return errors.NewConfigurationError(
+			fmt.Sprintf("Unsupported quarantine mode %q", rc.QuarantineMode),
+			"Quarantined tests can either be run (the default) or skipped.",
+			"Please set the quarantine mode to either 'run' or 'skip'.",
+		)
+	}
+
 	if rc.IsRunningPartition() {
 		err := rc.PartitionConfig.Validate()
 		if err != nil {
@@ -148,8 +196,34 @@ func (rc RunConfig) IsRunningPartition() bool {
 type PartitionConfig struct {
 	SuiteID        string
 	TestFilePaths  []string
+	Exclude        []string
+	FilesFrom      string
 	Delimiter      string
 	PartitionNodes config.PartitionNodes
+	Weights        []int
+	Selection      SelectionConfig
+}
+
+// weight returns the relative capacity of the partition with the given index. Partitions have equal capacity unless
+// weights are configured.
+func (pc PartitionConfig) weight(index int) int {
+	if len(pc.Weights) == 0 {
+		return 1
+	}
+
+	return pc.Weights[index]
+}
+
+func (pc PartitionConfig) totalWeight() int {
+	if len(pc.Weights) == 0 {
+		return pc.PartitionNodes.Total
+	}
+
+	total := 0
+	for _, weight := range pc.Weights {
+		total += weight
+	}
+	return total
 }
 
 func (pc PartitionConfig) Validate() error {
@@ -193,7 +267,29 @@ func (pc PartitionConfig) Validate() error {
 		)
 	}
 
-	if len(pc.TestFilePaths) == 0 {
+	if len(pc.Weights) > 0 && len(pc.Weights) != pc.PartitionNodes.Total {
+		return errors.NewConfigurationError(
+			"Unsupported partition weights",
+			fmt.Sprintf(
+				"You specified %d partition weights, but there are %d partitions.",
+				len(pc.Weights), pc.PartitionNodes.Total,
+			),
+			"Please specify exactly one weight per partition, e.g. '--partition-weights 2,1,1' for three partitions "+
+				"where the first one has twice the capacity of the others.",
+		)
+	}
+
+	for _, weight := range pc.Weights {
+		if weight <= 0 {
+			return errors.NewConfigurationError(
+				"Unsupported partition weights",
+				fmt.Sprintf("You specified a partition weight of %d.", weight),
+				"Partition weights need to be positive integers.",
+			)
+		}
+	}
+
+	if len(pc.TestFilePaths) == 0 && pc.FilesFrom == "" {
 		return errors.NewConfigurationError(
 			"Missing test file paths",
 			"No test file paths are provided.\n",
@@ -201,6 +297,8 @@ func (pc PartitionConfig) Validate() error {
 				"You may specify this flag multiple times if needed.\n\n"+
 				"When using the partition command, please specify the path or paths to your test files as arguments.\n\n"+
 				"\tcaptain partition [flags] <filepath>\n\n"+
+				"Alternatively, a list of test files can be read from a file (or stdin) using --partition-files-from or "+
+				"--files-from respectively.\n\n"+
 				"You can also execute 'captain partition --help' for further information.",
 		)
 	}
@@ -1,21 +1,43 @@
 package cli
 
+import "time"
+
 // configFile holds all options that can be set over the config file
 type ConfigFile struct {
 	Cloud struct {
 		APIHost  string `yaml:"api-host" env:"CAPTAIN_HOST"`
 		Disabled bool
 		Insecure bool
+		// SpoolDirectory is where test results are kept if they can't be uploaded, see `--replay-spool`
+		SpoolDirectory string `yaml:"spool-directory" env:"CAPTAIN_SPOOL_DIRECTORY"`
+		// CacheDirectory is where the run configuration & test timings are cached in case the Captain API is unavailable
+		CacheDirectory string        `yaml:"cache-directory" env:"CAPTAIN_CACHE_DIRECTORY"`
+		CacheMaxAge    time.Duration `yaml:"cache-max-age"`
+		// Timeout limits the duration of a single request to the Captain API, e.g. "30s". Uploads of test results to
+		// S3 aren't limited by it.
+		Timeout time.Duration
+		// Retries is the number of times idempotent requests are retried. Unset means the default of the client.
+		Retries    *int
+		Proxy      string
+		CAFile     string `yaml:"ca-file"`
+		ClientCert string `yaml:"client-cert"`
+		ClientKey  string `yaml:"client-key"`
 	}
 	Flags  map[string]any
 	Output struct {
 		Debug bool
 	}
+	// Storage selects where Captain keeps flakes, quarantines, timings, and history in OSS mode
+	Storage struct {
+		Backend string
+		Path    string
+	}
 	TestSuites map[string]SuiteConfig `yaml:"test-suites"`
 }
 
 type SuiteConfigOutput struct {
 	PrintSummary bool `yaml:"print-summary"`
+	Progress     string
 	Reporters    map[string]string
 	Quiet        bool
 }
@@ -40,15 +62,37 @@ type SuiteConfigRetries struct {
 type SuiteConfigPartition struct {
 	Command   string
 	Globs     []string
+	Exclude   []string
+	FilesFrom string `yaml:"files-from"`
 	Delimiter string
+	Weights   []int
+	Selection SuiteConfigSelection
+}
+
+// SuiteConfigSelection configures the change-aware selection of test files ahead of partitioning
+type SuiteConfigSelection struct {
+	BaseRef   string   `yaml:"base-ref"`
+	AlwaysRun []string `yaml:"always-run"`
+	Rules     []SelectionRule
+}
+
+// SuiteConfigQuarantine configures how quarantined tests are handled
+type SuiteConfigQuarantine struct {
+	Mode string
 }
 
 // SuiteConfig holds options that can be customized per suite
 type SuiteConfig struct {
 	Command           string
 	FailOnUploadError bool `yaml:"fail-on-upload-error"`
-	Output            SuiteConfigOutput
-	Results           SuiteConfigResults
-	Retries           SuiteConfigRetries
-	Partition         SuiteConfigPartition
+	// FailOnDurationRegression fails the run if test files took considerably longer than their stored timings
+	FailOnDurationRegression bool `yaml:"fail-on-duration-regression"`
+	// DurationRegressionThreshold is how much longer than before (in percent) a test file needs to take in order to
+	// count as slower. Test files also need to take at least `reporting.DurationRegressionMinIncrease` (1s) longer.
+	DurationRegressionThreshold float64 `yaml:"duration-regression-threshold"`
+	Output                      SuiteConfigOutput
+	Results                     SuiteConfigResults
+	Retries                     SuiteConfigRetries
+	Partition                   SuiteConfigPartition
+	Quarantine                  SuiteConfigQuarantine
 }
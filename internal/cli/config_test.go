@@ -104,6 +104,16 @@ var _ = Describe("RunConfig", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("errs when the progress mode is unsupported", func() {
+			err := cli.RunConfig{Progress: "sometimes"}.Validate(logger)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported progress mode"))
+
+			for _, mode := range []string{"", cli.ProgressAuto, cli.ProgressAlways, cli.ProgressNever} {
+				Expect(cli.RunConfig{Progress: mode}.Validate(logger)).To(Succeed())
+			}
+		})
+
 		It("errs when partitioning and partition config is missing suite id", func() {
 			err := cli.RunConfig{
 				PartitionCommandTemplate: "something {{ testFiles }}",
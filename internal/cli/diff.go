@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/parsing"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// DiffConfig holds the configuration for `captain diff`
+type DiffConfig struct {
+	// IdentityComponents identify the same test in both test results. If empty, tests need to match exactly.
+	IdentityComponents []string
+}
+
+// Diff is the implementation of `captain diff`. It prints a markdown summary of how the test results at `headPath`
+// differ from the ones at `basePath`. Both need to be RWX v1 JSON.
+func (s Service) Diff(_ context.Context, basePath, headPath string, cfg DiffConfig) error {
+	base, err := s.parseRWXTestResults(basePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	head, err := s.parseRWXTestResults(headPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	markdown, err := reporting.DiffMarkdown(*base, *head, cfg.IdentityComponents, reporting.Configuration{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infoln(markdown)
+
+	return nil
+}
+
+func (s Service) parseRWXTestResults(path string) (*v1.TestResults, error) {
+	fd, err := s.FileSystem.Open(path)
+	if err != nil {
+		return nil, errors.NewSystemError("unable to open file: %s", err)
+	}
+	defer fd.Close()
+
+	testResults, err := parsing.RWXParser{}.Parse(fd)
+	if err != nil {
+		return nil, errors.NewInputError("Unable to parse %q as RWX v1 JSON: %s", path, err)
+	}
+
+	// Any JSON object decodes successfully, but RWX v1 JSON always includes the framework
+	if testResults.Framework.Kind == "" {
+		return nil, errors.NewInputError(
+			"%q doesn't contain RWX v1 JSON. Test results can be converted using 'captain parse results'.", path,
+		)
+	}
+
+	return testResults, nil
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rwx-research/captain-cli/internal/backend"
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	ListFormatTable = "table"
+	ListFormatJSON  = "json"
+	ListFormatYAML  = "yaml"
+)
+
+// ListConfig holds the configuration for `captain list`
+type ListConfig struct {
+	// Format is one of "table", "json", or "yaml"
+	Format string
+	// TestResultsFileGlob optionally points to test results that are used to detect entries that don't match any test
+	// anymore. By default, the stored results of the latest run are used (see `local.Client.StoredResults`).
+	TestResultsFileGlob string
+}
+
+func (lc ListConfig) Validate() error {
+	switch lc.Format {
+	case ListFormatTable, ListFormatJSON, ListFormatYAML:
+		return nil
+	default:
+		return errors.NewConfigurationError(
+			fmt.Sprintf("Unsupported output format %q", lc.Format),
+			"'captain list' can only output tables, JSON, or YAML.",
+			"Please set the --output flag to 'table', 'json', or 'yaml'.",
+		)
+	}
+}
+
+// ListedEntry is a flake or quarantine as it is printed by `captain list`.
+type ListedEntry struct {
+	Identity           map[string]string `json:"identity" yaml:"identity"`
+	IdentityComponents []string          `json:"identity_components" yaml:"identity_components"`
+	Strict             bool              `json:"strict" yaml:"strict"`
+	Match              string            `json:"match,omitempty" yaml:"match,omitempty"`
+	QuarantinedAt      *time.Time        `json:"quarantined_at,omitempty" yaml:"quarantined_at,omitempty"`
+	ExpiresAt          *time.Time        `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	Expired            bool              `json:"expired" yaml:"expired"`
+	Owner              string            `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Reason             string            `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Issue              string            `json:"issue,omitempty" yaml:"issue,omitempty"`
+	// Matched is nil if no test results were available
+	Matched *bool `json:"matched,omitempty" yaml:"matched,omitempty"`
+}
+
+// ListedTiming is a test file timing as it is printed by `captain list`.
+type ListedTiming struct {
+	File     string `json:"file" yaml:"file"`
+	Duration string `json:"duration" yaml:"duration"`
+	// Exists is false if the test file doesn't exist anymore
+	Exists bool `json:"exists" yaml:"exists"`
+}
+
+func (s Service) localStorage(command string) (local.Client, error) {
+	localStorage, ok := s.API.(local.Client)
+	if !ok {
+		return local.Client{}, errors.NewConfigurationError(
+			fmt.Sprintf("'%s' only works in OSS mode", command),
+			"You are trying to inspect the local storage of Captain, however it appears that you are using "+
+				"Captain Cloud.",
+			"Please visit https://cloud.rwx.com/captain to see your flakes, quarantines, and timings.",
+		)
+	}
+
+	return localStorage, nil
+}
+
+// ListFlakes is the implementation of `captain list flakes`.
+func (s Service) ListFlakes(_ context.Context, cfg ListConfig) error {
+	localStorage, err := s.localStorage("captain list flakes")
+	if err != nil {
+		return err
+	}
+
+	return s.listEntries(localStorage, localStorage.Flakes, cfg)
+}
+
+// ListQuarantines is the implementation of `captain list quarantines`.
+func (s Service) ListQuarantines(_ context.Context, cfg ListConfig) error {
+	localStorage, err := s.localStorage("captain list quarantines")
+	if err != nil {
+		return err
+	}
+
+	return s.listEntries(localStorage, localStorage.Quarantines, cfg)
+}
+
+// ListTimings is the implementation of `captain list timings`.
+func (s Service) ListTimings(_ context.Context, cfg ListConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	localStorage, err := s.localStorage("captain list timings")
+	if err != nil {
+		return err
+	}
+
+	timings := make([]ListedTiming, 0, len(localStorage.Timings))
+	for file, duration := range localStorage.Timings {
+		_, err := s.FileSystem.Stat(file)
+		timings = append(timings, ListedTiming{File: file, Duration: duration.String(), Exists: err == nil})
+	}
+
+	sort.Slice(timings, func(i, j int) bool {
+		return timings[i].File < timings[j].File
+	})
+
+	if cfg.Format != ListFormatTable {
+		return s.printListing(timings, cfg.Format)
+	}
+
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "FILE\tDURATION\tEXISTS")
+	for _, timing := range timings {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", timing.File, timing.Duration, yesNo(timing.Exists))
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infoln(strings.TrimSuffix(table.String(), "\n"))
+	return nil
+}
+
+func (s Service) listEntries(localStorage local.Client, nodes []yaml.Node, cfg ListConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	testResults, err := s.latestTestResults(localStorage, cfg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if testResults == nil {
+		s.Log.Debug("No test results found, unable to tell whether entries match any tests")
+	}
+
+	now := time.Now()
+	entries := make([]ListedEntry, 0, len(nodes))
+	for _, node := range nodes {
+		entry, err := local.NewEntryFromYAML(node)
+		if err != nil {
+			s.Log.Warn(err)
+		}
+
+		listedEntry := ListedEntry{
+			Identity:           entry.Identity.Values,
+			IdentityComponents: entry.Identity.Order,
+			Strict:             entry.Strict,
+			Match:              entry.Match,
+			QuarantinedAt:      entry.QuarantinedAt,
+			ExpiresAt:          entry.ExpiresAt,
+			Expired:            entry.Expired(now),
+			Owner:              entry.Owner,
+			Reason:             entry.Reason,
+			Issue:              entry.Issue,
+		}
+
+		if testResults != nil {
+			matched := false
+			for _, test := range testResults.Tests {
+				if s.isIdentifiedIn(test, []backend.Test{entry.Test()}) {
+					matched = true
+					break
+				}
+			}
+			listedEntry.Matched = &matched
+		}
+
+		entries = append(entries, listedEntry)
+	}
+
+	if cfg.Format != ListFormatTable {
+		return s.printListing(entries, cfg.Format)
+	}
+
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "IDENTITY\tSTRICT\tOWNER\tREASON\tISSUE\tEXPIRES\tMATCHED")
+	for _, entry := range entries {
+		identity := make([]string, len(entry.IdentityComponents))
+		for i, component := range entry.IdentityComponents {
+			identity[i] = fmt.Sprintf("%s=%s", component, entry.Identity[component])
+		}
+
+		expires := "-"
+		if entry.ExpiresAt != nil {
+			expires = entry.ExpiresAt.Format(time.RFC3339)
+			if entry.Expired {
+				expires += " (expired)"
+			}
+		}
+
+		matched := "-"
+		if entry.Matched != nil {
+			matched = yesNo(*entry.Matched)
+		}
+
+		fmt.Fprintf(
+			writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", strings.Join(identity, ", "), yesNo(entry.Strict),
+			orDash(entry.Owner), orDash(entry.Reason), orDash(entry.Issue), expires, matched,
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infoln(strings.TrimSuffix(table.String(), "\n"))
+	return nil
+}
+
+// latestTestResults returns the test results that entries are matched against, or nil if there are none. Unless
+// they are overridden by `cfg.TestResultsFileGlob`, these are the stored results of the latest run of every partition.
+func (s Service) latestTestResults(localStorage local.Client, cfg ListConfig) (*v1.TestResults, error) {
+	if cfg.TestResultsFileGlob != "" {
+		testResultsFiles, err := s.FileSystem.GlobMany([]string{cfg.TestResultsFileGlob})
+		if err != nil {
+			return nil, errors.NewSystemError("unable to expand filepath glob: %s", err)
+		}
+
+		testResults, err := s.parse(testResultsFiles, 1)
+		return testResults, errors.WithStack(err)
+	}
+
+	allStoredResults, err := localStorage.AllStoredResults()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(allStoredResults) == 0 {
+		return nil, nil
+	}
+
+	storedResults := make([]v1.TestResults, 0, len(allStoredResults))
+	for _, testResults := range allStoredResults {
+		storedResults = append(storedResults, testResults)
+	}
+
+	testResults := v1.Merge(storedResults)
+	return &testResults, nil
+}
+
+func (s Service) printListing(v any, format string) error {
+	var output []byte
+	var err error
+
+	if format == ListFormatJSON {
+		output, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		output, err = yaml.Marshal(v)
+	}
+	if err != nil {
+		return errors.NewInternalError("Unable to output listing as %s: %s", format, err)
+	}
+
+	s.Log.Infoln(strings.TrimSuffix(string(output), "\n"))
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
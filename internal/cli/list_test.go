@@ -0,0 +1,195 @@
+package cli_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/parsing"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listing", func() {
+	const (
+		flakesPath      = "flakes"
+		quarantinesPath = "quarantines"
+		timingsPath     = "timings"
+		historyPath     = "history"
+		resultsPath     = "results.json"
+	)
+
+	var (
+		ctx          context.Context
+		mockedFS     *mocks.FileSystem
+		service      cli.Service
+		recordedLogs *observer.ObservedLogs
+
+		quarantines, timings string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		quarantines = "- description: test-1\n  owner: me\n  expires_at: 2000-01-01\n" +
+			"- description: test-2\n  reason: flaky on CI\n"
+		timings = "a_spec.rb: 4s\nb_spec.rb: 2s\n"
+
+		mockedFS = new(mocks.FileSystem)
+		mockedFS.MockOpen = func(name string) (fs.File, error) {
+			switch name {
+			case quarantinesPath:
+				return &mocks.File{Reader: strings.NewReader(quarantines)}, nil
+			case timingsPath:
+				return &mocks.File{Reader: strings.NewReader(timings)}, nil
+			default:
+				return &mocks.File{Reader: strings.NewReader("")}, nil
+			}
+		}
+		mockedFS.MockStat = func(name string) (os.FileInfo, error) {
+			if name == "a_spec.rb" {
+				return new(mocks.FileInfo), nil
+			}
+
+			return nil, os.ErrNotExist
+		}
+		mockedFS.MockGlob = func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		var core zapcore.Core
+		core, recordedLogs = observer.New(zapcore.InfoLevel)
+		log := zaptest.NewLogger(GinkgoT(), zaptest.WrapOptions(
+			zap.WrapCore(func(original zapcore.Core) zapcore.Core { return core }),
+		)).Sugar()
+
+		api, err := local.NewClient(mockedFS, log, flakesPath, quarantinesPath, timingsPath, historyPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		parser := new(mocks.Parser)
+		parser.MockParse = func(r io.Reader) (*v1.TestResults, error) {
+			return &v1.TestResults{Tests: []v1.Test{{Name: "test-2"}}}, nil
+		}
+
+		service = cli.Service{
+			API:        api,
+			FileSystem: mockedFS,
+			TaskRunner: new(mocks.TaskRunner),
+			ParseConfig: parsing.Config{
+				MutuallyExclusiveParsers: []parsing.Parser{parser},
+				Logger:                   log,
+			},
+			Log: log,
+		}
+	})
+
+	Describe("quarantines", func() {
+		It("prints a table including the metadata", func() {
+			Expect(service.ListQuarantines(ctx, cli.ListConfig{Format: cli.ListFormatTable})).To(Succeed())
+
+			logs := recordedLogs.TakeAll()
+			Expect(logs).To(HaveLen(1))
+			lines := strings.Split(logs[0].Message, "\n")
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(MatchRegexp(`^IDENTITY\s+STRICT\s+OWNER\s+REASON\s+ISSUE\s+EXPIRES\s+MATCHED$`))
+			Expect(lines[1]).To(MatchRegexp(`^description=test-1\s+no\s+me\s+-\s+-\s+2000-01-01T00:00:00Z \(expired\)\s+-$`))
+			Expect(lines[2]).To(MatchRegexp(`^description=test-2\s+no\s+-\s+flaky on CI\s+-\s+-\s+-$`))
+		})
+
+		It("shows whether entries match the latest test results", func() {
+			Expect(service.ListQuarantines(ctx, cli.ListConfig{
+				Format:              cli.ListFormatJSON,
+				TestResultsFileGlob: resultsPath,
+			})).To(Succeed())
+
+			logs := recordedLogs.TakeAll()
+			Expect(logs).To(HaveLen(1))
+
+			var entries []cli.ListedEntry
+			Expect(json.Unmarshal([]byte(logs[0].Message), &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Identity).To(Equal(map[string]string{"description": "test-1"}))
+			Expect(entries[0].Expired).To(BeTrue())
+			Expect(*entries[0].Matched).To(BeFalse())
+			Expect(entries[1].Reason).To(Equal("flaky on CI"))
+			Expect(entries[1].Expired).To(BeFalse())
+			Expect(*entries[1].Matched).To(BeTrue())
+		})
+
+		It("matches entries against the stored results of the latest run by default", func() {
+			mockedFS.MockGlob = func(pattern string) ([]string, error) {
+				return []string{"results-0-of-2.json", "results-1-of-2.json"}, nil
+			}
+			mockedFS.MockStat = func(name string) (os.FileInfo, error) {
+				return new(mocks.FileInfo), nil
+			}
+			mockedFS.MockOpenFile = func(name string, flag int, perm os.FileMode) (fs.File, error) {
+				return new(mocks.File), nil
+			}
+			mockedFS.MockRemove = func(name string) error {
+				return nil
+			}
+			storedResults := func(name string) *mocks.File {
+				buf, err := json.Marshal(v1.NewTestResults(v1.RubyRSpecFramework, []v1.Test{{Name: name}}, nil))
+				Expect(err).ToNot(HaveOccurred())
+				return &mocks.File{Reader: strings.NewReader(string(buf))}
+			}
+			mockedFS.MockOpen = func(name string) (fs.File, error) {
+				switch name {
+				case quarantinesPath:
+					return &mocks.File{Reader: strings.NewReader(quarantines)}, nil
+				case "results-1-of-2.json":
+					return storedResults("test-1"), nil
+				case "results-0-of-2.json":
+					return storedResults("test-3"), nil
+				default:
+					return &mocks.File{Reader: strings.NewReader("")}, nil
+				}
+			}
+
+			Expect(service.ListQuarantines(ctx, cli.ListConfig{Format: cli.ListFormatJSON})).To(Succeed())
+
+			logs := recordedLogs.TakeAll()
+			Expect(logs).To(HaveLen(1))
+
+			var entries []cli.ListedEntry
+			Expect(json.Unmarshal([]byte(logs[0].Message), &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(2))
+			Expect(*entries[0].Matched).To(BeTrue())
+			Expect(*entries[1].Matched).To(BeFalse())
+		})
+
+		It("rejects unknown output formats", func() {
+			err := service.ListQuarantines(ctx, cli.ListConfig{Format: "xml"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Unsupported output format "xml"`))
+		})
+	})
+
+	Describe("timings", func() {
+		It("shows whether the test files still exist", func() {
+			Expect(service.ListTimings(ctx, cli.ListConfig{Format: cli.ListFormatYAML})).To(Succeed())
+
+			logs := recordedLogs.TakeAll()
+			Expect(logs).To(HaveLen(1))
+			Expect(logs[0].Message).To(Equal(
+				"- file: a_spec.rb\n  duration: 4s\n  exists: true\n" +
+					"- file: b_spec.rb\n  duration: 2s\n  exists: false",
+			))
+		})
+	})
+})
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -25,15 +28,129 @@ func (s Service) Partition(ctx context.Context, cfg PartitionConfig) error {
 	return nil
 }
 
+// partitionTestFilePaths returns the sorted set of test files to partition. These are the expanded test file globs as
+// well as any files listed in `cfg.FilesFrom`, minus the files matched by the exclusion globs.
+func (s Service) partitionTestFilePaths(cfg PartitionConfig) ([]string, error) {
+	testFilePaths := make([]string, 0)
+
+	if len(cfg.TestFilePaths) > 0 {
+		expandedPaths, err := s.FileSystem.GlobMany(cfg.TestFilePaths)
+		if err != nil {
+			return nil, errors.NewSystemError("unable to expand filepath glob: %s", err)
+		}
+		testFilePaths = append(testFilePaths, expandedPaths...)
+	}
+
+	if cfg.FilesFrom != "" {
+		listedPaths, err := s.readFileList(cfg.FilesFrom)
+		if err != nil {
+			return nil, err
+		}
+		testFilePaths = append(testFilePaths, listedPaths...)
+	}
+
+	excludedPaths := make(map[string]struct{})
+	if len(cfg.Exclude) > 0 {
+		expandedPaths, err := s.FileSystem.GlobMany(cfg.Exclude)
+		if err != nil {
+			return nil, errors.NewSystemError("unable to expand exclusion glob: %s", err)
+		}
+
+		for _, excludedPath := range expandedPaths {
+			excludedPaths[filepath.Clean(excludedPath)] = struct{}{}
+		}
+	}
+
+	seenPaths := make(map[string]struct{})
+	filteredPaths := make([]string, 0, len(testFilePaths))
+	for _, testFilePath := range testFilePaths {
+		cleanPath := filepath.Clean(testFilePath)
+
+		if _, ok := seenPaths[cleanPath]; ok {
+			continue
+		}
+		seenPaths[cleanPath] = struct{}{}
+
+		if _, ok := excludedPaths[cleanPath]; ok {
+			s.Log.Debugf("Excluding %s from partitioning", testFilePath)
+			continue
+		}
+
+		filteredPaths = append(filteredPaths, testFilePath)
+	}
+
+	sort.Strings(filteredPaths)
+	return filteredPaths, nil
+}
+
+// readFileList reads a newline-separated list of test files from the file at `path`, or from stdin if `path` is "-".
+// Blank lines and lines starting with '#' are ignored, as are files that don't exist (e.g. files that were removed in
+// a `git diff`).
+func (s Service) readFileList(path string) ([]string, error) {
+	var reader io.Reader
+
+	if path == "-" {
+		reader = os.Stdin
+	} else {
+		file, err := s.FileSystem.Open(path)
+		if err != nil {
+			return nil, errors.NewSystemError("unable to open file list %q: %s", path, err)
+		}
+		defer file.Close()
+
+		reader = file
+	}
+
+	testFilePaths := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, err := s.FileSystem.Stat(line); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.Log.Debugf("Skipping %s from the file list as it does not exist", line)
+				continue
+			}
+
+			return nil, errors.NewSystemError("unable to access %q: %s", line, err)
+		}
+
+		testFilePaths = append(testFilePaths, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.NewSystemError("unable to read file list %q: %s", path, err)
+	}
+
+	return testFilePaths, nil
+}
+
 func (s Service) calculatePartition(ctx context.Context, cfg PartitionConfig) (PartitionResult, error) {
 	fileTimings, err := s.API.GetTestTimingManifest(ctx, cfg.SuiteID)
 	if err != nil {
 		return PartitionResult{}, errors.WithStack(err)
 	}
 
-	testFilePaths, err := s.FileSystem.GlobMany(cfg.TestFilePaths)
+	testFilePaths, err := s.partitionTestFilePaths(cfg)
 	if err != nil {
-		return PartitionResult{}, errors.NewSystemError("unable to expand filepath glob: %s", err)
+		return PartitionResult{}, err
+	}
+
+	var selection *SelectionResult
+	if cfg.Selection.Enabled() {
+		selectionResult, err := s.selectTestFiles(ctx, cfg.Selection, testFilePaths)
+		if err != nil {
+			return PartitionResult{}, err
+		}
+
+		selection = &selectionResult
+		testFilePaths = selectionResult.TestFilePaths
+		s.Log.Debugf(
+			"Selected test files (digest %s):\n%s", selectionResult.Digest(), strings.Join(testFilePaths, "\n"),
+		)
 	}
 	// Compare expanded client file paths w/ expanded server file paths
 	// taking care to always use the client path and sort by duration desc
@@ -87,12 +204,19 @@ func (s Service) calculatePartition(ctx context.Context, cfg PartitionConfig) (P
 	for _, fileTimingMatch := range fileTimingMatches {
 		totalCapacity += fileTimingMatch.Duration()
 	}
-	partitionCapacity := totalCapacity / time.Duration(cfg.PartitionNodes.Total)
+	totalWeight := cfg.totalWeight()
 
 	s.Log.Debugf("Total Capacity: %s", totalCapacity)
-	s.Log.Debugf("Target Partition Capacity: %s", partitionCapacity)
+	if len(cfg.Weights) == 0 {
+		s.Log.Debugf("Target Partition Capacity: %s", totalCapacity/time.Duration(totalWeight))
+	}
 
 	for i := 0; i < cfg.PartitionNodes.Total; i++ {
+		partitionCapacity := totalCapacity * time.Duration(cfg.weight(i)) / time.Duration(totalWeight)
+		if len(cfg.Weights) > 0 {
+			s.Log.Debugf("Target Capacity of Partition %d (weight %d): %s", i, cfg.weight(i), partitionCapacity)
+		}
+
 		partitions = append(partitions, testing.TestPartition{
 			Index:             i,
 			TestFilePaths:     make([]string, 0),
@@ -115,15 +239,18 @@ func (s Service) calculatePartition(ctx context.Context, cfg PartitionConfig) (P
 		s.Log.Debugf("%s: Assigned %s using most remaining capacity strategy", partition, fileTimingMatch)
 	}
 
-	for i, testFilepath := range unmatchedFilepaths {
-		partition := partitions[i%len(partitions)]
+	unmatchedFileCounts := make([]int, len(partitions))
+	for _, testFilepath := range unmatchedFilepaths {
+		partition := partitions[nextWeightedRoundRobinIndex(cfg, unmatchedFileCounts)]
 		partitions[partition.Index] = partition.AddFilePath(testFilepath)
+		unmatchedFileCounts[partition.Index]++
 		s.Log.Debugf("%s: Assigned '%s' using round robin strategy", partition, testFilepath)
 	}
 
 	return PartitionResult{
 		partition:              partitions[cfg.PartitionNodes.Index],
 		utilizedPartitionCount: utilizedPartitionCount(partitions),
+		selection:              selection,
 	}, nil
 }
 
@@ -150,6 +277,19 @@ func partitionWithMostRemainingCapacity(partitions []testing.TestPartition) test
 	return result
 }
 
+// nextWeightedRoundRobinIndex returns the partition that is furthest behind its share of files. Without weights, this
+// is regular round robin.
+func nextWeightedRoundRobinIndex(cfg PartitionConfig, fileCounts []int) int {
+	result := 0
+	for i := 1; i < len(fileCounts); i++ {
+		// (fileCounts[i] + 1) / weight(i) < (fileCounts[result] + 1) / weight(result)
+		if (fileCounts[i]+1)*cfg.weight(result) < (fileCounts[result]+1)*cfg.weight(i) {
+			result = i
+		}
+	}
+	return result
+}
+
 func utilizedPartitionCount(partitions []testing.TestPartition) int {
 	count := 0
 	for _, partition := range partitions {
@@ -163,4 +303,5 @@ func utilizedPartitionCount(partitions []testing.TestPartition) int {
 type PartitionResult struct {
 	partition              testing.TestPartition
 	utilizedPartitionCount int
+	selection              *SelectionResult
 }
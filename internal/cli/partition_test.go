@@ -2,7 +2,9 @@ package cli_test
 
 import (
 	"context"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -12,6 +14,8 @@ import (
 	"github.com/rwx-research/captain-cli/internal/cli"
 	"github.com/rwx-research/captain-cli/internal/config"
 	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/exec"
+	"github.com/rwx-research/captain-cli/internal/fs"
 	"github.com/rwx-research/captain-cli/internal/mocks"
 	"github.com/rwx-research/captain-cli/internal/parsing"
 	"github.com/rwx-research/captain-cli/internal/testing"
@@ -80,6 +84,17 @@ var _ = Describe("Partition", func() {
 			err = service.Partition(ctx, cfgWithArgs(0, 1, []string{}, " "))
 			Expect(err.Error()).To(ContainSubstring("Missing test file paths"))
 		})
+
+		It("requires one positive weight per partition", func() {
+			cfg := cfgWithGlob(0, 2, "*.test")
+			cfg.Weights = []int{2, 1, 1}
+			err = service.Partition(ctx, cfg)
+			Expect(err.Error()).To(ContainSubstring("Unsupported partition weights"))
+
+			cfg.Weights = []int{2, 0}
+			err = service.Partition(ctx, cfg)
+			Expect(err.Error()).To(ContainSubstring("Unsupported partition weights"))
+		})
 	})
 
 	Context("when the client provides multiple globs", func() {
@@ -171,6 +186,283 @@ var _ = Describe("Partition", func() {
 		})
 	})
 
+	Context("when partitions are weighted", func() {
+		BeforeEach(func() {
+			mockGetTimingManifest := func(
+				ctx context.Context,
+				testSuiteIdentifier string,
+			) ([]testing.TestFileTiming, error) {
+				return []testing.TestFileTiming{
+					{Filepath: "a.test", Duration: 4},
+					{Filepath: "b.test", Duration: 3},
+					{Filepath: "c.test", Duration: 2},
+					{Filepath: "d.test", Duration: 1},
+				}, nil
+			}
+			service.API.(*mocks.API).MockGetTestTimingManifest = mockGetTimingManifest
+			mockGlob := func(pattern string) ([]string, error) {
+				return []string{"a.test", "b.test", "c.test", "d.test", "e.test", "f.test", "g.test"}, nil
+			}
+			service.FileSystem.(*mocks.FileSystem).MockGlob = mockGlob
+		})
+
+		It("distributes the capacity proportionally", func() {
+			cfg := cfgWithGlob(0, 2, "*.test")
+			cfg.Weights = []int{3, 2}
+			_ = service.Partition(ctx, cfg)
+
+			assignments := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.DebugLevel).All() {
+				assignments = append(assignments, log.Message)
+			}
+			Expect(assignments).To(ContainElements([]string{
+				"Total Capacity: 10ns",
+				"Target Capacity of Partition 0 (weight 3): 6ns",
+				"Target Capacity of Partition 1 (weight 2): 4ns",
+			}))
+		})
+
+		It("logs the partitioned files for index 0", func() {
+			cfg := cfgWithGlob(0, 2, "*.test")
+			cfg.Weights = []int{3, 2}
+			_ = service.Partition(ctx, cfg)
+			logMessages := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.InfoLevel).All() {
+				logMessages = append(logMessages, log.Message)
+			}
+			Expect(logMessages).To(ContainElement("a.test c.test e.test g.test"))
+		})
+
+		It("logs the partitioned files for index 1", func() {
+			cfg := cfgWithGlob(1, 2, "*.test")
+			cfg.Weights = []int{3, 2}
+			_ = service.Partition(ctx, cfg)
+			logMessages := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.InfoLevel).All() {
+				logMessages = append(logMessages, log.Message)
+			}
+			Expect(logMessages).To(ContainElement("b.test d.test f.test"))
+		})
+	})
+
+	Context("when excluding test files", func() {
+		BeforeEach(func() {
+			service.API.(*mocks.API).MockGetTestTimingManifest = func(
+				ctx context.Context,
+				testSuiteIdentifier string,
+			) ([]testing.TestFileTiming, error) {
+				return []testing.TestFileTiming{}, nil
+			}
+			service.FileSystem.(*mocks.FileSystem).MockGlob = func(pattern string) ([]string, error) {
+				if pattern == "*_integration.test" {
+					return []string{"./b_integration.test", "d_integration.test"}, nil
+				}
+
+				return []string{"a.test", "b_integration.test", "c.test", "d_integration.test"}, nil
+			}
+		})
+
+		It("does not partition the excluded files", func() {
+			cfg := cfgWithGlob(0, 1, "*.test")
+			cfg.Exclude = []string{"*_integration.test"}
+			_ = service.Partition(ctx, cfg)
+
+			logMessages := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.InfoLevel).All() {
+				logMessages = append(logMessages, log.Message)
+			}
+			Expect(logMessages).To(ContainElement("a.test c.test"))
+		})
+	})
+
+	Context("when reading test files from a file list", func() {
+		BeforeEach(func() {
+			service.API.(*mocks.API).MockGetTestTimingManifest = func(
+				ctx context.Context,
+				testSuiteIdentifier string,
+			) ([]testing.TestFileTiming, error) {
+				return []testing.TestFileTiming{}, nil
+			}
+			service.FileSystem.(*mocks.FileSystem).MockGlob = func(pattern string) ([]string, error) {
+				if pattern == "*_integration.test" {
+					return []string{"c_integration.test"}, nil
+				}
+
+				return []string{"a.test"}, nil
+			}
+			service.FileSystem.(*mocks.FileSystem).MockOpen = func(name string) (fs.File, error) {
+				Expect(name).To(Equal("changed-files.txt"))
+				return &mocks.File{
+					Reader: strings.NewReader("# changed files\nb.test\n\nc_integration.test\ndeleted.test\na.test\n"),
+				}, nil
+			}
+			service.FileSystem.(*mocks.FileSystem).MockStat = func(name string) (os.FileInfo, error) {
+				if name == "deleted.test" {
+					return nil, os.ErrNotExist
+				}
+
+				return &mocks.FileInfo{}, nil
+			}
+		})
+
+		It("doesn't require test file globs", func() {
+			cfg := cfgWithArgs(0, 1, []string{}, " ")
+			cfg.FilesFrom = "changed-files.txt"
+			err = service.Partition(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+
+			logMessages := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.InfoLevel).All() {
+				logMessages = append(logMessages, log.Message)
+			}
+			Expect(logMessages).To(ContainElement("a.test b.test c_integration.test"))
+		})
+
+		It("combines the listed files with the globs and applies exclusions", func() {
+			cfg := cfgWithGlob(0, 1, "*.test")
+			cfg.FilesFrom = "changed-files.txt"
+			cfg.Exclude = []string{"*_integration.test"}
+			err = service.Partition(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+
+			logMessages := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.InfoLevel).All() {
+				logMessages = append(logMessages, log.Message)
+			}
+			Expect(logMessages).To(ContainElement("a.test b.test"))
+		})
+	})
+
+	Context("when selecting test files based on changes", func() {
+		var (
+			changedFiles string
+			gitFails     bool
+			cfg          cli.PartitionConfig
+		)
+
+		infoLogs := func() []string {
+			logMessages := make([]string, 0)
+			for _, log := range recordedLogs.FilterLevelExact(zap.InfoLevel).All() {
+				logMessages = append(logMessages, log.Message)
+			}
+			return logMessages
+		}
+
+		BeforeEach(func() {
+			changedFiles = ""
+			gitFails = false
+
+			cfg = cfgWithGlob(0, 1, "spec/**/*_spec.rb")
+			cfg.Selection = cli.SelectionConfig{
+				BaseRef:   "origin/main",
+				AlwaysRun: []string{"spec/smoke_spec.rb"},
+				Rules: []cli.SelectionRule{
+					{Changed: `app/models/(.*)\.rb`, Tests: []string{"spec/models/${1}_spec.rb"}},
+					{Changed: `config/.*`, Tests: []string{}},
+				},
+			}
+
+			service.API.(*mocks.API).MockGetTestTimingManifest = func(
+				ctx context.Context,
+				testSuiteIdentifier string,
+			) ([]testing.TestFileTiming, error) {
+				return []testing.TestFileTiming{}, nil
+			}
+			service.FileSystem.(*mocks.FileSystem).MockGlob = func(pattern string) ([]string, error) {
+				switch pattern {
+				case "spec/**/*_spec.rb":
+					return []string{
+						"spec/models/order_spec.rb",
+						"spec/models/user_spec.rb",
+						"spec/requests/users_spec.rb",
+						"spec/smoke_spec.rb",
+					}, nil
+				case "spec/models/user_spec.rb", "spec/smoke_spec.rb", "spec/requests/users_spec.rb":
+					return []string{pattern}, nil
+				default:
+					return []string{}, nil
+				}
+			}
+			service.FileSystem.(*mocks.FileSystem).MockGetwd = func() (string, error) {
+				return "/repo", nil
+			}
+			service.TaskRunner.(*mocks.TaskRunner).MockNewCommand = func(
+				ctx context.Context,
+				commandConfig exec.CommandConfig,
+			) (exec.Command, error) {
+				Expect(commandConfig.Name).To(Equal("git"))
+
+				return &mocks.Command{
+					MockStart: func() error {
+						var err error
+						switch commandConfig.Args[0] {
+						case "rev-parse":
+							_, err = commandConfig.Stdout.Write([]byte("/repo\n"))
+						case "merge-base":
+							Expect(commandConfig.Args).To(Equal([]string{"merge-base", "origin/main", "HEAD"}))
+							_, err = commandConfig.Stdout.Write([]byte("abc123\n"))
+						case "diff":
+							Expect(commandConfig.Args).To(Equal([]string{"diff", "--name-only", "abc123"}))
+							_, err = commandConfig.Stdout.Write([]byte(changedFiles))
+						}
+						return err
+					},
+					MockWait: func() error {
+						if gitFails {
+							return errors.NewSystemError("exit status 128")
+						}
+						return nil
+					},
+				}, nil
+			}
+		})
+
+		It("selects the test files mapped from the changed files", func() {
+			changedFiles = "app/models/user.rb\nconfig/routes.rb\nspec/requests/users_spec.rb\n"
+			err = service.Partition(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(infoLogs()).To(ContainElement(
+				"spec/models/user_spec.rb spec/requests/users_spec.rb spec/smoke_spec.rb",
+			))
+		})
+
+		It("falls back to the full suite when a changed file can't be mapped", func() {
+			changedFiles = "app/models/user.rb\nlib/tasks/import.rake\n"
+			err = service.Partition(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(infoLogs()).To(ContainElement(
+				"spec/models/order_spec.rb spec/models/user_spec.rb spec/requests/users_spec.rb spec/smoke_spec.rb",
+			))
+		})
+
+		It("falls back to the full suite when the changes can't be determined", func() {
+			gitFails = true
+			err = service.Partition(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(infoLogs()).To(ContainElement(
+				"spec/models/order_spec.rb spec/models/user_spec.rb spec/requests/users_spec.rb spec/smoke_spec.rb",
+			))
+		})
+
+		It("tags a digest of the selected test files", func() {
+			tags := cli.SelectionResult{TestFilePaths: []string{"b_spec.rb", "a_spec.rb"}}.JobTags()
+			reordered := cli.SelectionResult{TestFilePaths: []string{"a_spec.rb", "b_spec.rb"}}.JobTags()
+			other := cli.SelectionResult{TestFilePaths: []string{"a_spec.rb"}}.JobTags()
+
+			Expect(tags).To(HaveKeyWithValue("captain_selected_test_files_count", 2))
+			Expect(tags["captain_selected_test_files_digest"]).To(HaveLen(64))
+			Expect(tags["captain_selected_test_files_digest"]).To(Equal(reordered["captain_selected_test_files_digest"]))
+			Expect(tags["captain_selected_test_files_digest"]).NotTo(Equal(other["captain_selected_test_files_digest"]))
+		})
+
+		It("rejects invalid rules", func() {
+			cfg.Selection.Rules = []cli.SelectionRule{{Changed: "app/(", Tests: []string{"spec"}}}
+			err = service.Partition(ctx, cfg)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid test selection rule"))
+		})
+	})
+
 	Context("when there are no test file timings", func() {
 		BeforeEach(func() {
 			mockGlob := func(pattern string) ([]string, error) {
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/rwx-research/captain-cli/internal/progress"
+)
+
+const (
+	// ProgressAuto shows a live status line & a compact summary (with `--print-summary`) if stdout is a terminal. This
+	// is the default.
+	ProgressAuto = "auto"
+	// ProgressAlways shows a live status line & a compact summary (with `--print-summary`) even if stdout isn't
+	// detected as a terminal. The status line is still only drawn if the size of the terminal can be determined.
+	ProgressAlways = "always"
+	// ProgressNever disables the live status line & the compact summary.
+	ProgressNever = "never"
+)
+
+// progressEnabled returns whether the run should be rendered interactively.
+func (rc RunConfig) progressEnabled() bool {
+	if rc.Quiet {
+		return false
+	}
+
+	switch rc.Progress {
+	case ProgressAlways:
+		return true
+	case ProgressNever:
+		return false
+	default:
+		return progress.IsTerminal(os.Stdout)
+	}
+}
+
+// maxAttempts returns the number of times a test may run at most, including the original run.
+func (rc RunConfig) maxAttempts() int {
+	retries := rc.Retries
+	if rc.FlakyRetries > retries {
+		retries = rc.FlakyRetries
+	}
+
+	if retries < 0 {
+		return 1
+	}
+
+	return retries + 1
+}
+
+// newProgressLine returns a status line on stdout, or nil if the run isn't rendered interactively.
+func newProgressLine(cfg RunConfig) *progress.Line {
+	if !cfg.progressEnabled() {
+		return nil
+	}
+
+	return progress.New(os.Stdout, progress.TerminalSize(os.Stdout), progress.ColorEnabled())
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/mattn/go-shellwords"
+
+	"github.com/rwx-research/captain-cli/internal/backend"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/targetedretries"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	// QuarantineModeRun runs quarantined tests and ignores their failures afterwards. This is the default.
+	QuarantineModeRun = "run"
+	// QuarantineModeSkip excludes quarantined tests from the run using the `quarantineExclusions` keyword.
+	QuarantineModeSkip = "skip"
+
+	quarantineExclusionsKeyword = "quarantineExclusions"
+)
+
+var quarantineExclusionsPlaceholder = regexp.MustCompile(`{{\s?` + quarantineExclusionsKeyword + `\s?}}`)
+
+func hasQuarantineExclusionsPlaceholder(values ...string) bool {
+	for _, value := range values {
+		if quarantineExclusionsPlaceholder.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// substituteQuarantineExclusions replaces the `quarantineExclusions` placeholder in `command` and `args` with
+// `exclusions`. Arguments that consist of only the placeholder are expanded into multiple arguments.
+func substituteQuarantineExclusions(command string, args []string, exclusions string) (string, []string, error) {
+	command = quarantineExclusionsPlaceholder.ReplaceAllLiteralString(command, exclusions)
+
+	exclusionArgs, err := shellwords.Parse(exclusions)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "Unable to parse %q into shell arguments", exclusions)
+	}
+
+	substitutedArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if quarantineExclusionsPlaceholder.FindString(arg) == arg && arg != "" {
+			substitutedArgs = append(substitutedArgs, exclusionArgs...)
+			continue
+		}
+
+		substitutedArgs = append(substitutedArgs, quarantineExclusionsPlaceholder.ReplaceAllLiteralString(arg, exclusions))
+	}
+
+	return command, substitutedArgs, nil
+}
+
+// quarantineExclusions assembles the command-line arguments that exclude the quarantined tests from the run, and
+// returns them together with the quarantined tests that are actually excluded. Quarantined tests are only excluded if
+// the framework is able to select them exactly, i.e. if they don't use patterns and their identity can be expressed in
+// the filters of the framework. All other quarantined tests run as usual.
+func (s Service) quarantineExclusions(
+	cfg RunConfig,
+	quarantinedTests []backend.QuarantinedTest,
+) (string, []backend.Test) {
+	framework := v1.CoerceFramework(s.ParseConfig.ProvidedFrameworkLanguage, s.ParseConfig.ProvidedFrameworkKind)
+
+	exclusion, ok := cfg.SubstitutionsByFramework[framework].(targetedretries.Exclusion)
+	if !ok {
+		s.Log.Warnf(
+			"Captain is unable to skip quarantined tests of %v up front. Quarantined tests will run, but their "+
+				"failures are still ignored.", framework,
+		)
+		return "", nil
+	}
+
+	excludedTests := make([]targetedretries.ExcludedTest, 0, len(quarantinedTests))
+	excludedQuarantinedTests := make([]backend.Test, 0, len(quarantinedTests))
+	unexcludedQuarantinedTests := 0
+
+	for _, quarantinedTest := range quarantinedTests {
+		excludedTest, ok := excludedTestOf(quarantinedTest.Test)
+		if !ok || !exclusion.CanExclude(excludedTest) {
+			s.Log.Debugf("Unable to exclude quarantined test %q from the run", quarantinedTest.CompositeIdentifier)
+			unexcludedQuarantinedTests++
+			continue
+		}
+
+		excludedTests = append(excludedTests, excludedTest)
+		excludedQuarantinedTests = append(excludedQuarantinedTests, quarantinedTest.Test)
+	}
+
+	if unexcludedQuarantinedTests > 0 {
+		s.Log.Warnf(
+			"Captain is unable to skip %d of the quarantined tests of %v up front, as they can't be selected by their "+
+				"exact identity. These will run, but their failures are still ignored.",
+			unexcludedQuarantinedTests, framework,
+		)
+	}
+
+	return exclusion.ExclusionArgs(excludedTests), excludedQuarantinedTests
+}
+
+// excludedTestOf returns the exact identity of a quarantined test. Tests that are identified by patterns can't be
+// excluded.
+func excludedTestOf(quarantinedTest backend.Test) (targetedretries.ExcludedTest, bool) {
+	if backend.IsPattern(quarantinedTest.Match) {
+		return targetedretries.ExcludedTest{}, false
+	}
+
+	identity := make(map[string]string, len(quarantinedTest.IdentityComponents))
+	for _, component := range quarantinedTest.IdentityComponents {
+		value, ok := quarantinedTest.IdentityValue(component)
+		if !ok {
+			return targetedretries.ExcludedTest{}, false
+		}
+
+		identity[component] = value
+	}
+
+	return targetedretries.ExcludedTest{Identity: identity}, true
+}
+
+// skippedQuarantinedTests returns placeholder results for quarantined tests that were excluded from the run and
+// therefore don't show up in the test results. `excludedQuarantinedTests` must only contain the tests that were
+// actually excluded (see `quarantineExclusions`). When running a partition, only tests from its `testFilePaths` are
+// considered, as others most likely belong to a different partition.
+func (s Service) skippedQuarantinedTests(
+	testResults v1.TestResults,
+	excludedQuarantinedTests []backend.Test,
+	testFilePaths []string,
+) []v1.Test {
+	message := "Skipped by Captain because the test is quarantined"
+	skippedTests := make([]v1.Test, 0)
+
+	for _, quarantinedTest := range excludedQuarantinedTests {
+		found := false
+		for _, test := range testResults.Tests {
+			if s.isIdentifiedIn(test, []backend.Test{quarantinedTest}) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		test := v1.Test{Attempt: v1.TestAttempt{Status: v1.NewQuarantinedTestStatus(v1.NewSkippedTestStatus(&message))}}
+		if id, ok := quarantinedTest.IdentityValue("id"); ok {
+			test.ID = &id
+			test.Name = id
+		}
+		if description, ok := quarantinedTest.IdentityValue("description"); ok {
+			test.Name = description
+		}
+		if file, ok := quarantinedTest.IdentityValue("file"); ok {
+			test.Location = &v1.Location{File: file}
+		}
+
+		if test.Name == "" {
+			continue
+		}
+
+		if testFilePaths != nil && (test.Location == nil || !containsPath(testFilePaths, test.Location.File)) {
+			continue
+		}
+
+		skippedTests = append(skippedTests, test)
+	}
+
+	return skippedTests
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, candidate := range paths {
+		if filepath.Clean(candidate) == filepath.Clean(path) {
+			return true
+		}
+	}
+
+	return false
+}
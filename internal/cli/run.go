@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/mattn/go-shellwords"
 	"golang.org/x/sync/errgroup"
@@ -15,6 +16,7 @@ import (
 	"github.com/rwx-research/captain-cli/internal/backend/remote"
 	"github.com/rwx-research/captain-cli/internal/errors"
 	"github.com/rwx-research/captain-cli/internal/exec"
+	"github.com/rwx-research/captain-cli/internal/progress"
 	"github.com/rwx-research/captain-cli/internal/providers"
 	"github.com/rwx-research/captain-cli/internal/reporting"
 	"github.com/rwx-research/captain-cli/internal/targetedretries"
@@ -29,14 +31,26 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 		return errors.WithStack(err)
 	}
 
+	// `s.API` must not be reassigned once the run configuration is fetched in the background
+	if cfg.IsRunningPartition() {
+		switch client := s.API.(type) {
+		case remote.Client:
+			client.Provider = client.Provider.WithPartitionNodes(cfg.PartitionConfig.PartitionNodes)
+			s.API = client
+		case local.Client:
+			s.API = client.WithPartition(cfg.PartitionConfig.PartitionNodes.String())
+		}
+	}
+
 	// Fetch run configuration in the background
 	var apiConfiguration backend.RunConfiguration
 	eg, egCtx := errgroup.WithContext(ctx)
 	eg.Go(func() error {
-		apiConfiguration, err = s.API.GetRunConfiguration(egCtx, cfg.SuiteID)
+		configuration, err := s.API.GetRunConfiguration(egCtx, cfg.SuiteID)
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		apiConfiguration = configuration
 
 		if len(apiConfiguration.QuarantinedTests) == 0 {
 			s.Log.Debug("No quarantined tests defined in Captain")
@@ -45,6 +59,9 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 		return nil
 	})
 
+	progressLine := newProgressLine(cfg)
+	defer progressLine.Stop()
+
 	stdout := os.Stdout
 	if cfg.Quiet {
 		// According to the documentation, passing in a nil pointer to `os.Exec`
@@ -57,18 +74,52 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 		}
 	}
 
-	runCommand, err := s.makeRunCommand(ctx, cfg)
+	// Skipping quarantined tests requires knowing them before running the command
+	var quarantineExclusions string
+	var excludedQuarantinedTests []backend.Test
+	skipQuarantinedTests := cfg.QuarantineMode == QuarantineModeSkip
+	if skipQuarantinedTests {
+		if err := eg.Wait(); err != nil {
+			s.Log.Warnf("Unable to fetch run configuration from Captain: %s", err)
+		}
+
+		if len(apiConfiguration.QuarantinedTests) > 0 {
+			quarantineExclusions, excludedQuarantinedTests = s.quarantineExclusions(
+				cfg, apiConfiguration.QuarantinedTests,
+			)
+		}
+		s.Log.Debugf("Excluding quarantined tests using %q", quarantineExclusions)
+	}
+
+	runCommand, err := s.makeRunCommand(ctx, cfg, quarantineExclusions)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to assemble run command")
 	}
 
+	defer func() {
+		if err := runCommand.CleanUp(); err != nil {
+			s.Log.Warn(err)
+		}
+	}()
+
 	// Short circuit and print warning info (e.g attempting to run an empty partition)
 	if runCommand.shortCircuit {
 		s.Log.Warnf(runCommand.shortCircuitInfo)
-		os.Exit(0)
+
+		// The service must not be closed while the run configuration is still being fetched
+		_ = eg.Wait()
+		return nil
+	}
+
+	// Test results are reported with the job tags of the file selection, if any
+	api := s.API
+	if remoteClient, ok := api.(remote.Client); ok && cfg.IsRunningPartition() && runCommand.selection != nil {
+		remoteClient.Provider = remoteClient.Provider.WithJobTags(runCommand.selection.JobTags())
+		api = remoteClient
 	}
 
 	// Run sub-command
+	progressLine.Update(progress.Status{Attempt: 1, MaxAttempts: cfg.maxAttempts()})
 	ctx, cmdErr := s.runCommand(ctx, runCommand.commandArgs, stdout, true)
 	defer func() {
 		if abqErr := s.setAbqExitCode(ctx, finalErr); abqErr != nil {
@@ -85,11 +136,21 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 	}
 
 	// Wait until run configuration was fetched. Ignore any errors.
-	if err := eg.Wait(); err != nil {
-		s.Log.Warnf("Unable to fetch run configuration from Captain: %s", err)
+	if !skipQuarantinedTests {
+		if err := eg.Wait(); err != nil {
+			s.Log.Warnf("Unable to fetch run configuration from Captain: %s", err)
+		}
 	}
 
-	testResults, didRetry, err := s.attemptRetries(ctx, testResults, testResultsFiles, cfg, apiConfiguration)
+	testResults, didRetry, err := s.attemptRetries(
+		ctx,
+		testResults,
+		testResultsFiles,
+		cfg,
+		apiConfiguration,
+		progressLine,
+	)
+	progressLine.Stop()
 	if err != nil {
 		s.Log.Warnf("An issue occurred while retrying your tests: %v", err)
 	}
@@ -111,17 +172,28 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 				testResults.Tests[i] = test.Quarantine()
 				s.Log.Debugf("quarantined %v test: %v", test.Attempt.Status, test)
 				quarantinedFailedTests = append(quarantinedFailedTests, test)
+			} else if skipQuarantinedTests && test.Attempt.Status.ImpliesSkipped() && s.isIdentifiedIn(test, quarantinedTests) {
+				testResults.Tests[i] = test.Quarantine()
+				s.Log.Debugf("quarantined skipped test: %v", test)
 			} else if test.Attempt.Status.ImpliesFailure() {
 				s.Log.Debugf("did not quarantine %v test: %v", test.Attempt.Status, test)
 				unquarantinedFailedTests = append(unquarantinedFailedTests, test)
 			}
 		}
+
+		if len(excludedQuarantinedTests) > 0 {
+			skippedTests := s.skippedQuarantinedTests(*testResults, excludedQuarantinedTests, runCommand.testFilePaths)
+			testResults.Tests = append(testResults.Tests, skippedTests...)
+		}
+
 		testResults.Summary = v1.NewSummary(testResults.Tests, testResults.OtherErrors)
 	}
 
 	var uploadResults []backend.TestResultsUploadResult
 	var uploadError error
 	var headerPrinted bool
+	var baseline *v1.TestResults
+	var timings map[string]time.Duration
 
 	if cfg.PrintSummary {
 		s.printHeader()
@@ -131,7 +203,10 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 	// We ignore the error here since `UploadTestResults` will already log any errors. Furthermore, any errors here will
 	// not affect the exit code.
 	if testResults != nil {
-		uploadResults, uploadError = s.reportTestResults(ctx, cfg, *testResults)
+		// The baseline & timings need to be read before the test results of this run replace them
+		baseline = s.storedResults()
+		timings = s.storedTimings()
+		uploadResults, uploadError = s.reportTestResults(ctx, api, cfg, *testResults, baseline, timings)
 	} else {
 		s.Log.Debugf("No test results were parsed. Globbed files: %v", testResultsFiles)
 	}
@@ -234,15 +309,103 @@ func (s Service) RunSuite(ctx context.Context, cfg RunConfig) (finalErr error) {
 		return uploadError
 	}
 
+	if cfg.FailOnDurationRegression && testResults != nil {
+		return s.checkDurationRegressions(cfg, timings, *testResults)
+	}
+
 	return nil
 }
 
+// checkDurationRegressions returns an error if any test file took considerably longer than its stored timing.
+func (s Service) checkDurationRegressions(
+	cfg RunConfig,
+	timings map[string]time.Duration,
+	testResults v1.TestResults,
+) error {
+	if timings == nil {
+		s.Log.Warnln(
+			"Unable to check for duration regressions: there are no stored timings to compare against. Captain " +
+				"stores the timings of test files in OSS mode.",
+		)
+		return nil
+	}
+
+	threshold := cfg.DurationRegressionThreshold
+	if threshold <= 0 {
+		threshold = reporting.DefaultDurationRegressionThreshold
+	}
+
+	regressions := reporting.FileDurationRegressions(timings, testResults, threshold)
+	if len(regressions) == 0 {
+		return nil
+	}
+
+	s.Log.Infoln(
+		fmt.Sprintf(
+			"\n%v %v more than %v%% longer than %v:",
+			len(regressions),
+			pluralize(len(regressions), "test file took", "test files took"),
+			threshold,
+			pluralize(len(regressions), "its stored timing", "their stored timings"),
+		),
+	)
+
+	for _, regression := range regressions {
+		s.Log.Infoln(fmt.Sprintf(
+			"- %v (%v → %v)",
+			regression.File,
+			regression.Before.Round(time.Millisecond),
+			regression.After.Round(time.Millisecond),
+		))
+	}
+
+	return errors.NewExecutionError(
+		1,
+		"%v %v regressed in duration",
+		len(regressions),
+		pluralize(len(regressions), "test file", "test files"),
+	)
+}
+
+// storedResults returns the test results stored by a previous run in OSS mode, or nil if there are none.
+func (s Service) storedResults() *v1.TestResults {
+	localClient, ok := s.API.(local.Client)
+	if !ok {
+		return nil
+	}
+
+	testResults, err := localClient.StoredResults()
+	if err != nil {
+		s.Log.Warnf("Unable to read the stored results of a previous run: %s", err.Error())
+		return nil
+	}
+
+	return testResults
+}
+
+// storedTimings returns a copy of the timings of test files stored in OSS mode, or nil if there are none. The copy
+// is necessary since the client updates its timings in place once the test results of this run are reported.
+func (s Service) storedTimings() map[string]time.Duration {
+	localClient, ok := s.API.(local.Client)
+	if !ok || len(localClient.Timings) == 0 {
+		return nil
+	}
+
+	timings := make(map[string]time.Duration, len(localClient.Timings))
+	for file, duration := range localClient.Timings {
+		timings[file] = duration
+	}
+
+	return timings
+}
+
 func (s Service) attemptRetries(
 	ctx context.Context,
 	originalTestResults *v1.TestResults,
 	originalTestResultsFiles []string,
 	cfg RunConfig,
 	apiConfiguration backend.RunConfiguration,
+	progressLine *progress.Line,
 ) (*v1.TestResults, bool, error) {
 	nonFlakyRetries := cfg.Retries
 	flakyRetries := cfg.FlakyRetries
@@ -310,6 +473,10 @@ func (s Service) attemptRetries(
 		return flattenedTestResults, false, errors.WithStack(err)
 	}
 
+	if err := ias.copyAttachments(flattenedTestResults); err != nil {
+		s.Log.Warnf("Unable to copy test attachments: %v", err)
+	}
+
 	maxTestsToRetryCount, err := cfg.MaxTestsToRetryCount()
 	if err != nil {
 		return flattenedTestResults, false, errors.WithStack(err)
@@ -329,6 +496,8 @@ func (s Service) attemptRetries(
 		formattedRetryTotal = ""
 	}
 
+	retriedTests := 0
+
 	for retries := 0; retries < maxRetries; retries++ {
 		remainingFlakyFailures := make([]v1.Test, 0)
 		remainingNonFlakyFailures := make([]v1.Test, 0)
@@ -350,13 +519,15 @@ func (s Service) attemptRetries(
 		nonFlakyAttemptsExhausted := retries >= nonFlakyRetries
 		flakyAttemptsExhausted := retries >= flakyRetries
 
-		testsRemaining := 0
-		if !nonFlakyAttemptsExhausted {
-			testsRemaining += len(remainingNonFlakyFailures)
-		}
+		retryingFlakyFailures := 0
 		if !flakyAttemptsExhausted {
-			testsRemaining += len(remainingFlakyFailures)
+			retryingFlakyFailures = len(remainingFlakyFailures)
+		}
+		retryingNonFlakyFailures := 0
+		if !nonFlakyAttemptsExhausted {
+			retryingNonFlakyFailures = len(remainingNonFlakyFailures)
 		}
+		testsRemaining := retryingFlakyFailures + retryingNonFlakyFailures
 
 		// bail early if there are too many failed tests
 		if maxTestsToRetryCount != nil && testsRemaining > *maxTestsToRetryCount {
@@ -386,6 +557,15 @@ func (s Service) attemptRetries(
 			break
 		}
 
+		retriedTests += testsRemaining
+		progressLine.Update(progress.Status{
+			Attempt:                   retries + 2,
+			MaxAttempts:               maxRetries + 1,
+			RetriedTests:              retriedTests,
+			RemainingFlakyFailures:    len(remainingFlakyFailures),
+			RemainingNonFlakyFailures: len(remainingNonFlakyFailures),
+		})
+
 		filter := func(test v1.Test) bool {
 			testIsFlaky := false
 			for _, remainingFlakyFailure := range remainingFlakyFailures {
@@ -435,6 +615,13 @@ func (s Service) attemptRetries(
 					len(allSubstitutions),
 				))
 			}
+			s.Log.Infoln(fmt.Sprintf(
+				"-   Retrying %v failed %v (%v flaky, %v non-flaky)",
+				testsRemaining,
+				pluralize(testsRemaining, "test", "tests"),
+				retryingFlakyFailures,
+				retryingNonFlakyFailures,
+			))
 			for keyword, value := range substitutions {
 				s.Log.Infoln(fmt.Sprintf("-   %v: %v", keyword, value))
 			}
@@ -479,6 +666,10 @@ func (s Service) attemptRetries(
 				return flattenedTestResults, true, err
 			}
 
+			if err := ias.copyAttachments(newTestResults); err != nil {
+				s.Log.Warnf("Unable to copy test attachments: %v", err)
+			}
+
 			if newTestResults != nil {
 				allNewTestResults = append(allNewTestResults, *newTestResults)
 			}
@@ -582,6 +773,13 @@ func (s Service) runCommand(
 
 func (s Service) isIdentifiedIn(test v1.Test, identifiedTests []backend.Test) bool {
 	for _, identifiedTest := range identifiedTests {
+		if backend.IsPattern(identifiedTest.Match) {
+			if s.isMatchedBy(test, identifiedTest) {
+				return true
+			}
+			continue
+		}
+
 		compositeIdentifier, err := test.Identify(
 			identifiedTest.IdentityComponents,
 			identifiedTest.StrictIdentity,
@@ -614,23 +812,68 @@ func (s Service) isIdentifiedIn(test v1.Test, identifiedTests []backend.Test) bo
 	return false
 }
 
+// isMatchedBy evaluates the identity patterns of `identifiedTest` (see `backend.MatchGlob` and `backend.MatchRegex`)
+// against `test`. Every identity component needs to match its respective pattern.
+func (s Service) isMatchedBy(test v1.Test, identifiedTest backend.Test) bool {
+	if len(identifiedTest.IdentityValues) != len(identifiedTest.IdentityComponents) {
+		s.Log.Debugf("%v does not identify %v because its patterns are incomplete", identifiedTest, test)
+		return false
+	}
+
+	explanations := make([]string, len(identifiedTest.IdentityComponents))
+	for i, component := range identifiedTest.IdentityComponents {
+		pattern := identifiedTest.IdentityValues[i]
+
+		value, err := test.Identify([]string{component}, identifiedTest.StrictIdentity)
+		if err != nil {
+			s.Log.Debugf("%v does not identify %v because %v", identifiedTest, test, err.Error())
+			return false
+		}
+
+		compiled, err := backend.CompilePattern(identifiedTest.Match, pattern)
+		if err != nil {
+			s.Log.Debugf("%v does not identify %v because %v", identifiedTest, test, err.Error())
+			return false
+		}
+
+		if !compiled.MatchString(value) {
+			s.Log.Debugf(
+				"%v does not identify %v because its %s (%q) does not match the %s pattern %q",
+				identifiedTest, test, component, value, identifiedTest.Match, pattern,
+			)
+			return false
+		}
+
+		explanations[i] = fmt.Sprintf("%s %q matches the %s pattern %q", component, value, identifiedTest.Match, pattern)
+	}
+
+	s.Log.Debugf("%v identifies %v because %s", identifiedTest, test, strings.Join(explanations, ", "))
+	return true
+}
+
 func (s Service) reportTestResults(
 	ctx context.Context,
+	api backend.Client,
 	cfg RunConfig,
 	testResults v1.TestResults,
+	baseline *v1.TestResults,
+	timings map[string]time.Duration,
 ) ([]backend.TestResultsUploadResult, error) {
 	reportingConfiguration := reporting.Configuration{
-		SuiteID:              cfg.SuiteID,
-		RetryCommandTemplate: cfg.RetryCommandTemplate,
+		SuiteID:                     cfg.SuiteID,
+		RetryCommandTemplate:        cfg.RetryCommandTemplate,
+		Baseline:                    baseline,
+		Timings:                     timings,
+		DurationRegressionThreshold: cfg.DurationRegressionThreshold,
 	}
 
-	if remoteClient, ok := s.API.(remote.Client); ok {
+	if remoteClient, ok := api.(remote.Client); ok {
 		reportingConfiguration.CloudEnabled = true
 		reportingConfiguration.CloudHost = remoteClient.Host
 		reportingConfiguration.Provider = remoteClient.Provider
 	}
 
-	if _, ok := s.API.(local.Client); ok {
+	if _, ok := api.(local.Client); ok {
 		reportingConfiguration.CloudEnabled = false
 		reportingConfiguration.CloudHost = ""
 		reportingConfiguration.Provider = providers.Provider{}
@@ -646,7 +889,14 @@ func (s Service) reportTestResults(
 		}
 	}
 
-	if cfg.PrintSummary {
+	// Interactive runs print the summary in a compact form that fits their status line
+	if cfg.PrintSummary && cfg.progressEnabled() {
+		if err := reporting.WriteCompactSummary(os.Stdout, testResults, progress.ColorEnabled()); err != nil {
+			s.Log.Warnf("Unable to write summary to stdout: %s", err.Error())
+		} else {
+			fmt.Fprintf(os.Stdout, "\n")
+		}
+	} else if cfg.PrintSummary {
 		if err := reporting.WriteTextSummary(os.Stdout, testResults, reportingConfiguration); err != nil {
 			s.Log.Warnf("Unable to write text summary to stdout: %s", err.Error())
 		} else {
@@ -655,15 +905,15 @@ func (s Service) reportTestResults(
 		}
 	}
 
-	if _, ok := s.API.(local.Client); ok && !cfg.UpdateStoredResults {
+	if _, ok := api.(local.Client); ok && !cfg.UpdateStoredResults {
 		return nil, nil
 	}
 
-	if _, ok := s.API.(remote.Client); ok && !cfg.UploadResults {
+	if _, ok := api.(remote.Client); ok && !cfg.UploadResults {
 		return nil, nil
 	}
 
-	result, err := s.API.UpdateTestResults(ctx, cfg.SuiteID, testResults)
+	result, err := api.UpdateTestResults(ctx, cfg.SuiteID, testResults)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to update test results")
 	}
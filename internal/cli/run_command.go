@@ -17,6 +17,19 @@ type RunCommand struct {
 	commandArgs      []string
 	shortCircuit     bool
 	shortCircuitInfo string
+	selection        *SelectionResult
+	// testFilePaths holds the test files of the partition, if running one
+	testFilePaths []string
+	cleanUp       func() error
+}
+
+// CleanUp removes any temporary files that were created to assemble the command.
+func (rc RunCommand) CleanUp() error {
+	if rc.cleanUp == nil {
+		return nil
+	}
+
+	return rc.cleanUp()
 }
 
 func commandArgs(command string, args []string) ([]string, error) {
@@ -39,9 +52,23 @@ func commandArgs(command string, args []string) ([]string, error) {
 	return commandArgs, nil
 }
 
-func (s Service) makeRunCommand(ctx context.Context, cfg RunConfig) (RunCommand, error) {
+// makeRunCommand assembles the command to run. `quarantineExclusions` is substituted for the `quarantineExclusions`
+// keyword, which is simply removed if there aren't any tests to exclude.
+func (s Service) makeRunCommand(ctx context.Context, cfg RunConfig, quarantineExclusions string) (RunCommand, error) {
+	command, args, err := substituteQuarantineExclusions(cfg.Command, cfg.Args, quarantineExclusions)
+	if err != nil {
+		return RunCommand{}, errors.WithStack(err)
+	}
+
+	partitionCommandTemplate, _, err := substituteQuarantineExclusions(
+		cfg.PartitionCommandTemplate, nil, quarantineExclusions,
+	)
+	if err != nil {
+		return RunCommand{}, errors.WithStack(err)
+	}
+
 	if !cfg.IsRunningPartition() {
-		commandArgs, err := commandArgs(cfg.Command, cfg.Args)
+		commandArgs, err := commandArgs(command, args)
 		if err != nil {
 			return RunCommand{}, err
 		}
@@ -55,19 +82,26 @@ func (s Service) makeRunCommand(ctx context.Context, cfg RunConfig) (RunCommand,
 	partitionedTestFilePaths := partitionResult.partition.TestFilePaths
 
 	// compile template
-	compiledPartitionTemplate, err := templating.CompileTemplate(cfg.PartitionCommandTemplate)
+	compiledPartitionTemplate, err := templating.CompileTemplate(partitionCommandTemplate)
 	if err != nil {
 		return RunCommand{}, errors.WithStack(err)
 	}
 
 	// validate template
-	substitution := runpartition.DelimiterSubstitution{Delimiter: cfg.PartitionConfig.Delimiter}
+	substitution := runpartition.DelimiterSubstitution{
+		Delimiter:      cfg.PartitionConfig.Delimiter,
+		PartitionNodes: cfg.PartitionConfig.PartitionNodes,
+		FileSystem:     s.FileSystem,
+	}
 	if err := substitution.ValidateTemplate(compiledPartitionTemplate); err != nil {
 		return RunCommand{}, errors.WithStack(err)
 	}
 
 	// substitute template keywords with values
-	substitutionValueLookup, err := substitution.SubstitutionLookupFor(compiledPartitionTemplate, partitionedTestFilePaths)
+	substitutionValueLookup, cleanUp, err := substitution.SubstitutionLookupFor(
+		compiledPartitionTemplate,
+		partitionedTestFilePaths,
+	)
 	if err != nil {
 		return RunCommand{}, errors.WithStack(err)
 	}
@@ -75,6 +109,9 @@ func (s Service) makeRunCommand(ctx context.Context, cfg RunConfig) (RunCommand,
 
 	commandArgs, err := commandArgs(partitionCommand, nil)
 	if err != nil {
+		if cleanUpErr := cleanUp(); cleanUpErr != nil {
+			s.Log.Warn(cleanUpErr)
+		}
 		return RunCommand{}, err
 	}
 
@@ -87,9 +124,19 @@ func (s Service) makeRunCommand(ctx context.Context, cfg RunConfig) (RunCommand,
 			cfg.PartitionConfig.PartitionNodes.Total,
 			partitionResult.utilizedPartitionCount,
 		)
+		if err := cleanUp(); err != nil {
+			s.Log.Warn(err)
+		}
+
 		// short circuit to avoid running the entire test suite in a single partition (e.g empty partition)
 		return RunCommand{commandArgs: commandArgs, shortCircuit: true, shortCircuitInfo: infoMessage}, nil
 	}
 
-	return RunCommand{commandArgs: commandArgs, shortCircuit: false}, nil
+	return RunCommand{
+		commandArgs:   commandArgs,
+		shortCircuit:  false,
+		selection:     partitionResult.selection,
+		testFilePaths: partitionedTestFilePaths,
+		cleanUp:       cleanUp,
+	}, nil
 }
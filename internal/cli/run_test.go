@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -446,6 +447,97 @@ var _ = Describe("Run", func() {
 			})
 		})
 
+		Context("when failing on duration regressions", func() {
+			BeforeEach(func() {
+				after := 3 * time.Second
+				service.ParseConfig.MutuallyExclusiveParsers[0].(*mocks.Parser).MockParse = func(r io.Reader) (
+					*v1.TestResults,
+					error,
+				) {
+					return v1.NewTestResults(v1.RubyRSpecFramework, []v1.Test{{
+						Name:     "gets slower",
+						Location: &v1.Location{File: "spec/slow_spec.rb"},
+						Attempt:  v1.TestAttempt{Duration: &after, Status: v1.NewSuccessfulTestStatus()},
+					}}, nil), nil
+				}
+
+				runConfig.FailOnDurationRegression = true
+			})
+
+			Context("with stored timings", func() {
+				BeforeEach(func() {
+					localFileSystem := new(mocks.FileSystem)
+					localFileSystem.MockOpen = func(name string) (fs.File, error) {
+						if name == "timings.yaml" {
+							return &mocks.File{Reader: strings.NewReader("spec/slow_spec.rb: 1s\n")}, nil
+						}
+
+						return &mocks.File{Reader: strings.NewReader("")}, nil
+					}
+					localFileSystem.MockStat = func(name string) (os.FileInfo, error) {
+						return nil, nil
+					}
+					localFileSystem.MockOpenFile = func(name string, flag int, perm os.FileMode) (fs.File, error) {
+						return new(mocks.File), nil
+					}
+					localFileSystem.MockRemove = func(name string) error {
+						return nil
+					}
+
+					service.API, err = local.NewClient(
+						localFileSystem, zap.NewNop().Sugar(), "flakes.yaml", "quarantines.yaml", "timings.yaml",
+						"history.yaml",
+					)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("returns an error", func() {
+					executionErr, ok := errors.AsExecutionError(err)
+					Expect(ok).To(BeTrue())
+					Expect(executionErr.Code).To(Equal(1))
+				})
+
+				It("logs the test files that got slower", func() {
+					logMessages := make([]string, 0)
+
+					for _, log := range recordedLogs.All() {
+						logMessages = append(logMessages, log.Message)
+					}
+
+					Expect(logMessages).To(ContainElement(
+						"\n1 test file took more than 50% longer than its stored timing:",
+					))
+					Expect(logMessages).To(ContainElement("- spec/slow_spec.rb (1s → 3s)"))
+				})
+
+				Context("with a higher threshold", func() {
+					BeforeEach(func() {
+						runConfig.DurationRegressionThreshold = 300
+					})
+
+					It("doesn't return an error", func() {
+						Expect(err).ToNot(HaveOccurred())
+					})
+				})
+			})
+
+			Context("without stored timings", func() {
+				It("doesn't return an error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("warns that there is nothing to compare against", func() {
+					logMessages := make([]string, 0)
+
+					for _, log := range recordedLogs.All() {
+						logMessages = append(logMessages, log.Message)
+					}
+
+					Expect(logMessages).To(ContainElement(ContainSubstring("Unable to check for duration regressions")))
+				})
+			})
+		})
+
 		Context("With uploading disabled and a remote client", func() {
 			var mockRoundTripper func(*http.Request) (*http.Response, error)
 
@@ -500,6 +592,39 @@ var _ = Describe("Run", func() {
 		})
 	})
 
+	Context("showing the progress", func() {
+		var commandStdout, commandStderr io.Writer
+
+		BeforeEach(func() {
+			mockCommand.MockWait = func() error { return nil }
+			service.TaskRunner.(*mocks.TaskRunner).MockNewCommand = func(
+				ctx context.Context,
+				cfg exec.CommandConfig,
+			) (exec.Command, error) {
+				commandStdout, commandStderr = cfg.Stdout, cfg.Stderr
+				return mockCommand, nil
+			}
+		})
+
+		It("lets the command write to the terminal directly", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commandStdout).To(BeIdenticalTo(os.Stdout))
+			Expect(commandStderr).To(BeIdenticalTo(os.Stderr))
+		})
+
+		Context("with --progress=always", func() {
+			BeforeEach(func() {
+				runConfig.Progress = cli.ProgressAlways
+			})
+
+			It("still lets the command write to the terminal directly", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commandStdout).To(BeIdenticalTo(os.Stdout))
+				Expect(commandStderr).To(BeIdenticalTo(os.Stderr))
+			})
+		})
+	})
+
 	Context("with an erroring command", func() {
 		var (
 			exitCode                       int
@@ -804,6 +929,62 @@ var _ = Describe("Run", func() {
 			})
 		})
 
+		Context("tests quarantined by pattern", func() {
+			var match string
+			var identityValues []string
+
+			BeforeEach(func() {
+				match = backend.MatchGlob
+				identityValues = []string{"*", "/other/**"}
+
+				service.API.(*mocks.API).MockGetRunConfiguration = func(
+					ctx context.Context,
+					testSuiteIdentifier string,
+				) (backend.RunConfiguration, error) {
+					return backend.RunConfiguration{
+						QuarantinedTests: []backend.QuarantinedTest{
+							{
+								Test: backend.Test{
+									CompositeIdentifier: strings.Join(identityValues, " -captain- "),
+									IdentityComponents:  []string{"description", "file"},
+									StrictIdentity:      true,
+									Match:               match,
+									IdentityValues:      identityValues,
+								},
+							},
+						},
+					}, nil
+				}
+			})
+
+			It("quarantines the tests matching the glob patterns", func() {
+				logMessages := make([]string, 0)
+
+				for _, log := range recordedLogs.All() {
+					logMessages = append(logMessages, log.Message)
+				}
+
+				Expect(logMessages).To(ContainElement(ContainSubstring("1 of 2 failures under quarantine")))
+			})
+
+			Context("using regular expressions", func() {
+				BeforeEach(func() {
+					match = backend.MatchRegex
+					identityValues = []string{"(first|second)-failed-.*", ".*/file\\.test"}
+				})
+
+				It("quarantines the tests matching the regular expressions", func() {
+					logMessages := make([]string, 0)
+
+					for _, log := range recordedLogs.All() {
+						logMessages = append(logMessages, log.Message)
+					}
+
+					Expect(logMessages).To(ContainElement(ContainSubstring("2 of 2 failures under quarantine")))
+				})
+			})
+		})
+
 		Context("all tests quarantined tests fail", func() {
 			BeforeEach(func() {
 				mockGetRunConfiguration := func(
@@ -973,6 +1154,179 @@ var _ = Describe("Run", func() {
 		})
 	})
 
+	Context("skipping quarantined tests", func() {
+		var (
+			commandArgs         []string
+			uploadedTestResults *v1.TestResults
+		)
+
+		BeforeEach(func() {
+			commandArgs = nil
+			uploadedTestResults = nil
+
+			mockCommand.MockWait = func() error {
+				commandFinished = true
+				return nil
+			}
+
+			service.ParseConfig.ProvidedFrameworkLanguage = "JavaScript"
+			service.ParseConfig.ProvidedFrameworkKind = "Jest"
+			service.ParseConfig.FrameworkParsers = map[v1.Framework][]parsing.Parser{
+				v1.JavaScriptJestFramework: service.ParseConfig.MutuallyExclusiveParsers,
+			}
+
+			service.TaskRunner.(*mocks.TaskRunner).MockNewCommand = func(
+				ctx context.Context,
+				cfg exec.CommandConfig,
+			) (exec.Command, error) {
+				commandArgs = append([]string{cfg.Name}, cfg.Args...)
+				return mockCommand, nil
+			}
+
+			service.ParseConfig.MutuallyExclusiveParsers[0].(*mocks.Parser).MockParse = func(r io.Reader) (
+				*v1.TestResults,
+				error,
+			) {
+				return &v1.TestResults{
+					Framework: v1.JavaScriptJestFramework,
+					Tests: []v1.Test{
+						{
+							Name:     "passing test",
+							Location: &v1.Location{File: "/path/to/file.test"},
+							Attempt:  v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+						},
+					},
+				}, nil
+			}
+
+			service.API.(*mocks.API).MockGetRunConfiguration = func(
+				ctx context.Context,
+				testSuiteIdentifier string,
+			) (backend.RunConfiguration, error) {
+				return backend.RunConfiguration{
+					QuarantinedTests: []backend.QuarantinedTest{
+						{
+							Test: backend.Test{
+								CompositeIdentifier: "quarantined test",
+								IdentityComponents:  []string{"description"},
+								StrictIdentity:      true,
+							},
+						},
+					},
+				}, nil
+			}
+
+			service.API.(*mocks.API).MockUpdateTestResults = func(
+				ctx context.Context,
+				testSuiteID string,
+				testResults v1.TestResults,
+			) ([]backend.TestResultsUploadResult, error) {
+				uploadedTestResults = &testResults
+				return []backend.TestResultsUploadResult{{OriginalPaths: []string{testResultsFilePath}, Uploaded: true}}, nil
+			}
+
+			runConfig.Command = "jest {{ quarantineExclusions }} --ci"
+			runConfig.QuarantineMode = cli.QuarantineModeSkip
+			runConfig.SubstitutionsByFramework = targetedretries.SubstitutionsByFramework
+		})
+
+		It("excludes the quarantined tests from the command", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commandArgs).To(Equal([]string{
+				"jest", "--testNamePattern", "^(?!(?:quarantined test)$)", "--ci",
+			}))
+		})
+
+		It("reports the excluded tests as quarantined and skipped", func() {
+			Expect(uploadedTestResults).NotTo(BeNil())
+			Expect(uploadedTestResults.Tests).To(HaveLen(2))
+
+			skippedTest := uploadedTestResults.Tests[1]
+			Expect(skippedTest.Name).To(Equal("quarantined test"))
+			Expect(skippedTest.Location).To(BeNil())
+			Expect(skippedTest.Attempt.Status.Kind).To(Equal(v1.TestStatusQuarantined))
+			Expect(skippedTest.Attempt.Status.OriginalStatus.Kind).To(Equal(v1.TestStatusSkipped))
+		})
+
+		Context("with quarantined tests that can't be selected exactly", func() {
+			BeforeEach(func() {
+				service.API.(*mocks.API).MockGetRunConfiguration = func(
+					ctx context.Context,
+					testSuiteIdentifier string,
+				) (backend.RunConfiguration, error) {
+					return backend.RunConfiguration{
+						QuarantinedTests: []backend.QuarantinedTest{
+							{
+								Test: backend.Test{
+									CompositeIdentifier: "quarantined test -captain- /path/to/file.test",
+									IdentityComponents:  []string{"description", "file"},
+									StrictIdentity:      true,
+								},
+							},
+							{
+								Test: backend.Test{
+									CompositeIdentifier: "quarantined *",
+									IdentityComponents:  []string{"description"},
+									StrictIdentity:      true,
+									Match:               backend.MatchGlob,
+								},
+							},
+						},
+					}, nil
+				}
+			})
+
+			It("runs the tests", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commandArgs).To(Equal([]string{"jest", "--ci"}))
+			})
+
+			It("doesn't report them as skipped", func() {
+				Expect(uploadedTestResults).NotTo(BeNil())
+				Expect(uploadedTestResults.Tests).To(HaveLen(1))
+			})
+
+			It("warns about them", func() {
+				logMessages := make([]string, 0)
+				for _, log := range recordedLogs.All() {
+					logMessages = append(logMessages, log.Message)
+				}
+
+				Expect(logMessages).To(ContainElement(ContainSubstring(
+					"Captain is unable to skip 2 of the quarantined tests of Jest (JavaScript) up front",
+				)))
+			})
+		})
+
+		Context("without quarantined tests", func() {
+			BeforeEach(func() {
+				service.API.(*mocks.API).MockGetRunConfiguration = func(
+					ctx context.Context,
+					testSuiteIdentifier string,
+				) (backend.RunConfiguration, error) {
+					return backend.RunConfiguration{}, nil
+				}
+			})
+
+			It("removes the keyword from the command", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commandArgs).To(Equal([]string{"jest", "--ci"}))
+			})
+		})
+
+		Context("without the quarantineExclusions keyword", func() {
+			BeforeEach(func() {
+				runConfig.Command = "jest --ci"
+			})
+
+			It("errs", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Missing quarantine exclusions"))
+				Expect(commandArgs).To(BeNil())
+			})
+		})
+	})
+
 	Context("with other errors", func() {
 		var (
 			exitCode                       int
@@ -1389,6 +1743,109 @@ var _ = Describe("Run", func() {
 					fmt.Sprintf("%s/retry-2/command-1/%s", runConfig.IntermediateArtifactsPath, testResultsFilePath)),
 				)
 			})
+
+			Context("when tests have attachments", func() {
+				var copiedAttachments []string
+
+				BeforeEach(func() {
+					copiedAttachments = make([]string, 0)
+
+					parse := service.ParseConfig.MutuallyExclusiveParsers[0].(*mocks.Parser).MockParse
+					service.ParseConfig.MutuallyExclusiveParsers[0].(*mocks.Parser).MockParse = func(r io.Reader) (
+						*v1.TestResults,
+						error,
+					) {
+						testResults, err := parse(r)
+						path := "/go/github.com/rwx-research/captain-cli/screenshots/first.png"
+						testResults.Tests[0].Attempt.Attachments = []v1.Attachment{
+							{Name: "first.png", ContentType: "image/png", Path: &path},
+						}
+						return testResults, err
+					}
+
+					open := service.FileSystem.(*mocks.FileSystem).MockOpen
+					service.FileSystem.(*mocks.FileSystem).MockOpen = func(name string) (fs.File, error) {
+						if strings.HasSuffix(name, "screenshots/first.png") {
+							file := new(mocks.File)
+							file.Reader = strings.NewReader("some screenshot")
+							return file, nil
+						}
+						return open(name)
+					}
+
+					service.FileSystem.(*mocks.FileSystem).MockCreate = func(name string) (fs.File, error) {
+						file := new(mocks.File)
+						file.Builder = new(strings.Builder)
+						copiedAttachments = append(copiedAttachments, name)
+						return file, nil
+					}
+				})
+
+				It("copies the attachments of every attempt", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					for _, attempt := range []string{"original-attempt", "retry-1/command-1", "retry-2/command-1"} {
+						Expect(copiedAttachments).To(ContainElement(fmt.Sprintf(
+							"%s/%s/attachments/screenshots/first.png",
+							runConfig.IntermediateArtifactsPath,
+							attempt,
+						)))
+					}
+				})
+
+				It("points the attachments to their copies", func() {
+					Expect(err).ToNot(HaveOccurred())
+
+					test := uploadedTestResults.Tests[0]
+					Expect(*test.Attempt.Attachments[0].Path).To(Equal(fmt.Sprintf(
+						"%s/retry-2/command-1/attachments/screenshots/first.png",
+						runConfig.IntermediateArtifactsPath,
+					)))
+					Expect(*test.PastAttempts[0].Attachments[0].Path).To(Equal(fmt.Sprintf(
+						"%s/original-attempt/attachments/screenshots/first.png",
+						runConfig.IntermediateArtifactsPath,
+					)))
+				})
+			})
+		})
+
+		Context("when tests have attachments but no intermediate artifacts path is defined", func() {
+			var copiedAttachments []string
+
+			BeforeEach(func() {
+				runConfig.Retries = 2
+				copiedAttachments = make([]string, 0)
+
+				parse := service.ParseConfig.MutuallyExclusiveParsers[0].(*mocks.Parser).MockParse
+				service.ParseConfig.MutuallyExclusiveParsers[0].(*mocks.Parser).MockParse = func(r io.Reader) (
+					*v1.TestResults,
+					error,
+				) {
+					testResults, err := parse(r)
+					path := "/go/github.com/rwx-research/captain-cli/screenshots/first.png"
+					testResults.Tests[0].Attempt.Attachments = []v1.Attachment{
+						{Name: "first.png", ContentType: "image/png", Path: &path},
+					}
+					return testResults, err
+				}
+
+				service.FileSystem.(*mocks.FileSystem).MockCreate = func(name string) (fs.File, error) {
+					file := new(mocks.File)
+					file.Builder = new(strings.Builder)
+					copiedAttachments = append(copiedAttachments, name)
+					return file, nil
+				}
+			})
+
+			It("doesn't copy the attachments", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(copiedAttachments).To(BeEmpty())
+
+				test := uploadedTestResults.Tests[0]
+				Expect(*test.Attempt.Attachments[0].Path).To(Equal(
+					"/go/github.com/rwx-research/captain-cli/screenshots/first.png",
+				))
+			})
 		})
 
 		Context("when there are failures left after all retries", func() {
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/exec"
+)
+
+// SelectionConfig configures the change-aware selection of test files ahead of partitioning.
+type SelectionConfig struct {
+	// BaseRef is the git ref to compare against, e.g. "origin/main". Selection is disabled if this is empty.
+	BaseRef string
+	// AlwaysRun holds globs of test files that are selected regardless of which files changed.
+	AlwaysRun []string
+	// Rules map changed files to the test files that cover them.
+	Rules []SelectionRule
+}
+
+// SelectionRule maps changed files to test files. `Changed` is a regular expression that needs to match the full
+// path of a changed file. `Tests` are globs that can reference capture groups of `Changed`, e.g. `$1` or `${name}`.
+type SelectionRule struct {
+	Changed string
+	Tests   []string
+}
+
+func (sc SelectionConfig) Enabled() bool {
+	return sc.BaseRef != ""
+}
+
+const (
+	selectionStrategyChanged   = "changed"
+	selectionStrategyFullSuite = "full-suite"
+)
+
+// SelectionResult describes which test files were selected and why.
+type SelectionResult struct {
+	BaseRef       string
+	Strategy      string
+	TestFilePaths []string
+}
+
+// JobTags returns the job tags that record the selection for traceability. The selected test files are identified by
+// their digest, as the list itself can get arbitrarily long.
+func (sr SelectionResult) JobTags() map[string]any {
+	return map[string]any{
+		"captain_selection_base_ref":         sr.BaseRef,
+		"captain_selection_strategy":         sr.Strategy,
+		"captain_selected_test_files_count":  len(sr.TestFilePaths),
+		"captain_selected_test_files_digest": sr.Digest(),
+	}
+}
+
+// Digest returns the SHA-256 digest of the selected test files, regardless of their order.
+func (sr SelectionResult) Digest() string {
+	testFilePaths := append([]string(nil), sr.TestFilePaths...)
+	sort.Strings(testFilePaths)
+
+	digest := sha256.Sum256([]byte(strings.Join(testFilePaths, "\n")))
+	return hex.EncodeToString(digest[:])
+}
+
+type compiledSelectionRule struct {
+	changed *regexp.Regexp
+	tests   []string
+}
+
+func compileSelectionRules(rules []SelectionRule) ([]compiledSelectionRule, error) {
+	compiledRules := make([]compiledSelectionRule, 0, len(rules))
+
+	for _, rule := range rules {
+		changed, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", rule.Changed))
+		if err != nil {
+			return nil, errors.NewConfigurationError(
+				"Invalid test selection rule",
+				fmt.Sprintf("%q is not a valid regular expression: %s", rule.Changed, err),
+				"Please make sure that the 'changed' pattern of each selection rule is a valid regular expression.",
+			)
+		}
+
+		compiledRules = append(compiledRules, compiledSelectionRule{changed: changed, tests: rule.Tests})
+	}
+
+	return compiledRules, nil
+}
+
+// selectTestFiles narrows down the test files to the ones affected by the changes since `cfg.BaseRef`. It falls back
+// to the full suite whenever a changed file can't be mapped to test files, or the changes can't be determined at all.
+func (s Service) selectTestFiles(
+	ctx context.Context,
+	cfg SelectionConfig,
+	testFilePaths []string,
+) (SelectionResult, error) {
+	fullSuite := SelectionResult{
+		BaseRef:       cfg.BaseRef,
+		Strategy:      selectionStrategyFullSuite,
+		TestFilePaths: testFilePaths,
+	}
+
+	rules, err := compileSelectionRules(cfg.Rules)
+	if err != nil {
+		return SelectionResult{}, err
+	}
+
+	changedFiles, err := s.changedFiles(ctx, cfg.BaseRef)
+	if err != nil {
+		s.Log.Warnf("Unable to determine the files changed since %q, running the full suite: %s", cfg.BaseRef, err)
+		return fullSuite, nil
+	}
+
+	candidates := make(map[string]string, len(testFilePaths))
+	for _, testFilePath := range testFilePaths {
+		candidates[filepath.Clean(testFilePath)] = testFilePath
+	}
+
+	selected := make(map[string]struct{})
+	selectGlobs := func(globs []string) error {
+		if len(globs) == 0 {
+			return nil
+		}
+
+		expandedPaths, err := s.FileSystem.GlobMany(globs)
+		if err != nil {
+			return errors.NewSystemError("unable to expand filepath glob: %s", err)
+		}
+
+		for _, expandedPath := range expandedPaths {
+			if testFilePath, ok := candidates[filepath.Clean(expandedPath)]; ok {
+				selected[testFilePath] = struct{}{}
+			}
+		}
+
+		return nil
+	}
+
+	if err := selectGlobs(cfg.AlwaysRun); err != nil {
+		return SelectionResult{}, err
+	}
+
+	for _, changedFile := range changedFiles {
+		if testFilePath, ok := candidates[changedFile]; ok {
+			selected[testFilePath] = struct{}{}
+			continue
+		}
+
+		mapped := false
+		for _, rule := range rules {
+			submatches := rule.changed.FindStringSubmatchIndex(changedFile)
+			if submatches == nil {
+				continue
+			}
+
+			mapped = true
+			globs := make([]string, 0, len(rule.tests))
+			for _, test := range rule.tests {
+				globs = append(globs, string(rule.changed.ExpandString(nil, test, changedFile, submatches)))
+			}
+
+			if err := selectGlobs(globs); err != nil {
+				return SelectionResult{}, err
+			}
+		}
+
+		if !mapped {
+			s.Log.Infof("%s changed but does not map to any test files, running the full suite", changedFile)
+			return fullSuite, nil
+		}
+	}
+
+	selectedPaths := make([]string, 0, len(selected))
+	for testFilePath := range selected {
+		selectedPaths = append(selectedPaths, testFilePath)
+	}
+	sort.Strings(selectedPaths)
+
+	s.Log.Infof(
+		"Selected %d of %d test %s based on %d changed %s since %q",
+		len(selectedPaths), len(testFilePaths), pluralize(len(testFilePaths), "file", "files"),
+		len(changedFiles), pluralize(len(changedFiles), "file", "files"), cfg.BaseRef,
+	)
+
+	return SelectionResult{
+		BaseRef:       cfg.BaseRef,
+		Strategy:      selectionStrategyChanged,
+		TestFilePaths: selectedPaths,
+	}, nil
+}
+
+// changedFiles returns the files that changed between the merge-base of `baseRef` & `HEAD` and the working tree.
+// The paths are relative to the current working directory, i.e. the same as the paths of the test files.
+func (s Service) changedFiles(ctx context.Context, baseRef string) ([]string, error) {
+	repositoryRoot, err := s.git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	repositoryRoot = strings.TrimSpace(repositoryRoot)
+
+	mergeBase, err := s.git(ctx, "merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := s.git(ctx, "diff", "--name-only", strings.TrimSpace(mergeBase))
+	if err != nil {
+		return nil, err
+	}
+
+	workingDirectory, err := s.FileSystem.Getwd()
+	if err != nil {
+		return nil, errors.NewSystemError("unable to determine the current working directory: %s", err)
+	}
+
+	changedFiles := make([]string, 0)
+	for _, line := range strings.Split(diff, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		changedFile, err := filepath.Rel(workingDirectory, filepath.Join(repositoryRoot, filepath.FromSlash(line)))
+		if err != nil {
+			return nil, errors.NewSystemError("unable to determine the relative path of %q: %s", line, err)
+		}
+
+		changedFiles = append(changedFiles, changedFile)
+	}
+
+	return changedFiles, nil
+}
+
+// git runs the local `git` binary and returns its output.
+func (s Service) git(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr strings.Builder
+
+	cmd, err := s.TaskRunner.NewCommand(ctx, exec.CommandConfig{
+		Name:   "git",
+		Args:   args,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return "", errors.NewSystemError("unable to spawn git: %s", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", errors.NewSystemError("unable to execute git: %s", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return "", errors.NewSystemError(
+			"'git %s' failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return stdout.String(), nil
+}
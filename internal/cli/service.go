@@ -6,6 +6,7 @@ package cli
 
 import (
 	"context"
+	"io"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -25,6 +26,16 @@ type Service struct {
 	ParseConfig parsing.Config
 }
 
+// Close releases the resources held by the backend of the service, e.g. the database of the local storage. It needs to
+// be called once the command has finished.
+func (s Service) Close() error {
+	if closer, ok := s.API.(io.Closer); ok {
+		return errors.WithStack(closer.Close())
+	}
+
+	return nil
+}
+
 type contextKey string
 
 var configKey = contextKey("captainService")
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+// ImportStorage is the implementation of `captain storage import`. It copies the flakes, quarantines, timings, and
+// history of the YAML files of a suite into its SQLite database.
+func (s Service) ImportStorage(_ context.Context, yamlStorage local.Client) error {
+	database, err := s.databaseStorage("captain storage import")
+	if err != nil {
+		return err
+	}
+
+	summary, err := copyStorage(yamlStorage, database)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infof("Imported %s into the database", summary)
+	return nil
+}
+
+// ExportStorage is the implementation of `captain storage export`. It writes the flakes, quarantines, timings, and
+// history stored in the SQLite database of a suite to its YAML files, e.g. in order to commit them.
+func (s Service) ExportStorage(_ context.Context, yamlStorage local.Client) error {
+	database, err := s.databaseStorage("captain storage export")
+	if err != nil {
+		return err
+	}
+
+	summary, err := copyStorage(database, yamlStorage)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infof("Exported %s from the database", summary)
+	return nil
+}
+
+func (s Service) databaseStorage(command string) (local.Client, error) {
+	localStorage, err := s.localStorage(command)
+	if err != nil {
+		return local.Client{}, err
+	}
+
+	if !localStorage.IsDatabase() {
+		return local.Client{}, errors.NewConfigurationError(
+			fmt.Sprintf("'%s' requires SQLite storage", command),
+			"You are trying to copy data between the YAML files and the SQLite database of Captain, however the "+
+				"SQLite storage is not enabled.",
+			"Please set 'storage.backend' to 'sqlite' in the config file.",
+		)
+	}
+
+	return localStorage, nil
+}
+
+// copyStorage replaces the flakes & quarantines of `target` with the ones of `source` and merges the timings. Runs of
+// the history are only copied if they are more recent than the latest run of `target`, which means that copying the
+// same history twice doesn't duplicate it. The stored results of the most recent run of each partition are replaced as
+// well.
+func copyStorage(source, target local.Client) (string, error) {
+	target.Flakes = source.Flakes
+	target.Quarantines = source.Quarantines
+	if err := target.Flush(); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := target.MergeTimings(source.Timings); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	sourceHistory, err := source.History()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	targetHistory, err := target.History()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	copiedRuns := 0
+	for _, entry := range sourceHistory {
+		if len(targetHistory) > 0 && !entry.RecordedAt.After(targetHistory[len(targetHistory)-1].RecordedAt) {
+			continue
+		}
+
+		if err := target.RecordHistory(entry); err != nil {
+			return "", errors.WithStack(err)
+		}
+		copiedRuns++
+	}
+
+	allStoredResults, err := source.AllStoredResults()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	for partition, storedResults := range allStoredResults {
+		if err := target.WithPartition(partition).StoreResults(storedResults, time.Now()); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	return fmt.Sprintf(
+		"%d %s, %d %s, %d test file %s, and %d %s",
+		len(source.Flakes), pluralize(len(source.Flakes), "flake", "flakes"),
+		len(source.Quarantines), pluralize(len(source.Quarantines), "quarantine", "quarantines"),
+		len(source.Timings), pluralize(len(source.Timings), "timing", "timings"),
+		copiedRuns, pluralize(copiedRuns, "run", "runs"),
+	), nil
+}
@@ -0,0 +1,158 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/fs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Storage", func() {
+	var (
+		err          error
+		service      cli.Service
+		recordedLogs *observer.ObservedLogs
+		yamlStorage  local.Client
+		database     local.Client
+		directory    string
+	)
+
+	newYAMLStorage := func() local.Client {
+		client, err := local.NewClient(
+			fs.Local{}, zap.NewNop().Sugar(),
+			filepath.Join(directory, "flakes.yaml"),
+			filepath.Join(directory, "quarantines.yaml"),
+			filepath.Join(directory, "timings.yaml"),
+			filepath.Join(directory, "history.yaml"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	BeforeEach(func() {
+		directory = GinkgoT().TempDir()
+
+		Expect(os.WriteFile(
+			filepath.Join(directory, "flakes.yaml"),
+			[]byte("- description: flaky test\n  file: ./flaky_spec.rb\n"),
+			0o600,
+		)).To(Succeed())
+		Expect(os.WriteFile(
+			filepath.Join(directory, "timings.yaml"),
+			[]byte("./flaky_spec.rb: 2s\n"),
+			0o600,
+		)).To(Succeed())
+		Expect(os.WriteFile(
+			filepath.Join(directory, "history.yaml"),
+			[]byte("- recorded_at: 2023-05-01T12:00:00Z\n  flaky_tests:\n    - description: flaky test\n"),
+			0o600,
+		)).To(Succeed())
+		yamlStorage = newYAMLStorage()
+
+		database, err = local.NewSQLiteClient(zap.NewNop().Sugar(), filepath.Join(directory, "captain.db"), "suite")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(database.Close)
+
+		var core zapcore.Core
+		core, recordedLogs = observer.New(zapcore.InfoLevel)
+		service = cli.Service{
+			API: database,
+			Log: zaptest.NewLogger(GinkgoT(), zaptest.WrapOptions(
+				zap.WrapCore(func(original zapcore.Core) zapcore.Core { return core }),
+			)).Sugar(),
+			FileSystem: fs.Local{},
+		}
+	})
+
+	Describe("ImportStorage", func() {
+		JustBeforeEach(func() {
+			err = service.ImportStorage(context.Background(), yamlStorage)
+		})
+
+		It("copies the YAML files into the database", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			reopened, err := local.NewSQLiteClient(
+				zap.NewNop().Sugar(), filepath.Join(directory, "captain.db"), "suite",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			defer reopened.Close()
+
+			Expect(reopened.Flakes).To(HaveLen(1))
+			Expect(reopened.Timings).To(Equal(map[string]time.Duration{"./flaky_spec.rb": 2 * time.Second}))
+
+			history, err := reopened.History()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+
+			Expect(recordedLogs.All()[0].Message).To(Equal(
+				"Imported 1 flake, 0 quarantines, 1 test file timing, and 1 run into the database",
+			))
+		})
+
+		It("doesn't duplicate the history when importing twice", func() {
+			Expect(service.ImportStorage(context.Background(), yamlStorage)).To(Succeed())
+
+			history, err := database.History()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+		})
+
+		Context("without SQLite storage", func() {
+			BeforeEach(func() {
+				service.API = yamlStorage
+			})
+
+			It("errs", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("requires SQLite storage"))
+			})
+		})
+	})
+
+	Describe("ExportStorage", func() {
+		BeforeEach(func() {
+			database.Quarantines = append(database.Quarantines, local.Map{
+				Order:  []string{"description"},
+				Values: map[string]string{"description": "quarantined test"},
+			}.ToYAML())
+			Expect(database.Flush()).To(Succeed())
+			service.API = database
+		})
+
+		JustBeforeEach(func() {
+			err = service.ExportStorage(context.Background(), yamlStorage)
+		})
+
+		It("writes the database to the YAML files", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			exported := newYAMLStorage()
+			Expect(exported.Flakes).To(BeEmpty())
+			Expect(exported.Quarantines).To(HaveLen(1))
+			Expect(exported.Timings).To(HaveKey("./flaky_spec.rb"))
+		})
+	})
+
+	Describe("Close", func() {
+		It("closes the database of the local storage", func() {
+			Expect(service.Close()).To(Succeed())
+
+			_, err := database.History()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("database is closed"))
+		})
+	})
+})
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
+
+// SuggestConfig holds the configuration for `captain suggest`
+type SuggestConfig struct {
+	// MinFlakes is the number of times a test needs to have flaked in order to be suggested
+	MinFlakes int
+	// Uploads is the number of most recent uploads of test results that are taken into account. Partitioned runs
+	// upload the results of every partition separately.
+	Uploads int
+	// Apply writes the suggested entries to the local storage instead of only printing them
+	Apply bool
+}
+
+func (sc SuggestConfig) Validate() error {
+	if sc.MinFlakes < 1 {
+		return errors.NewConfigurationError(
+			"Invalid --min-flakes",
+			"A test needs to have flaked at least once in order to be suggested.",
+			"Please set --min-flakes to a positive number.",
+		)
+	}
+
+	if sc.Uploads < 1 {
+		return errors.NewConfigurationError(
+			"Invalid --uploads",
+			"Suggestions are based on at least one recorded upload of test results.",
+			"Please set --uploads to a positive number.",
+		)
+	}
+
+	return nil
+}
+
+type suggestion struct {
+	identity local.Map
+	flakes   int
+}
+
+// SuggestFlakes is the implementation of `captain suggest flakes`.
+func (s Service) SuggestFlakes(_ context.Context, cfg SuggestConfig) error {
+	localStorage, err := s.localStorage("captain suggest flakes")
+	if err != nil {
+		return err
+	}
+
+	suggestions, uploads, err := s.suggest(localStorage, localStorage.Flakes, cfg)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]yaml.Node, len(suggestions))
+	for i, suggestion := range suggestions {
+		entries[i] = suggestion.identity.Merge(suggestion.metadata(uploads, time.Time{})).ToYAML()
+	}
+
+	if !cfg.Apply {
+		return s.printSuggestions("flakes", entries, cfg, uploads)
+	}
+
+	if err := localStorage.AddFlakes(entries...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infof("Added %d flaky %s", len(entries), pluralize(len(entries), "test", "tests"))
+	return nil
+}
+
+// SuggestQuarantines is the implementation of `captain suggest quarantines`.
+func (s Service) SuggestQuarantines(_ context.Context, cfg SuggestConfig) error {
+	localStorage, err := s.localStorage("captain suggest quarantines")
+	if err != nil {
+		return err
+	}
+
+	suggestions, uploads, err := s.suggest(localStorage, localStorage.Quarantines, cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	entries := make([]yaml.Node, len(suggestions))
+	for i, suggestion := range suggestions {
+		entries[i] = suggestion.identity.Merge(suggestion.metadata(uploads, now)).ToYAML()
+	}
+
+	if !cfg.Apply {
+		return s.printSuggestions("quarantines", entries, cfg, uploads)
+	}
+
+	if err := localStorage.AddQuarantines(entries...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infof("Quarantined %d %s", len(entries), pluralize(len(entries), "test", "tests"))
+	return nil
+}
+
+// suggest returns all tests that flaked at least `cfg.MinFlakes` times in the last `cfg.Uploads` recorded uploads and
+// that aren't part of `existing` yet, most flaky tests first. It also returns the number of uploads that were
+// considered.
+func (s Service) suggest(
+	localStorage local.Client,
+	existing []yaml.Node,
+	cfg SuggestConfig,
+) ([]suggestion, int, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	history, err := localStorage.History()
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	if len(history) > cfg.Uploads {
+		history = history[len(history)-cfg.Uploads:]
+	}
+
+	suggestions := make([]suggestion, 0)
+	for _, upload := range history {
+	flakyTests:
+		for _, node := range upload.FlakyTests {
+			identity := local.NewMapFromYAML(node)
+
+			for i := range suggestions {
+				if suggestions[i].identity.Equals(identity) {
+					suggestions[i].flakes++
+					continue flakyTests
+				}
+			}
+
+			suggestions = append(suggestions, suggestion{identity: identity, flakes: 1})
+		}
+	}
+
+	filtered := make([]suggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		if suggestion.flakes < cfg.MinFlakes || containsEntry(existing, suggestion.identity) {
+			continue
+		}
+
+		filtered = append(filtered, suggestion)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].flakes > filtered[j].flakes
+	})
+
+	return filtered, len(history), nil
+}
+
+func (sg suggestion) metadata(uploads int, quarantinedAt time.Time) local.Map {
+	reason := fmt.Sprintf(
+		"Flaked %d %s in the last %d %s",
+		sg.flakes, pluralize(sg.flakes, "time", "times"), uploads, pluralize(uploads, "upload", "uploads"),
+	)
+	metadata := local.Map{
+		Order:  []string{"reason"},
+		Values: map[string]string{"reason": reason},
+	}
+
+	if !quarantinedAt.IsZero() {
+		metadata.Order = append(metadata.Order, "quarantined_at")
+		metadata.Values["quarantined_at"] = quarantinedAt.UTC().Format(time.RFC3339)
+	}
+
+	return metadata
+}
+
+func (s Service) printSuggestions(kind string, entries []yaml.Node, cfg SuggestConfig, uploads int) error {
+	threshold := fmt.Sprintf(
+		"at least %d %s in the last %d recorded %s",
+		cfg.MinFlakes, pluralize(cfg.MinFlakes, "time", "times"), uploads, pluralize(uploads, "upload", "uploads"),
+	)
+
+	if len(entries) == 0 {
+		s.Log.Infof("No tests flaked %s", threshold)
+		return nil
+	}
+
+	output, err := yaml.Marshal(entries)
+	if err != nil {
+		return errors.NewInternalError("Unable to output suggestions: %s", err)
+	}
+
+	s.Log.Infof(
+		"The following tests flaked %s. Re-run with --apply to add them to your %s:\n\n%s",
+		threshold, kind, strings.TrimSuffix(string(output), "\n"),
+	)
+	return nil
+}
+
+func containsEntry(entries []yaml.Node, identity local.Map) bool {
+	for _, entry := range entries {
+		// Metadata like `strict` doesn't matter here, any entry for the same test is sufficient
+		if entryIdentity, _ := local.NewMapFromYAML(entry).Split(); identity.Equals(entryIdentity) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,146 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/cli"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	"github.com/rwx-research/captain-cli/internal/mocks"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Suggesting", func() {
+	const (
+		flakesPath      = "flakes"
+		quarantinesPath = "quarantines"
+		timingsPath     = "timings"
+		historyPath     = "history"
+	)
+
+	var (
+		ctx          context.Context
+		mockedFS     *mocks.FileSystem
+		service      cli.Service
+		recordedLogs *observer.ObservedLogs
+		cfg          cli.SuggestConfig
+
+		flakes, quarantines *mocks.File
+		storedQuarantines   string
+		history             string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cfg = cli.SuggestConfig{MinFlakes: 2, Uploads: 3}
+
+		flakes = &mocks.File{Builder: new(strings.Builder), Reader: strings.NewReader("")}
+		quarantines = &mocks.File{Builder: new(strings.Builder)}
+		storedQuarantines = "- description: quarantined\n  file: a_spec.rb\n  strict: true\n"
+		history = "- recorded_at: 2023-05-01T10:00:00Z\n" +
+			"  flaky_tests:\n    - {description: old, file: a_spec.rb}\n" +
+			"- recorded_at: 2023-05-02T10:00:00Z\n" +
+			"  flaky_tests:\n    - {description: old, file: a_spec.rb}\n    - {description: quarantined, file: a_spec.rb}\n" +
+			"- recorded_at: 2023-05-03T10:00:00Z\n" +
+			"  flaky_tests:\n    - {description: new, file: b_spec.rb}\n    - {description: quarantined, file: a_spec.rb}\n" +
+			"- recorded_at: 2023-05-04T10:00:00Z\n" +
+			"  flaky_tests:\n    - {description: new, file: b_spec.rb}\n"
+
+		mockedFS = new(mocks.FileSystem)
+		mockedFS.MockOpen = func(name string) (fs.File, error) {
+			switch name {
+			case flakesPath:
+				return flakes, nil
+			case quarantinesPath:
+				quarantines.Reader = strings.NewReader(storedQuarantines)
+				return quarantines, nil
+			case historyPath:
+				return &mocks.File{Reader: strings.NewReader(history)}, nil
+			default:
+				return &mocks.File{Reader: strings.NewReader("")}, nil
+			}
+		}
+		mockedFS.MockOpenFile = func(name string, flag int, perm os.FileMode) (fs.File, error) {
+			if strings.HasSuffix(name, ".lock") {
+				return new(mocks.File), nil
+			}
+
+			return mockedFS.MockOpen(name)
+		}
+		mockedFS.MockRemove = func(name string) error {
+			return nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		var core zapcore.Core
+		core, recordedLogs = observer.New(zapcore.InfoLevel)
+		log := zaptest.NewLogger(GinkgoT(), zaptest.WrapOptions(
+			zap.WrapCore(func(original zapcore.Core) zapcore.Core { return core }),
+		)).Sugar()
+
+		api, err := local.NewClient(mockedFS, log, flakesPath, quarantinesPath, timingsPath, historyPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		service = cli.Service{API: api, FileSystem: mockedFS, Log: log}
+	})
+
+	It("only suggests tests that flaked often enough in the most recent uploads", func() {
+		Expect(service.SuggestFlakes(ctx, cfg)).To(Succeed())
+
+		logs := recordedLogs.TakeAll()
+		Expect(logs).To(HaveLen(1))
+		Expect(logs[0].Message).To(HaveSuffix(
+			"- description: quarantined\n  file: a_spec.rb\n  reason: Flaked 2 times in the last 3 uploads\n" +
+				"- description: new\n  file: b_spec.rb\n  reason: Flaked 2 times in the last 3 uploads",
+		))
+		Expect(flakes.Builder.String()).To(BeEmpty())
+	})
+
+	It("doesn't suggest tests that are already quarantined", func() {
+		cfg.Apply = true
+		Expect(service.SuggestQuarantines(ctx, cfg)).To(Succeed())
+
+		Expect(quarantines.Builder.String()).To(HavePrefix(
+			"- description: quarantined\n  file: a_spec.rb\n  strict: true\n" +
+				"- description: new\n  file: b_spec.rb\n  reason: Flaked 2 times in the last 3 uploads\n",
+		))
+		Expect(quarantines.Builder.String()).To(MatchRegexp(`quarantined_at: \d{4}-\d{2}-\d{2}T`))
+	})
+
+	It("reports when there is nothing to suggest", func() {
+		cfg.MinFlakes = 3
+		Expect(service.SuggestFlakes(ctx, cfg)).To(Succeed())
+
+		logs := recordedLogs.TakeAll()
+		Expect(logs).To(HaveLen(1))
+		Expect(logs[0].Message).To(Equal("No tests flaked at least 3 times in the last 3 recorded uploads"))
+	})
+
+	It("uses the singular for single flakes & uploads", func() {
+		cfg.MinFlakes = 1
+		cfg.Uploads = 1
+		Expect(service.SuggestFlakes(ctx, cfg)).To(Succeed())
+
+		logs := recordedLogs.TakeAll()
+		Expect(logs).To(HaveLen(1))
+		Expect(logs[0].Message).To(HavePrefix("The following tests flaked at least 1 time in the last 1 recorded upload."))
+		Expect(logs[0].Message).To(HaveSuffix("reason: Flaked 1 time in the last 1 upload"))
+	})
+
+	It("rejects invalid thresholds", func() {
+		cfg.Uploads = 0
+		err := service.SuggestFlakes(ctx, cfg)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid --uploads"))
+	})
+})
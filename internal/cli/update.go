@@ -3,9 +3,11 @@ package cli
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/rwx-research/captain-cli/internal/backend"
 	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/backend/remote"
 	"github.com/rwx-research/captain-cli/internal/errors"
 )
 
@@ -35,6 +37,46 @@ func parseFlags(args []string) local.Map {
 	return local.Map{Order: order, Values: values}
 }
 
+// newLocalEntry assembles a flake or quarantine entry from the given flags. The identity of the test is followed by
+// any metadata (e.g. `--owner` or `--expires-at`), which is validated and normalized. The identity values of
+// `--match glob` or `--match regex` entries need to be valid patterns. Unless specified otherwise, `quarantined_at` is
+// set to `quarantinedAt` if that is non-zero.
+func newLocalEntry(args []string, quarantinedAt time.Time) (local.Map, error) {
+	identity, metadata := parseFlags(args).Split()
+
+	for _, key := range []string{"quarantined_at", "expires_at"} {
+		if value, ok := metadata.Values[key]; ok {
+			timestamp, err := local.ParseTimestamp(value)
+			if err != nil {
+				return local.Map{}, errors.NewInputError("Invalid --%s: %s", strings.ReplaceAll(key, "_", "-"), err)
+			}
+
+			metadata.Values[key] = timestamp.Format(time.RFC3339)
+		}
+	}
+
+	if match, ok := metadata.Values["match"]; ok {
+		if err := backend.ValidateMatch(match); err != nil {
+			return local.Map{}, errors.NewInputError("Invalid --match: %s", err)
+		}
+
+		if backend.IsPattern(match) {
+			for _, component := range identity.Order {
+				if _, err := backend.CompilePattern(match, identity.Values[component]); err != nil {
+					return local.Map{}, errors.NewInputError("Invalid --%s: %s", component, err)
+				}
+			}
+		}
+	}
+
+	if _, ok := metadata.Values["quarantined_at"]; !ok && !quarantinedAt.IsZero() {
+		metadata.Order = append(metadata.Order, "quarantined_at")
+		metadata.Values["quarantined_at"] = quarantinedAt.UTC().Format(time.RFC3339)
+	}
+
+	return identity.Merge(metadata), nil
+}
+
 func (s Service) AddFlake(_ context.Context, args []string) error {
 	localStorage, ok := s.API.(local.Client)
 	if !ok {
@@ -46,9 +88,12 @@ func (s Service) AddFlake(_ context.Context, args []string) error {
 		)
 	}
 
-	localStorage.Flakes = append(localStorage.Flakes, parseFlags(args).ToYAML())
+	entry, err := newLocalEntry(args, time.Time{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
-	return errors.WithStack(localStorage.Flush())
+	return errors.WithStack(localStorage.AddFlakes(entry.ToYAML()))
 }
 
 func (s Service) AddQuarantine(_ context.Context, args []string) error {
@@ -62,9 +107,12 @@ func (s Service) AddQuarantine(_ context.Context, args []string) error {
 		)
 	}
 
-	localStorage.Quarantines = append(localStorage.Quarantines, parseFlags(args).ToYAML())
+	entry, err := newLocalEntry(args, time.Now())
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
-	return errors.WithStack(localStorage.Flush())
+	return errors.WithStack(localStorage.AddQuarantines(entry.ToYAML()))
 }
 
 func (s Service) RemoveFlake(_ context.Context, args []string) error {
@@ -78,14 +126,7 @@ func (s Service) RemoveFlake(_ context.Context, args []string) error {
 		)
 	}
 
-	identity := parseFlags(args)
-	for i := len(localStorage.Flakes) - 1; i >= 0; i-- {
-		if identity.Equals(local.NewMapFromYAML(localStorage.Flakes[i])) {
-			localStorage.Flakes = append(localStorage.Flakes[:i], localStorage.Flakes[i+1:]...)
-		}
-	}
-
-	return errors.WithStack(localStorage.Flush())
+	return errors.WithStack(localStorage.RemoveFlakes(parseFlags(args)))
 }
 
 func (s Service) RemoveQuarantine(_ context.Context, args []string) error {
@@ -99,14 +140,7 @@ func (s Service) RemoveQuarantine(_ context.Context, args []string) error {
 		)
 	}
 
-	identity := parseFlags(args)
-	for i := len(localStorage.Quarantines) - 1; i >= 0; i-- {
-		if identity.Equals(local.NewMapFromYAML(localStorage.Quarantines[i])) {
-			localStorage.Quarantines = append(localStorage.Quarantines[:i], localStorage.Quarantines[i+1:]...)
-		}
-	}
-
-	return errors.WithStack(localStorage.Flush())
+	return errors.WithStack(localStorage.RemoveQuarantines(parseFlags(args)))
 }
 
 // UploadTestResults is the implementation of `captain upload results`.
@@ -169,3 +203,94 @@ func (s Service) UpdateTestResults(
 
 	return result, nil
 }
+
+// ReplaySpool is the implementation of `captain update results --replay-spool`. It re-sends the test results of a
+// suite that couldn't be uploaded previously, using the provider metadata of the run that produced them.
+func (s Service) ReplaySpool(ctx context.Context, testSuiteID string) error {
+	remoteClient, ok := s.API.(remote.Client)
+	if !ok {
+		return errors.NewConfigurationError(
+			"Unable to replay spooled uploads",
+			"Spooled test results are sent to the Captain API, however Captain is running in OSS mode.",
+			"Please make sure that the RWX_ACCESS_TOKEN environment variable is set.",
+		)
+	}
+
+	result, err := remoteClient.ReplaySpool(ctx, testSuiteID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infof("Replayed %d spooled %s", result.Replayed, pluralize(result.Replayed, "upload", "uploads"))
+
+	if result.Remaining > 0 {
+		return errors.NewSystemError(
+			"%d spooled %s could not be replayed and will be kept for the next attempt",
+			result.Remaining, pluralize(result.Remaining, "upload", "uploads"),
+		)
+	}
+
+	return nil
+}
+
+// MergeTimings is the implementation of `captain merge timings`. It combines timings files written by different
+// partitions into the timings file of the test suite.
+// Every partition starts out with the same stored timings, but only the timings of its own test files are fresh. Any
+// timing that differs from the currently stored one is therefore considered an update. If more than one file updated
+// the timing of the same test file, the one listed last takes precedence - this keeps the result deterministic.
+func (s Service) MergeTimings(_ context.Context, filepaths []string) error {
+	localStorage, ok := s.API.(local.Client)
+	if !ok {
+		return errors.NewConfigurationError(
+			"'captain merge timings' only works in OSS mode",
+			"You are trying to merge test file timings, however it appears that you are using Captain Cloud.",
+			"Captain Cloud keeps track of your test file timings automatically. There is no need to merge them.",
+		)
+	}
+
+	expandedFilepaths, err := s.FileSystem.GlobMany(filepaths)
+	if err != nil {
+		return errors.NewSystemError("unable to expand filepath glob: %s", err)
+	}
+
+	if len(expandedFilepaths) == 0 {
+		return errors.NewInputError("No timings files found under %v", strings.Join(filepaths, ", "))
+	}
+
+	updatedTimings := make(map[string]time.Duration)
+
+	for _, filepath := range expandedFilepaths {
+		fd, err := s.FileSystem.Open(filepath)
+		if err != nil {
+			return errors.NewSystemError("unable to open file: %s", err)
+		}
+
+		timings, err := local.DecodeTimings(fd)
+		_ = fd.Close()
+		if err != nil {
+			return errors.Wrapf(err, "unable to read %q", filepath)
+		}
+
+		for file, duration := range timings {
+			if storedDuration, ok := localStorage.Timings[file]; ok && storedDuration == duration {
+				continue
+			}
+
+			updatedTimings[file] = duration
+		}
+	}
+
+	if err := localStorage.MergeTimings(updatedTimings); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.Log.Infof(
+		"Merged %d updated test file %s from %d timings %s",
+		len(updatedTimings),
+		pluralize(len(updatedTimings), "timing", "timings"),
+		len(expandedFilepaths),
+		pluralize(len(expandedFilepaths), "file", "files"),
+	)
+
+	return nil
+}
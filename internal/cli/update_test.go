@@ -2,15 +2,21 @@ package cli_test
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"strings"
 
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
 	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/backend/remote"
 	"github.com/rwx-research/captain-cli/internal/cli"
 	"github.com/rwx-research/captain-cli/internal/errors"
 	"github.com/rwx-research/captain-cli/internal/fs"
 	"github.com/rwx-research/captain-cli/internal/mocks"
 	"github.com/rwx-research/captain-cli/internal/parsing"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -21,6 +27,7 @@ var _ = Describe("Update Captain", func() {
 		flakesPath      = "flakes"
 		quarantinesPath = "quarantines"
 		timingsPath     = "timings"
+		historyPath     = "history"
 	)
 
 	var (
@@ -30,10 +37,16 @@ var _ = Describe("Update Captain", func() {
 		service  cli.Service
 
 		flakes, quarantines, timings *mocks.File
+
+		// storedFlakes & storedQuarantines are read whenever the corresponding file is opened
+		storedFlakes, storedQuarantines string
 	)
 
 	BeforeEach(func() {
 		args = []string{}
+		ctx = context.Background()
+		storedFlakes = ""
+		storedQuarantines = ""
 
 		flakes = &mocks.File{
 			Builder: new(strings.Builder),
@@ -52,8 +65,10 @@ var _ = Describe("Update Captain", func() {
 		mockedFS.MockOpen = func(name string) (fs.File, error) {
 			switch name {
 			case flakesPath:
+				flakes.Reader = strings.NewReader(storedFlakes)
 				return flakes, nil
 			case quarantinesPath:
+				quarantines.Reader = strings.NewReader(storedQuarantines)
 				return quarantines, nil
 			case timingsPath:
 				return timings, nil
@@ -62,12 +77,19 @@ var _ = Describe("Update Captain", func() {
 			}
 		}
 		mockedFS.MockOpenFile = func(name string, flag int, perm os.FileMode) (fs.File, error) {
+			if strings.HasSuffix(name, ".lock") {
+				return new(mocks.File), nil
+			}
+
 			return mockedFS.MockOpen(name)
 		}
+		mockedFS.MockRemove = func(name string) error {
+			return nil
+		}
 	})
 
 	JustBeforeEach(func() {
-		api, err := local.NewClient(mockedFS, flakesPath, quarantinesPath, timingsPath)
+		api, err := local.NewClient(mockedFS, zap.NewNop().Sugar(), flakesPath, quarantinesPath, timingsPath, historyPath)
 		Expect(err).NotTo(HaveOccurred())
 
 		service = cli.Service{
@@ -75,6 +97,7 @@ var _ = Describe("Update Captain", func() {
 			FileSystem:  mockedFS,
 			TaskRunner:  new(mocks.TaskRunner),
 			ParseConfig: parsing.Config{},
+			Log:         zap.NewNop().Sugar(),
 		}
 	})
 
@@ -113,11 +136,72 @@ var _ = Describe("Update Captain", func() {
 					Expect(fileContent).To(HavePrefix("- project:"), "The first line should be the first argument")
 					Expect(fileContent).To(ContainSubstring("project: foobar"))
 					Expect(fileContent).To(ContainSubstring("description: another test"))
+					Expect(fileContent).To(MatchRegexp(`quarantined_at: \d{4}-\d{2}-\d{2}T`))
+				})
+			})
+
+			Context("using metadata flags", func() {
+				BeforeEach(func() {
+					args = []string{
+						"--description", "another test", "--owner", "platform-team", "--reason", "times out on CI",
+						"--issue=JIRA-123", "--expires-at", "2023-06-01", "--quarantined-at", "2023-05-01T10:00:00Z",
+					}
+				})
+
+				It("stores the metadata after the identity of the test", func() {
+					Expect(quarantines.Builder.String()).To(Equal(
+						"- description: another test\n" +
+							"  owner: platform-team\n" +
+							"  reason: times out on CI\n" +
+							"  issue: JIRA-123\n" +
+							"  expires_at: 2023-06-01T00:00:00Z\n" +
+							"  quarantined_at: 2023-05-01T10:00:00Z\n",
+					))
+				})
+			})
+
+			Context("using an invalid expiry date", func() {
+				It("fails", func() {
+					err := service.AddQuarantine(ctx, []string{"--description", "another test", "--expires-at", "soon"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Invalid --expires-at"))
+				})
+			})
+
+			Context("using an unsupported match", func() {
+				It("fails", func() {
+					err := service.AddQuarantine(ctx, []string{"--description", "another test", "--match", "fuzzy"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Invalid --match"))
+				})
+			})
+
+			Context("using an invalid pattern", func() {
+				It("fails", func() {
+					err := service.AddQuarantine(ctx, []string{"--description", "another (test", "--match", "regex"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Invalid --description"))
 				})
 			})
 		})
 	})
 
+	Describe("adding & removing concurrently", func() {
+		It("keeps flakes that another process added in the meantime", func() {
+			storedFlakes = "- name: test-1\n"
+
+			Expect(service.AddFlake(ctx, []string{"--name", "test-2"})).To(Succeed())
+			Expect(flakes.Builder.String()).To(Equal("- name: test-1\n- name: test-2\n"))
+		})
+
+		It("keeps quarantines that another process added in the meantime", func() {
+			storedQuarantines = "- name: test-1\n- name: test-2\n"
+
+			Expect(service.RemoveQuarantine(ctx, []string{"--name", "test-1"})).To(Succeed())
+			Expect(quarantines.Builder.String()).To(Equal("- name: test-2\n"))
+		})
+	})
+
 	Describe("removing", func() {
 		Context("a flake", func() {
 			JustBeforeEach(func() {
@@ -127,7 +211,7 @@ var _ = Describe("Update Captain", func() {
 			Context("using some args", func() {
 				BeforeEach(func() {
 					args = []string{"--name", "test-1"}
-					flakes.Reader = strings.NewReader("- name: test-1\n- name: test-2")
+					storedFlakes = "- name: test-1\n- name: test-2"
 				})
 
 				It("updates the local flakes file", func() {
@@ -144,13 +228,108 @@ var _ = Describe("Update Captain", func() {
 			Context("using some args", func() {
 				BeforeEach(func() {
 					args = []string{"--description", "my test"}
-					quarantines.Reader = strings.NewReader("- name: test-1\n- description: my test")
+					storedQuarantines = "- name: test-1\n- description: my test"
 				})
 
 				It("updates the local flakes file", func() {
 					Expect(quarantines.Builder.String()).To(Equal("- name: test-1\n"))
 				})
 			})
+
+			Context("with metadata", func() {
+				BeforeEach(func() {
+					args = []string{"--description", "my test"}
+					storedQuarantines = "- name: test-1\n- description: my test\n  owner: me\n  quarantined_at: 2023-05-01T10:00:00Z"
+				})
+
+				It("ignores the metadata when identifying the test", func() {
+					Expect(quarantines.Builder.String()).To(Equal("- name: test-1\n"))
+				})
+			})
+
+			Context("with patterns", func() {
+				BeforeEach(func() {
+					args = []string{"--description", "my *", "--match", "glob"}
+					storedQuarantines = "- description: my *\n- description: my *\n  match: glob"
+				})
+
+				It("only removes the entry with the same match", func() {
+					Expect(quarantines.Builder.String()).To(Equal("- description: my *\n"))
+				})
+			})
+		})
+	})
+
+	Describe("merging timings", func() {
+		var partitionTimings map[string]string
+
+		BeforeEach(func() {
+			partitionTimings = map[string]string{
+				"partition-0.yaml": "a_spec.rb: 4s\nb_spec.rb: 2s\nc_spec.rb: 3s\n",
+				"partition-1.yaml": "a_spec.rb: 1s\nb_spec.rb: 5s\nc_spec.rb: 3s\n",
+			}
+
+			open := mockedFS.MockOpen
+			mockedFS.MockOpen = func(name string) (fs.File, error) {
+				if content, ok := partitionTimings[name]; ok {
+					return &mocks.File{Reader: strings.NewReader(content)}, nil
+				}
+
+				if name == timingsPath {
+					return &mocks.File{
+						Builder: timings.Builder,
+						Reader:  strings.NewReader("a_spec.rb: 1s\nb_spec.rb: 2s\nc_spec.rb: 3s\n"),
+					}, nil
+				}
+
+				return open(name)
+			}
+			mockedFS.MockGlob = func(pattern string) ([]string, error) {
+				return []string{"partition-0.yaml", "partition-1.yaml"}, nil
+			}
+		})
+
+		It("only merges timings that were updated by a partition", func() {
+			Expect(service.MergeTimings(ctx, []string{"partition-*.yaml"})).To(Succeed())
+			Expect(timings.Builder.String()).To(Equal("a_spec.rb: 4s\nb_spec.rb: 5s\nc_spec.rb: 3s\n"))
+		})
+
+		It("requires at least one timings file", func() {
+			mockedFS.MockGlob = func(pattern string) ([]string, error) {
+				return []string{}, nil
+			}
+
+			err := service.MergeTimings(ctx, []string{"partition-*.yaml"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No timings files found"))
+		})
+	})
+
+	Describe("replaying spooled uploads", func() {
+		It("only works with the Captain API", func() {
+			err := service.ReplaySpool(ctx, "suite-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unable to replay spooled uploads"))
+		})
+
+		It("errs if spooled uploads remain", func() {
+			apiClient := remote.Client{
+				ClientConfig: remote.ClientConfig{
+					Log:            zap.NewNop().Sugar(),
+					NewUUID:        uuid.NewRandom,
+					SpoolDirectory: GinkgoT().TempDir(),
+				},
+				RoundTrip: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.NewSystemError("unable to perform HTTP request")
+				},
+			}
+			_, err := apiClient.UpdateTestResults(ctx, "suite-id", v1.TestResults{})
+			Expect(err).To(HaveOccurred())
+
+			service.API = apiClient
+			err = service.ReplaySpool(ctx, "suite-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("1 spooled upload could not be replayed"))
 		})
 	})
 })
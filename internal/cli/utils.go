@@ -8,6 +8,7 @@ import (
 
 	"github.com/rwx-research/captain-cli/internal/errors"
 	"github.com/rwx-research/captain-cli/internal/fs"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
 )
 
 type intermediateArtifactStorage struct {
@@ -15,6 +16,7 @@ type intermediateArtifactStorage struct {
 	commandID  string
 	fs         fs.FileSystem
 	retryID    string
+	temporary  bool
 	workingDir string
 }
 
@@ -38,6 +40,7 @@ func (s Service) newIntermediateArtifactStorage(path string) (*intermediateArtif
 		}
 
 		ias.basePath = path
+		ias.temporary = true
 
 		return ias, nil
 	}
@@ -66,14 +69,20 @@ func (s Service) newIntermediateArtifactStorage(path string) (*intermediateArtif
 	return ias, nil
 }
 
-func (ias *intermediateArtifactStorage) moveTestResults(artifacts []string) error {
-	var err error
-
+func (ias *intermediateArtifactStorage) attemptPath() string {
 	attemptPath := filepath.Join(ias.basePath, ias.retryID)
 	if ias.commandID != "" {
 		attemptPath = filepath.Join(attemptPath, ias.commandID)
 	}
 
+	return attemptPath
+}
+
+func (ias *intermediateArtifactStorage) moveTestResults(artifacts []string) error {
+	var err error
+
+	attemptPath := ias.attemptPath()
+
 	for _, artifact := range artifacts {
 		dir, filename := filepath.Split(artifact)
 
@@ -111,6 +120,106 @@ func (ias *intermediateArtifactStorage) moveTestResults(artifacts []string) erro
 	return nil
 }
 
+// copyAttachments copies the files that are attached to the test results (e.g. screenshots) into the storage of the
+// current attempt, and points the attachments to their copies. Frameworks like Playwright clean up their output
+// directory before every run, which would otherwise remove the attachments of previous attempts. Nothing is copied if
+// the storage is temporary, as the copies would be removed before anyone could look at them, which means that the
+// attachments of every attempt are only kept with `--intermediate-artifacts-path`. Attachments that don't exist or are
+// outside of the working directory are skipped.
+func (ias *intermediateArtifactStorage) copyAttachments(testResults *v1.TestResults) error {
+	if testResults == nil || ias.temporary {
+		return nil
+	}
+
+	attemptPath := ias.attemptPath()
+	copiedPaths := make(map[string]string)
+
+	for i := range testResults.Tests {
+		test := &testResults.Tests[i]
+
+		attempts := []*v1.TestAttempt{&test.Attempt}
+		for j := range test.PastAttempts {
+			attempts = append(attempts, &test.PastAttempts[j])
+		}
+
+		for _, attempt := range attempts {
+			for j, attachment := range attempt.Attachments {
+				if attachment.Path == nil {
+					continue
+				}
+
+				copiedPath, ok := copiedPaths[*attachment.Path]
+				if !ok {
+					var err error
+
+					copiedPath, err = ias.copyAttachment(*attachment.Path, attemptPath)
+					if err != nil {
+						return errors.WithStack(err)
+					}
+
+					copiedPaths[*attachment.Path] = copiedPath
+				}
+
+				if copiedPath != "" {
+					path := copiedPath
+					attempt.Attachments[j].Path = &path
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyAttachment returns the path of the copy, or an empty string if the attachment was skipped.
+func (ias *intermediateArtifactStorage) copyAttachment(path, attemptPath string) (string, error) {
+	relativePath := path
+	if filepath.IsAbs(relativePath) {
+		var err error
+
+		relativePath, err = filepath.Rel(ias.workingDir, relativePath)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	if !fs.IsLocal(relativePath) {
+		return "", nil
+	}
+
+	srcFile, err := ias.fs.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() {
+		_ = srcFile.Close()
+	}()
+
+	dstPath := filepath.Join(attemptPath, "attachments", relativePath)
+	if err := ias.fs.MkdirAll(filepath.Dir(dstPath), 0o750); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	dstFile, err := ias.fs.Create(dstPath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		_ = dstFile.Close()
+		return "", errors.WithStack(err)
+	}
+
+	if err := dstFile.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return dstPath, nil
+}
+
 func (ias *intermediateArtifactStorage) moveFile(srcPath, dstPath string) error {
 	// Renaming only works if both paths are on the same file-system. We'll fall back to
 	// copy & delete instead if this is not the case.
@@ -1,6 +1,12 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+)
 
 type PartitionNodes struct {
 	Total int
@@ -10,3 +16,43 @@ type PartitionNodes struct {
 func (pn PartitionNodes) String() string {
 	return fmt.Sprintf("%d/%d", pn.Index, pn.Total)
 }
+
+// ParsePartitionNodes parses a partition in the `<index>/<total>` notation, e.g. "1/4".
+func ParsePartitionNodes(s string) (PartitionNodes, error) {
+	rawIndex, rawTotal, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return PartitionNodes{}, errors.NewInputError("partition %q is not in the '<index>/<total>' format", s)
+	}
+
+	index, err := strconv.Atoi(strings.TrimSpace(rawIndex))
+	if err != nil {
+		return PartitionNodes{}, errors.NewInputError("partition index %q is not a number", rawIndex)
+	}
+
+	total, err := strconv.Atoi(strings.TrimSpace(rawTotal))
+	if err != nil {
+		return PartitionNodes{}, errors.NewInputError("partition total %q is not a number", rawTotal)
+	}
+
+	if total < 1 || index < 0 || index >= total {
+		return PartitionNodes{}, errors.NewInputError(
+			"partition %q is invalid. The index needs to be between 0 and the total number of partitions", s,
+		)
+	}
+
+	return PartitionNodes{Index: index, Total: total}, nil
+}
+
+// CombinePartitionNodes composes the partitions of multiple dimensions (e.g. browser × shard) into a single
+// partition. The first dimension is the most significant one, i.e. with dimensions 1/2 and 2/3, the combined
+// partition is 5/6.
+func CombinePartitionNodes(dimensions []PartitionNodes) PartitionNodes {
+	combined := PartitionNodes{Index: 0, Total: 1}
+
+	for _, dimension := range dimensions {
+		combined.Index = combined.Index*dimension.Total + dimension.Index
+		combined.Total *= dimension.Total
+	}
+
+	return combined
+}
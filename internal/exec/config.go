@@ -8,5 +8,6 @@ type CommandConfig struct {
 	Env    []string
 	Name   string
 	Stderr io.Writer
+	Stdin  io.Reader
 	Stdout io.Writer
 }
@@ -19,6 +19,7 @@ func (l Local) NewCommand(ctx context.Context, cfg CommandConfig) (Command, erro
 	cmd := exec.CommandContext(ctx, cfg.Name, cfg.Args...)
 
 	cmd.Stderr = cfg.Stderr
+	cmd.Stdin = cfg.Stdin
 	cmd.Stdout = cfg.Stdout
 
 	for _, override := range cfg.Env {
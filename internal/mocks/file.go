@@ -17,6 +17,15 @@ type File struct {
 	MockName    func() string
 }
 
+// Write either writes to the builder of the file or discards `p` if there is none
+func (f *File) Write(p []byte) (int, error) {
+	if f.Builder == nil {
+		return len(p), nil
+	}
+
+	return f.Builder.Write(p)
+}
+
 // Close will always return nil.
 func (f *File) Close() error {
 	return nil
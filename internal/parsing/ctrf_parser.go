@@ -0,0 +1,285 @@
+package parsing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// CTRFParser parses the Common Test Report Format, see https://ctrf.io
+type CTRFParser struct{}
+
+type CTRFReport struct {
+	ReportFormat string       `json:"reportFormat,omitempty"`
+	SpecVersion  string       `json:"specVersion,omitempty"`
+	Results      *CTRFResults `json:"results"`
+}
+
+type CTRFResults struct {
+	Tool        CTRFTool         `json:"tool"`
+	Summary     *CTRFSummary     `json:"summary"`
+	Tests       []CTRFTest       `json:"tests"`
+	Environment *CTRFEnvironment `json:"environment,omitempty"`
+}
+
+type CTRFTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type CTRFSummary struct {
+	Tests   int   `json:"tests"`
+	Passed  int   `json:"passed"`
+	Failed  int   `json:"failed"`
+	Pending int   `json:"pending"`
+	Skipped int   `json:"skipped"`
+	Other   int   `json:"other"`
+	Start   int64 `json:"start"`
+	Stop    int64 `json:"stop"`
+}
+
+type CTRFEnvironment struct {
+	BuildName      string `json:"buildName,omitempty"`
+	BuildURL       string `json:"buildUrl,omitempty"`
+	RepositoryName string `json:"repositoryName,omitempty"`
+	BranchName     string `json:"branchName,omitempty"`
+	Commit         string `json:"commit,omitempty"`
+}
+
+type CTRFTest struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Duration  float64   `json:"duration"`
+	Start     int64     `json:"start,omitempty"`
+	Stop      int64     `json:"stop,omitempty"`
+	Suite     CTRFSuite `json:"suite,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Trace     string    `json:"trace,omitempty"`
+	RawStatus string    `json:"rawStatus,omitempty"`
+	FilePath  string    `json:"filePath,omitempty"`
+	Line      *int      `json:"line,omitempty"`
+	Retries   int       `json:"retries,omitempty"`
+	Flaky     bool      `json:"flaky,omitempty"`
+	Stdout    []string  `json:"stdout,omitempty"`
+	Stderr    []string  `json:"stderr,omitempty"`
+}
+
+// CTRFSuite is the suite of a CTRF test. Most tools report it as a single string, some as a list of nested suites.
+type CTRFSuite []string
+
+const (
+	CTRFStatusPassed  = "passed"
+	CTRFStatusFailed  = "failed"
+	CTRFStatusPending = "pending"
+	CTRFStatusSkipped = "skipped"
+	CTRFStatusOther   = "other"
+
+	// CTRFRawStatusOtherError marks entries that are errors outside of tests rather than actual tests
+	CTRFRawStatusOtherError = "otherError"
+
+	ctrfSuiteSeparator = " > "
+)
+
+func (s CTRFSuite) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(strings.Join(s, ctrfSuiteSeparator)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (s *CTRFSuite) UnmarshalJSON(data []byte) error {
+	var suites []string
+	if err := json.Unmarshal(data, &suites); err == nil {
+		*s = suites
+		return nil
+	}
+
+	var suite string
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if suite == "" {
+		*s = nil
+	} else {
+		*s = strings.Split(suite, ctrfSuiteSeparator)
+	}
+
+	return nil
+}
+
+func (p CTRFParser) Parse(data io.Reader) (*v1.TestResults, error) {
+	var report CTRFReport
+
+	if err := json.NewDecoder(data).Decode(&report); err != nil {
+		return nil, errors.NewInputError("Unable to parse test results as JSON: %s", err)
+	}
+	if report.ReportFormat != "" && report.ReportFormat != "CTRF" {
+		return nil, errors.NewInputError("The JSON has a report format of %q rather than CTRF", report.ReportFormat)
+	}
+	if report.Results == nil || report.Results.Summary == nil {
+		return nil, errors.NewInputError("No results were found in the JSON")
+	}
+	if report.Results.Tool.Name == "" {
+		return nil, errors.NewInputError("No tool was found in the JSON")
+	}
+	if report.Results.Tests == nil {
+		return nil, errors.NewInputError("No tests were found in the JSON")
+	}
+
+	tests := make([]v1.Test, 0, len(report.Results.Tests))
+	otherErrors := make([]v1.OtherError, 0)
+
+	for _, ctrfTest := range report.Results.Tests {
+		if ctrfTest.RawStatus == CTRFRawStatusOtherError {
+			otherErrors = append(otherErrors, p.otherErrorFrom(ctrfTest))
+			continue
+		}
+
+		tests = append(tests, p.testFrom(ctrfTest))
+	}
+
+	return v1.NewTestResults(
+		p.frameworkFor(report.Results.Tool),
+		tests,
+		otherErrors,
+	), nil
+}
+
+// frameworkFor returns the known framework with the same name as the CTRF tool. CTRF doesn't include the language,
+// which is why ambiguous tool names (e.g. Cucumber) are reported as other frameworks.
+func (p CTRFParser) frameworkFor(tool CTRFTool) v1.Framework {
+	var framework *v1.Framework
+
+	for i, knownFramework := range v1.KnownFrameworks {
+		if !strings.EqualFold(string(knownFramework.Kind), strings.TrimSpace(tool.Name)) {
+			continue
+		}
+
+		if framework != nil {
+			return v1.NewOtherFramework(nil, nil)
+		}
+
+		framework = &v1.KnownFrameworks[i]
+	}
+
+	if framework == nil {
+		return v1.NewOtherFramework(nil, nil)
+	}
+
+	return *framework
+}
+
+func (p CTRFParser) testFrom(ctrfTest CTRFTest) v1.Test {
+	duration := time.Duration(ctrfTest.Duration * float64(time.Millisecond))
+
+	test := v1.Test{
+		Name: ctrfTest.Name,
+		Attempt: v1.TestAttempt{
+			Duration: &duration,
+			Status:   p.statusFrom(ctrfTest),
+			Stdout:   p.outputFrom(ctrfTest.Stdout),
+			Stderr:   p.outputFrom(ctrfTest.Stderr),
+		},
+	}
+
+	if len(ctrfTest.Suite) > 0 {
+		test.Lineage = append(append([]string{}, ctrfTest.Suite...), ctrfTest.Name)
+	}
+
+	if ctrfTest.FilePath != "" {
+		test.Location = &v1.Location{File: ctrfTest.FilePath, Line: ctrfTest.Line}
+	}
+
+	if ctrfTest.Start > 0 {
+		startedAt := time.UnixMilli(ctrfTest.Start).UTC()
+		test.Attempt.StartedAt = &startedAt
+	}
+
+	if ctrfTest.Stop > 0 {
+		finishedAt := time.UnixMilli(ctrfTest.Stop).UTC()
+		test.Attempt.FinishedAt = &finishedAt
+	}
+
+	// CTRF only reports the number of retries, not their outcome. Since tests are retried because they failed, past
+	// attempts are reported as failures.
+	test.PastAttempts = make([]v1.TestAttempt, ctrfTest.Retries)
+	for i := range test.PastAttempts {
+		test.PastAttempts[i] = v1.TestAttempt{Status: v1.NewFailedTestStatus(nil, nil, nil)}
+	}
+
+	return test
+}
+
+func (p CTRFParser) statusFrom(ctrfTest CTRFTest) v1.TestStatus {
+	var message *string
+	if ctrfTest.Message != "" {
+		message = &ctrfTest.Message
+	}
+
+	var backtrace []string
+	if ctrfTest.Trace != "" {
+		backtrace = strings.Split(ctrfTest.Trace, "\n")
+	}
+
+	switch ctrfTest.Status {
+	case CTRFStatusPassed:
+		return v1.NewSuccessfulTestStatus()
+	case CTRFStatusSkipped:
+		return v1.NewSkippedTestStatus(message)
+	case CTRFStatusPending:
+		if ctrfTest.RawStatus == string(v1.TestStatusTodo) {
+			return v1.NewTodoTestStatus(message)
+		}
+
+		return v1.NewPendedTestStatus(message)
+	}
+
+	// "failed" & "other". We can't tell whether other tests passed, so they're treated as failures.
+	status := v1.NewFailedTestStatus(message, nil, backtrace)
+	switch ctrfTest.RawStatus {
+	case string(v1.TestStatusTimedOut):
+		status.Kind = v1.TestStatusTimedOut
+	case string(v1.TestStatusCanceled):
+		status.Kind = v1.TestStatusCanceled
+	}
+
+	return status
+}
+
+func (p CTRFParser) otherErrorFrom(ctrfTest CTRFTest) v1.OtherError {
+	otherError := v1.OtherError{Message: ctrfTest.Message}
+
+	if otherError.Message == "" {
+		otherError.Message = ctrfTest.Name
+	}
+
+	if ctrfTest.Trace != "" {
+		otherError.Backtrace = strings.Split(ctrfTest.Trace, "\n")
+	}
+
+	if ctrfTest.FilePath != "" {
+		otherError.Location = &v1.Location{File: ctrfTest.FilePath, Line: ctrfTest.Line}
+	}
+
+	return otherError
+}
+
+func (p CTRFParser) outputFrom(lines []string) *string {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	output := strings.Join(lines, "\n")
+	return &output
+}
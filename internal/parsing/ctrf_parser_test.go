@@ -0,0 +1,106 @@
+package parsing_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy"
+
+	"github.com/rwx-research/captain-cli/internal/parsing"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CTRFParser", func() {
+	Describe("Parse", func() {
+		It("parses the sample file", func() {
+			fixture, err := os.Open("../../test/fixtures/ctrf.json")
+			Expect(err).ToNot(HaveOccurred())
+
+			testResults, err := parsing.CTRFParser{}.Parse(fixture)
+			Expect(err).ToNot(HaveOccurred())
+			rwxJSON, err := json.MarshalIndent(testResults, "", "  ")
+			Expect(err).ToNot(HaveOccurred())
+			cupaloy.SnapshotT(GinkgoT(), rwxJSON)
+		})
+
+		It("maps the CTRF test attributes", func() {
+			fixture, err := os.Open("../../test/fixtures/ctrf.json")
+			Expect(err).ToNot(HaveOccurred())
+
+			testResults, err := parsing.CTRFParser{}.Parse(fixture)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(testResults.Framework).To(Equal(v1.JavaScriptPlaywrightFramework))
+			Expect(testResults.Tests).To(HaveLen(6))
+			Expect(testResults.OtherErrors).To(HaveLen(1))
+			Expect(testResults.OtherErrors[0].Message).To(Equal("Error: Cannot find module './helpers'"))
+
+			test := testResults.Tests[1]
+			Expect(test.Lineage).To(Equal([]string{"example.spec.ts", "homepage", "get started link"}))
+			Expect(*test.Attempt.Duration).To(Equal(1204500 * time.Microsecond))
+			Expect(test.PastAttempts).To(HaveLen(2))
+			Expect(test.Flaky()).To(BeTrue())
+			Expect(*test.Attempt.Stdout).To(Equal("navigating to /\nclicked get started"))
+
+			Expect(testResults.Tests[2].Lineage).To(Equal([]string{"example.spec.ts", "docs", "shows the docs"}))
+			Expect(testResults.Tests[2].Attempt.Status.Kind).To(Equal(v1.TestStatusTimedOut))
+			Expect(testResults.Tests[3].Attempt.Status.Backtrace).To(HaveLen(2))
+			Expect(testResults.Tests[5].Lineage).To(BeNil())
+		})
+
+		It("errors on malformed JSON", func() {
+			testResults, err := parsing.CTRFParser{}.Parse(strings.NewReader(`{`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unable to parse test results as JSON"))
+			Expect(testResults).To(BeNil())
+		})
+
+		It("errors on JSON that doesn't look like CTRF", func() {
+			var testResults *v1.TestResults
+			var err error
+
+			testResults, err = parsing.CTRFParser{}.Parse(strings.NewReader(`{}`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No results were found in the JSON"))
+			Expect(testResults).To(BeNil())
+
+			testResults, err = parsing.CTRFParser{}.Parse(strings.NewReader(`{"results": {"summary": {}}}`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No tool was found in the JSON"))
+			Expect(testResults).To(BeNil())
+
+			testResults, err = parsing.CTRFParser{}.Parse(
+				strings.NewReader(`{"results": {"tool": {"name": "jest"}, "summary": {}}}`),
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No tests were found in the JSON"))
+			Expect(testResults).To(BeNil())
+
+			testResults, err = parsing.CTRFParser{}.Parse(
+				strings.NewReader(`{"reportFormat": "other", "results": {"tool": {"name": "jest"}, "summary": {}, "tests": []}}`),
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("rather than CTRF"))
+			Expect(testResults).To(BeNil())
+
+			testResults, err = parsing.CTRFParser{}.Parse(
+				strings.NewReader(`{"results": {"tool": {"name": "jest"}, "summary": {}, "tests": []}}`),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testResults.Framework).To(Equal(v1.JavaScriptJestFramework))
+		})
+
+		It("uses an other framework for ambiguous tools", func() {
+			testResults, err := parsing.CTRFParser{}.Parse(
+				strings.NewReader(`{"results": {"tool": {"name": "cucumber"}, "summary": {}, "tests": []}}`),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testResults.Framework.IsOther()).To(BeTrue())
+		})
+	})
+})
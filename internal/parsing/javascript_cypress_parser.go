@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -64,7 +65,26 @@ type JavaScriptCypressTestResults struct {
 	XMLName    xml.Name                     `xml:"testsuites"`
 }
 
-var javaScriptCypressNewlineRegexp = regexp.MustCompile(`\r?\n`)
+var (
+	javaScriptCypressNewlineRegexp = regexp.MustCompile(`\r?\n`)
+
+	// mocha-junit-reporter references screenshots & other files in the output of a test case when its `attachments`
+	// option is enabled, see https://plugins.jenkins.io/junit-attachments/
+	javaScriptCypressAttachmentRegexp = regexp.MustCompile(`\[\[ATTACHMENT\|([^\]]+)\]\]`)
+
+	javaScriptCypressAttachmentContentTypes = map[string]string{
+		".gif":  "image/gif",
+		".jpeg": "image/jpeg",
+		".jpg":  "image/jpeg",
+		".json": "application/json",
+		".mp4":  "video/mp4",
+		".png":  "image/png",
+		".txt":  "text/plain",
+		".webm": "video/webm",
+		".webp": "image/webp",
+		".zip":  "application/zip",
+	}
+)
 
 func (p JavaScriptCypressParser) Parse(data io.Reader) (*v1.TestResults, error) {
 	var testResults JavaScriptCypressTestResults
@@ -167,11 +187,12 @@ func (p JavaScriptCypressParser) Parse(data io.Reader) (*v1.TestResults, error)
 					Name:     name,
 					Location: location,
 					Attempt: v1.TestAttempt{
-						Duration: &duration,
-						Meta:     properties,
-						Status:   status,
-						Stderr:   testCase.SystemErr,
-						Stdout:   testCase.SystemOut,
+						Duration:    &duration,
+						Meta:        properties,
+						Status:      status,
+						Stderr:      testCase.SystemErr,
+						Stdout:      testCase.SystemOut,
+						Attachments: p.attachmentsOf(testCase),
 					},
 				},
 			)
@@ -184,3 +205,30 @@ func (p JavaScriptCypressParser) Parse(data io.Reader) (*v1.TestResults, error)
 		nil,
 	), nil
 }
+
+func (p JavaScriptCypressParser) attachmentsOf(testCase JavaScriptCypressTestCase) []v1.Attachment {
+	var attachments []v1.Attachment
+
+	for _, output := range []*string{testCase.SystemOut, testCase.SystemErr} {
+		if output == nil {
+			continue
+		}
+
+		for _, match := range javaScriptCypressAttachmentRegexp.FindAllStringSubmatch(*output, -1) {
+			path := strings.TrimSpace(match[1])
+
+			contentType, ok := javaScriptCypressAttachmentContentTypes[strings.ToLower(filepath.Ext(path))]
+			if !ok {
+				contentType = "application/octet-stream"
+			}
+
+			attachments = append(attachments, v1.Attachment{
+				Name:        filepath.Base(path),
+				ContentType: contentType,
+				Path:        &path,
+			})
+		}
+	}
+
+	return attachments
+}
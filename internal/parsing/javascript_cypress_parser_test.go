@@ -78,6 +78,22 @@ var _ = Describe("JavaScriptCypressParser", func() {
 			Expect(testResults).To(BeNil())
 		})
 
+		It("parses attachments referenced in the output", func() {
+			testResults, err := parsing.JavaScriptCypressParser{}.Parse(strings.NewReader(
+				`<testsuites tests="1"><testsuite file="cypress/e2e/todo.cy.js">` +
+					`<testcase name="fails" classname="todo fails" time="1">` +
+					`<failure message="expected true to equal false" type="AssertionError"></failure>` +
+					`<system-out>[[ATTACHMENT|cypress/screenshots/todo.cy.js/todo fails (failed).png]]</system-out>` +
+					`</testcase></testsuite></testsuites>`,
+			))
+			Expect(err).ToNot(HaveOccurred())
+
+			path := "cypress/screenshots/todo.cy.js/todo fails (failed).png"
+			Expect(testResults.Tests[0].Attempt.Attachments).To(Equal([]v1.Attachment{
+				{Name: "todo fails (failed).png", ContentType: "image/png", Path: &path},
+			}))
+		})
+
 		It("parses files properly", func() {
 			fixture, err := os.Open("../../test/fixtures/cypress.xml")
 			Expect(err).ToNot(HaveOccurred())
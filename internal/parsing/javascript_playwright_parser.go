@@ -119,6 +119,10 @@ type JavaScriptPlaywrightMetaFileAttachment struct {
 	Path string `json:"path,omitempty"`
 }
 
+// javaScriptPlaywrightMaxInlinedAttachmentSize is the maximum size of an attachment body (base64 encoded) that is
+// kept as part of the test results. Larger bodies are dropped unless they were also written to a file.
+const javaScriptPlaywrightMaxInlinedAttachmentSize = 64 * 1000
+
 var javaScriptPlaywrightBacktraceSeparatorRegexp = regexp.MustCompile(`\r?\n\s{4}at`)
 
 func (p JavaScriptPlaywrightParser) Parse(data io.Reader) (*v1.TestResults, error) {
@@ -309,12 +313,13 @@ func (p JavaScriptPlaywrightParser) testsWithinSuite(
 			}
 
 			workingAttempt := v1.TestAttempt{
-				Duration:  &duration,
-				Meta:      meta,
-				Status:    status,
-				Stderr:    &stderr,
-				Stdout:    &stdout,
-				StartedAt: &startedAt,
+				Duration:    &duration,
+				Meta:        meta,
+				Status:      status,
+				Stderr:      &stderr,
+				Stdout:      &stdout,
+				StartedAt:   &startedAt,
+				Attachments: p.attachmentsOf(result),
 			}
 
 			if i == resultCount-1 {
@@ -346,3 +351,30 @@ func (p JavaScriptPlaywrightParser) testsWithinSuite(
 	tests = append(tests, nestedTests...)
 	return tests, nil
 }
+
+func (p JavaScriptPlaywrightParser) attachmentsOf(result JavaScriptPlaywrightTestResult) []v1.Attachment {
+	var attachments []v1.Attachment
+
+	for _, attachment := range result.Attachments {
+		parsedAttachment := v1.Attachment{Name: attachment.Name, ContentType: attachment.ContentType}
+
+		if attachment.Path != "" {
+			path := attachment.Path
+			parsedAttachment.Path = &path
+		}
+
+		// The JSON reporter encodes bodies as base64 already
+		if attachment.Body != "" && len(attachment.Body) <= javaScriptPlaywrightMaxInlinedAttachmentSize {
+			content := attachment.Body
+			parsedAttachment.Content = &content
+		}
+
+		if parsedAttachment.Path == nil && parsedAttachment.Content == nil {
+			continue
+		}
+
+		attachments = append(attachments, parsedAttachment)
+	}
+
+	return attachments
+}
@@ -38,6 +38,61 @@ var _ = Describe("JavaScriptPlaywrightParser", func() {
 			Expect(*test.Scope).To(SatisfyAny(Equal("chromium"), Equal("firefox")))
 		})
 
+		It("parses attachments", func() {
+			fixture, err := os.Open("../../test/fixtures/playwright.json")
+			Expect(err).ToNot(HaveOccurred())
+
+			testResults, err := parsing.JavaScriptPlaywrightParser{}.Parse(fixture)
+			Expect(err).ToNot(HaveOccurred())
+
+			attachments := make([]v1.Attachment, 0)
+			for _, test := range testResults.Tests {
+				for _, attempt := range append(append([]v1.TestAttempt{}, test.PastAttempts...), test.Attempt) {
+					attachments = append(attachments, attempt.Attachments...)
+				}
+			}
+
+			Expect(attachments).NotTo(BeEmpty())
+			Expect(attachments[0].Name).To(Equal("trace"))
+			Expect(attachments[0].ContentType).To(Equal("application/zip"))
+			Expect(*attachments[0].Path).To(HaveSuffix("/trace.zip"))
+			Expect(attachments[0].Content).To(BeNil())
+		})
+
+		It("inlines small attachment bodies", func() {
+			testResults, err := parsing.JavaScriptPlaywrightParser{}.Parse(strings.NewReader(`{
+				"config": {},
+				"errors": [],
+				"suites": [{
+					"title": "example.spec.ts",
+					"file": "example.spec.ts",
+					"specs": [{
+						"title": "has a title",
+						"file": "example.spec.ts",
+						"tests": [{
+							"projectName": "chromium",
+							"expectedStatus": "passed",
+							"status": "expected",
+							"results": [{
+								"status": "passed",
+								"startTime": "2022-12-15T20:31:04.561Z",
+								"attachments": [
+									{"name": "note", "contentType": "text/plain", "body": "aGVsbG8="},
+									{"name": "empty", "contentType": "text/plain"}
+								]
+							}]
+						}]
+					}]
+				}]
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			content := "aGVsbG8="
+			Expect(testResults.Tests[0].Attempt.Attachments).To(Equal([]v1.Attachment{
+				{Name: "note", ContentType: "text/plain", Content: &content},
+			}))
+		})
+
 		It("parses the sample file with other errors", func() {
 			fixture, err := os.Open("../../test/fixtures/playwright_with_other_errors.json")
 			Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,279 @@
+// Package progress renders a live status line at the bottom of a terminal while the output of sub-processes scrolls
+// by above it.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	refreshInterval = 200 * time.Millisecond
+
+	// minWidth is the narrowest terminal that the status line is drawn in, which still fits a character & an ellipsis
+	minWidth = 3
+	// minHeight is the shortest terminal that the status line is drawn in, which leaves at least one row for output
+	minHeight = 2
+
+	saveCursor    = "\x1b7"
+	restoreCursor = "\x1b8"
+	clearLine     = "\x1b[2K"
+	resetRegion   = "\x1b[r"
+	dim           = "\x1b[2m"
+	reset         = "\x1b[0m"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Status is what the status line shows.
+type Status struct {
+	// Attempt is the current attempt, starting at 1 for the original run
+	Attempt int
+	// MaxAttempts is the maximum number of attempts, including the original run
+	MaxAttempts int
+	// RetriedTests is the number of tests that were retried so far
+	RetriedTests int
+	// RemainingFlakyFailures are failing tests that are known to be flaky
+	RemainingFlakyFailures int
+	// RemainingNonFlakyFailures are failing tests that aren't known to be flaky
+	RemainingNonFlakyFailures int
+}
+
+// SizeFunc returns the width & height of a terminal, or an error if they can't be determined.
+type SizeFunc func() (width int, height int, err error)
+
+// TerminalSize returns the SizeFunc of the terminal that `file` refers to.
+func TerminalSize(file *os.File) SizeFunc {
+	return func() (int, int, error) {
+		return term.GetSize(int(file.Fd())) //nolint:wrapcheck
+	}
+}
+
+// Line is a status line in the bottom row of a terminal. The row is reserved for the status line by limiting the
+// scrolling region of the terminal to the rows above it, which means that the output of sub-processes can be written
+// to the terminal directly without garbling it. A nil Line is valid and doesn't render anything, which allows callers
+// to use it unconditionally.
+type Line struct {
+	mu   sync.Mutex
+	out  io.Writer
+	size SizeFunc
+
+	color     bool
+	status    Status
+	startedAt time.Time
+	frame     int
+
+	// width & height are the size of the terminal when the bottom row was reserved. Both are zero while no row is
+	// reserved.
+	width   int
+	height  int
+	stopped bool
+	done    chan struct{}
+	signals chan os.Signal
+}
+
+// IsTerminal returns whether the file is an interactive terminal that can render a status line.
+func IsTerminal(file *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// ColorEnabled returns whether output may be colored, see https://no-color.org
+func ColorEnabled() bool {
+	_, ok := os.LookupEnv("NO_COLOR")
+	return !ok
+}
+
+// New returns a status line that's drawn to the terminal `out`, which measures `size`. Nothing is drawn while the
+// size can't be determined, e.g. because `out` isn't a terminal. The status line is refreshed periodically until
+// `Stop` is called. As the terminal would keep its scrolling region otherwise, the status line is also removed if the
+// process is interrupted or terminated.
+func New(out io.Writer, size SizeFunc, color bool) *Line {
+	line := &Line{
+		out:       out,
+		size:      size,
+		color:     color,
+		startedAt: time.Now(),
+		done:      make(chan struct{}),
+		signals:   make(chan os.Signal, 1),
+	}
+
+	signal.Notify(line.signals, os.Interrupt, syscall.SIGTERM)
+	go line.refresh()
+
+	return line
+}
+
+// Update replaces the status & redraws the status line.
+func (l *Line) Update(status Status) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.status = status
+	l.draw()
+}
+
+// Stop removes the status line for good & gives the bottom row back to the output.
+func (l *Line) Stop() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stop()
+}
+
+func (l *Line) refresh() {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case sig := <-l.signals:
+			l.Stop()
+			reraise(sig)
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			l.frame = (l.frame + 1) % len(spinnerFrames)
+			l.draw()
+			l.mu.Unlock()
+		}
+	}
+}
+
+// reraise delivers `sig` to this process again now that it's no longer handled, which lets it exit like it would
+// have without a status line.
+func reraise(sig os.Signal) {
+	process, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = process.Signal(sig)
+	}
+
+	// Not every platform supports sending signals (e.g. interrupts on Windows)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// draw, release & stop need to be called while holding the lock
+func (l *Line) draw() {
+	if l.stopped {
+		return
+	}
+
+	width, height, err := l.size()
+	if err != nil || width < minWidth || height < minHeight {
+		l.release()
+		return
+	}
+
+	if width != l.width || height != l.height {
+		l.reserve(width, height)
+	}
+
+	text := l.text()
+	if l.color {
+		text = dim + text + reset
+	}
+
+	// Restoring the cursor also restores its attributes, which keeps colored output of sub-processes intact
+	_, _ = io.WriteString(
+		l.out,
+		fmt.Sprintf("%s\x1b[%d;1H%s%s%s", saveCursor, l.height, clearLine, text, restoreCursor),
+	)
+}
+
+// reserve limits the scrolling region of the terminal to all but its bottom row, e.g. again after it was resized. If
+// the cursor is in the bottom row, the output is scrolled up by one row first (moving the cursor down by one row &
+// back up) so that the cursor ends up inside of the scrolling region. Unlike a newline, this keeps the cursor in its
+// column, so partial lines of output stay intact.
+func (l *Line) reserve(width, height int) {
+	l.release()
+
+	_, _ = io.WriteString(
+		l.out,
+		fmt.Sprintf("\x1bD\x1b[1A%s\x1b[1;%dr%s", saveCursor, height-1, restoreCursor),
+	)
+	l.width = width
+	l.height = height
+}
+
+// release clears the status line & resets the scrolling region of the terminal to all of its rows.
+func (l *Line) release() {
+	if l.height == 0 {
+		return
+	}
+
+	_, _ = io.WriteString(
+		l.out,
+		fmt.Sprintf("%s%s\x1b[%d;1H%s%s", saveCursor, resetRegion, l.height, clearLine, restoreCursor),
+	)
+	l.width = 0
+	l.height = 0
+}
+
+func (l *Line) stop() {
+	if l.stopped {
+		return
+	}
+
+	l.release()
+	l.stopped = true
+	signal.Stop(l.signals)
+	close(l.done)
+}
+
+func (l *Line) text() string {
+	parts := []string{fmt.Sprintf("%v running tests", spinnerFrames[l.frame])}
+
+	if l.status.MaxAttempts > 1 {
+		parts[0] = fmt.Sprintf("%v attempt %v of %v", spinnerFrames[l.frame], l.status.Attempt, l.status.MaxAttempts)
+	}
+
+	parts = append(parts, time.Since(l.startedAt).Round(time.Second).String())
+
+	if l.status.RetriedTests > 0 {
+		parts = append(parts, fmt.Sprintf("%v retried", pluralize(l.status.RetriedTests, "test", "tests")))
+		parts = append(parts, fmt.Sprintf(
+			"still failing: %v flaky, %v non-flaky",
+			l.status.RemainingFlakyFailures,
+			l.status.RemainingNonFlakyFailures,
+		))
+	}
+
+	// The status line must not wrap, otherwise it would scroll the terminal
+	text := []rune(strings.Join(parts, " · "))
+	if len(text) > l.width-1 {
+		text = append(text[:l.width-2], '…')
+	}
+
+	return string(text)
+}
+
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%v %v", count, singular)
+	}
+
+	return fmt.Sprintf("%v %v", count, plural)
+}
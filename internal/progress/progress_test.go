@@ -0,0 +1,149 @@
+package progress_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/rwx-research/captain-cli/internal/progress"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// terminal records what's written to it. It's safe for concurrent use, as the status line is also redrawn periodically.
+type terminal struct {
+	mu      sync.Mutex
+	builder strings.Builder
+}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.builder.Write(p)
+}
+
+func (t *terminal) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.builder.String()
+}
+
+var _ = Describe("Line", func() {
+	var (
+		out           *terminal
+		line          *progress.Line
+		width, height int
+		sizeErr       error
+		sizeMutex     sync.Mutex
+	)
+
+	size := func() (int, int, error) {
+		sizeMutex.Lock()
+		defer sizeMutex.Unlock()
+
+		return width, height, sizeErr
+	}
+
+	resize := func(newWidth, newHeight int) {
+		sizeMutex.Lock()
+		defer sizeMutex.Unlock()
+
+		width, height = newWidth, newHeight
+	}
+
+	BeforeEach(func() {
+		out = new(terminal)
+		width, height, sizeErr = 80, 24, nil
+		line = progress.New(out, size, false)
+	})
+
+	AfterEach(func() {
+		line.Stop()
+	})
+
+	It("renders the status", func() {
+		line.Update(progress.Status{
+			Attempt:                   2,
+			MaxAttempts:               4,
+			RetriedTests:              5,
+			RemainingFlakyFailures:    1,
+			RemainingNonFlakyFailures: 2,
+		})
+		line.Stop()
+
+		Expect(out.String()).To(ContainSubstring(" attempt 2 of 4 · "))
+		Expect(out.String()).To(ContainSubstring(" · 5 tests retried · still failing: 1 flaky, 2 non-flaky"))
+	})
+
+	It("reserves the bottom row of the terminal for the status line", func() {
+		line.Update(progress.Status{Attempt: 1, MaxAttempts: 1})
+
+		Expect(out.String()).To(HavePrefix("\x1bD\x1b[1A\x1b7\x1b[1;23r\x1b8"))
+		Expect(out.String()).To(ContainSubstring("\x1b7\x1b[24;1H\x1b[2K"))
+		Expect(out.String()).To(ContainSubstring("running tests"))
+		Expect(out.String()).To(HaveSuffix("\x1b8"))
+	})
+
+	It("gives the bottom row back once stopped", func() {
+		line.Update(progress.Status{Attempt: 1, MaxAttempts: 1})
+		line.Stop()
+
+		Expect(out.String()).To(HaveSuffix("\x1b7\x1b[r\x1b[24;1H\x1b[2K\x1b8"))
+	})
+
+	It("reserves the bottom row again once the terminal was resized", func() {
+		line.Update(progress.Status{Attempt: 1, MaxAttempts: 1})
+		resize(80, 30)
+
+		Eventually(out.String).Should(ContainSubstring("\x1b7\x1b[1;29r\x1b8"))
+		Expect(out.String()).To(ContainSubstring("\x1b7\x1b[r\x1b[24;1H\x1b[2K\x1b8"))
+	})
+
+	It("truncates the status line to the width of the terminal", func() {
+		resize(20, 24)
+
+		line.Update(progress.Status{Attempt: 2, MaxAttempts: 4, RetriedTests: 5})
+		line.Stop()
+
+		status := strings.SplitN(strings.SplitN(out.String(), "\x1b[24;1H\x1b[2K", 2)[1], "\x1b8", 2)[0]
+		Expect([]rune(status)).To(HaveLen(19))
+		Expect(status).To(HaveSuffix("…"))
+	})
+
+	It("doesn't draw the status line in very small terminals", func() {
+		line.Stop()
+		resize(1, 24)
+
+		out = new(terminal)
+		line = progress.New(out, size, false)
+		line.Update(progress.Status{Attempt: 2, MaxAttempts: 4, RetriedTests: 5})
+
+		resize(80, 1)
+		line.Update(progress.Status{Attempt: 2, MaxAttempts: 4, RetriedTests: 5})
+		line.Stop()
+
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("doesn't draw the status line if the size of the terminal is unknown", func() {
+		line.Stop()
+		sizeErr = errors.New("not a terminal")
+
+		out = new(terminal)
+		line = progress.New(out, size, false)
+		line.Update(progress.Status{Attempt: 1, MaxAttempts: 1})
+		line.Stop()
+
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("does nothing if nil", func() {
+		var nilLine *progress.Line
+
+		nilLine.Update(progress.Status{})
+		nilLine.Stop()
+	})
+})
@@ -99,6 +99,32 @@ func Merge(into Provider, from Provider) Provider {
 	return from
 }
 
+// WithPartitionNodes returns a copy of the provider that runs the given partition. The partition is also exposed via
+// the job tags, which allows telling apart the different partitions of a test suite run.
+func (p Provider) WithPartitionNodes(nodes config.PartitionNodes) Provider {
+	p = p.WithJobTags(map[string]any{
+		"captain_partition_index": nodes.Index,
+		"captain_partition_total": nodes.Total,
+	})
+	p.PartitionNodes = nodes
+	return p
+}
+
+// WithJobTags returns a copy of the provider with additional job tags. Existing tags with the same key are overwritten.
+func (p Provider) WithJobTags(tags map[string]any) Provider {
+	jobTags := make(map[string]any, len(p.JobTags)+len(tags))
+	for key, value := range p.JobTags {
+		jobTags[key] = value
+	}
+
+	for key, value := range tags {
+		jobTags[key] = value
+	}
+
+	p.JobTags = jobTags
+	return p
+}
+
 // return the first non-empty string
 func firstNonempty(strs ...string) string {
 	for _, str := range strs {
@@ -420,3 +420,21 @@ var _ = Describe("MakeProvider", func() {
 		})
 	})
 })
+
+var _ = Describe("Provider.WithPartitionNodes", func() {
+	It("sets the partition and exposes it in the job tags", func() {
+		provider := providers.Provider{
+			JobTags: map[string]any{"captain_build_url": "https://ci.example.com/1"},
+		}
+
+		partitioned := provider.WithPartitionNodes(config.PartitionNodes{Index: 5, Total: 6})
+
+		Expect(partitioned.PartitionNodes).To(Equal(config.PartitionNodes{Index: 5, Total: 6}))
+		Expect(partitioned.JobTags).To(Equal(map[string]any{
+			"captain_build_url":       "https://ci.example.com/1",
+			"captain_partition_index": 5,
+			"captain_partition_total": 6,
+		}))
+		Expect(provider.JobTags).To(HaveLen(1))
+	})
+})
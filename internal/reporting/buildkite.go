@@ -0,0 +1,37 @@
+package reporting
+
+import (
+	"github.com/rwx-research/captain-cli/internal/fs"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// WriteBuildkiteAnnotation writes the body of a Buildkite annotation, which can be passed to
+// `buildkite-agent annotate`. Buildkite renders annotations as markdown & has the same size limit as GitHub step
+// summaries, which is why this is the markdown summary.
+func WriteBuildkiteAnnotation(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	return WriteMarkdownSummary(file, testResults, cfg)
+}
+
+// BuildkiteAnnotationStyle returns the style of the Buildkite annotation for the test results. It's "error" if any
+// tests failed, "warning" if there are flaky or quarantined tests, and "success" otherwise.
+func BuildkiteAnnotationStyle(testResults v1.TestResults) string {
+	testsBySection := testsByMarkdownSection(testResults)
+
+	for _, section := range []markdownTestSection{failedSection, timedOutSection, canceledSection} {
+		if len(testsBySection[section]) > 0 {
+			return "error"
+		}
+	}
+
+	if testResults.Summary.OtherErrors > 0 {
+		return "error"
+	}
+
+	for _, section := range []markdownTestSection{flakySection, quarantinedSection} {
+		if len(testsBySection[section]) > 0 {
+			return "warning"
+		}
+	}
+
+	return "success"
+}
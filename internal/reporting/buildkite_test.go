@@ -0,0 +1,35 @@
+package reporting_test
+
+import (
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Buildkite Annotation", func() {
+	resultsWith := func(tests ...v1.Test) v1.TestResults {
+		return *v1.NewTestResults(v1.RubyRSpecFramework, tests, nil)
+	}
+
+	successful := v1.Test{Name: "successful", Attempt: v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()}}
+	failed := v1.Test{Name: "failed", Attempt: v1.TestAttempt{Status: v1.NewFailedTestStatus(nil, nil, nil)}}
+	flaky := v1.Test{
+		Name:         "flaky",
+		Attempt:      v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+		PastAttempts: []v1.TestAttempt{{Status: v1.NewFailedTestStatus(nil, nil, nil)}},
+	}
+
+	It("uses the error style if tests failed", func() {
+		Expect(reporting.BuildkiteAnnotationStyle(resultsWith(successful, flaky, failed))).To(Equal("error"))
+	})
+
+	It("uses the warning style if tests are flaky", func() {
+		Expect(reporting.BuildkiteAnnotationStyle(resultsWith(successful, flaky))).To(Equal("warning"))
+	})
+
+	It("uses the success style otherwise", func() {
+		Expect(reporting.BuildkiteAnnotationStyle(resultsWith(successful))).To(Equal("success"))
+	})
+})
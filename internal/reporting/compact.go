@@ -0,0 +1,85 @@
+package reporting
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	// compactSummaryLimit is the number of tests that are listed per section of a compact summary
+	compactSummaryLimit = 10
+
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+)
+
+var compactSummaryColors = map[markdownTestSection]string{
+	flakySection:       "\x1b[33m",
+	failedSection:      "\x1b[31m",
+	timedOutSection:    "\x1b[31m",
+	quarantinedSection: "\x1b[36m",
+	canceledSection:    "\x1b[90m",
+}
+
+// WriteCompactSummary writes a short summary of the test results that's meant to be read in a terminal. Tests are
+// grouped in the same sections as the markdown summary, and sections are colored if `color` is true.
+func WriteCompactSummary(w io.Writer, testResults v1.TestResults, color bool) error {
+	summary := new(strings.Builder)
+
+	if _, err := summary.WriteString("\n"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := writeMarkdownSummaryLine(summary, testResults); err != nil {
+		return errors.WithStack(err)
+	}
+
+	testsBySection := testsByMarkdownSection(testResults)
+
+	for _, section := range orderedMarkdownSections {
+		tests := testsBySection[section]
+		if len(tests) == 0 {
+			continue
+		}
+
+		heading := fmt.Sprintf("%v (%v)", section, len(tests))
+		if color {
+			heading = compactSummaryColors[section] + ansiBold + heading + ansiReset
+		}
+
+		if _, err := summary.WriteString(fmt.Sprintf("\n%v\n", heading)); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for i, test := range tests {
+			if i == compactSummaryLimit {
+				if _, err := summary.WriteString(fmt.Sprintf("  … and %v more\n", len(tests)-i)); err != nil {
+					return errors.WithStack(err)
+				}
+				break
+			}
+
+			if _, err := summary.WriteString(fmt.Sprintf("  - %v\n", compactSummaryTestName(test))); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, summary.String()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func compactSummaryTestName(test v1.Test) string {
+	if test.Location == nil {
+		return test.Name
+	}
+
+	return fmt.Sprintf("%v (%v)", test.Name, test.Location.String())
+}
@@ -0,0 +1,75 @@
+package reporting_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compact Summary", func() {
+	var (
+		output      *strings.Builder
+		testResults v1.TestResults
+	)
+
+	BeforeEach(func() {
+		output = new(strings.Builder)
+
+		message := "expected true to equal false"
+		failed := v1.NewFailedTestStatus(&message, nil, nil)
+
+		testResults = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				{Name: "passes", Attempt: v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()}},
+				{
+					Name:         "is flaky",
+					Location:     &v1.Location{File: "./spec/foo_spec.rb"},
+					Attempt:      v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+					PastAttempts: []v1.TestAttempt{{Status: failed}},
+				},
+				{Name: "fails", Attempt: v1.TestAttempt{Status: failed}},
+				{Name: "is quarantined", Attempt: v1.TestAttempt{Status: v1.NewQuarantinedTestStatus(failed)}},
+			},
+			nil,
+		)
+	})
+
+	It("groups tests like the markdown summary", func() {
+		Expect(reporting.WriteCompactSummary(output, testResults, false)).To(Succeed())
+
+		Expect(output.String()).To(Equal(
+			"\n4 tests, 1 flaky, 1 failed, 1 quarantined, 1 retry\n" +
+				"\n🔁 Flaky (1)\n  - is flaky (./spec/foo_spec.rb)\n" +
+				"\n❌ Failed (1)\n  - fails\n" +
+				"\n🏥 Quarantined (1)\n  - is quarantined\n",
+		))
+	})
+
+	It("colors the sections if requested", func() {
+		Expect(reporting.WriteCompactSummary(output, testResults, true)).To(Succeed())
+
+		Expect(output.String()).To(ContainSubstring("\x1b[31m\x1b[1m❌ Failed (1)\x1b[0m\n"))
+	})
+
+	It("limits the number of tests per section", func() {
+		tests := make([]v1.Test, 12)
+		for i := range tests {
+			tests[i] = v1.Test{
+				Name:    fmt.Sprintf("fails %v", i),
+				Attempt: v1.TestAttempt{Status: v1.NewFailedTestStatus(nil, nil, nil)},
+			}
+		}
+		testResults = *v1.NewTestResults(v1.RubyRSpecFramework, tests, nil)
+
+		Expect(reporting.WriteCompactSummary(output, testResults, false)).To(Succeed())
+
+		Expect(output.String()).To(ContainSubstring("  - fails 9\n  … and 2 more\n"))
+		Expect(output.String()).NotTo(ContainSubstring("fails 10"))
+	})
+})
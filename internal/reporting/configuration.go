@@ -1,6 +1,12 @@
 package reporting
 
-import "github.com/rwx-research/captain-cli/internal/providers"
+import (
+	"fmt"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/providers"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
 
 type Configuration struct {
 	CloudEnabled         bool
@@ -8,4 +14,34 @@ type Configuration struct {
 	SuiteID              string
 	RetryCommandTemplate string
 	Provider             providers.Provider
+	// Baseline are the test results of a previous run to compare against. It's nil if there are none.
+	Baseline *v1.TestResults
+	// Timings are the stored durations of test files to detect duration regressions with. It's nil if there are none.
+	Timings map[string]time.Duration
+	// DurationRegressionThreshold is how much longer than before (in percent) a test or test file needs to take in
+	// order to count as slower. Zero means DefaultDurationRegressionThreshold.
+	DurationRegressionThreshold float64
+}
+
+func (c Configuration) durationRegressionThreshold() float64 {
+	if c.DurationRegressionThreshold <= 0 {
+		return DefaultDurationRegressionThreshold
+	}
+
+	return c.DurationRegressionThreshold
+}
+
+// cloudURL returns the link to the test results in Captain Cloud, or an empty string if Captain Cloud isn't used.
+func cloudURL(cfg Configuration) string {
+	if !cfg.CloudEnabled {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"https://%v/captain/deep_link/test_suite_summaries/%v/%v/%v",
+		cfg.CloudHost,
+		cfg.SuiteID,
+		cfg.Provider.BranchName,
+		cfg.Provider.CommitSha,
+	)
 }
@@ -0,0 +1,178 @@
+package reporting
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	"github.com/rwx-research/captain-cli/internal/parsing"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// WriteCTRFSummary writes the test results in the Common Test Report Format, see https://ctrf.io
+func WriteCTRFSummary(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	summary := new(parsing.CTRFSummary)
+	report := parsing.CTRFReport{
+		ReportFormat: "CTRF",
+		SpecVersion:  "0.0.0",
+		Results: &parsing.CTRFResults{
+			Tool:    parsing.CTRFTool{Name: string(testResults.Framework.Kind)},
+			Summary: summary,
+			Tests:   make([]parsing.CTRFTest, 0, len(testResults.Tests)+len(testResults.OtherErrors)),
+		},
+	}
+
+	if testResults.Framework.IsOther() && testResults.Framework.ProvidedKind != nil {
+		report.Results.Tool.Name = *testResults.Framework.ProvidedKind
+	}
+
+	if cfg.Provider.BranchName != "" || cfg.Provider.CommitSha != "" {
+		report.Results.Environment = &parsing.CTRFEnvironment{
+			BranchName: cfg.Provider.BranchName,
+			Commit:     cfg.Provider.CommitSha,
+		}
+	}
+
+	for _, test := range testResults.Tests {
+		ctrfTest := newCTRFTest(test)
+
+		switch ctrfTest.Status {
+		case parsing.CTRFStatusPassed:
+			summary.Passed++
+		case parsing.CTRFStatusFailed:
+			summary.Failed++
+		case parsing.CTRFStatusPending:
+			summary.Pending++
+		case parsing.CTRFStatusSkipped:
+			summary.Skipped++
+		default:
+			summary.Other++
+		}
+
+		if ctrfTest.Start > 0 && (summary.Start == 0 || ctrfTest.Start < summary.Start) {
+			summary.Start = ctrfTest.Start
+		}
+
+		if ctrfTest.Stop > summary.Stop {
+			summary.Stop = ctrfTest.Stop
+		}
+
+		report.Results.Tests = append(report.Results.Tests, ctrfTest)
+	}
+
+	// CTRF has no notion of errors outside of tests. They are reported as failed tests to make sure that they aren't
+	// lost, and marked by their raw status so that Captain can tell them apart when parsing the report.
+	for _, otherError := range testResults.OtherErrors {
+		ctrfTest := parsing.CTRFTest{
+			Name:      otherError.Message,
+			Status:    parsing.CTRFStatusFailed,
+			Message:   otherError.Message,
+			Trace:     strings.Join(otherError.Backtrace, "\n"),
+			RawStatus: parsing.CTRFRawStatusOtherError,
+		}
+
+		if otherError.Location != nil {
+			ctrfTest.FilePath = otherError.Location.File
+			ctrfTest.Line = otherError.Location.Line
+		}
+
+		summary.Failed++
+		report.Results.Tests = append(report.Results.Tests, ctrfTest)
+	}
+
+	summary.Tests = len(report.Results.Tests)
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func newCTRFTest(test v1.Test) parsing.CTRFTest {
+	ctrfTest := parsing.CTRFTest{
+		Name:      test.Name,
+		Status:    ctrfStatusOf(test.Attempt.Status),
+		RawStatus: string(test.Attempt.Status.Kind),
+		Retries:   len(test.PastAttempts),
+		Flaky:     test.Flaky(),
+		Stdout:    ctrfOutput(test.Attempt.Stdout),
+		Stderr:    ctrfOutput(test.Attempt.Stderr),
+	}
+
+	if test.Attempt.Duration != nil {
+		ctrfTest.Duration = float64(*test.Attempt.Duration) / float64(time.Millisecond)
+	}
+
+	if test.Attempt.StartedAt != nil {
+		ctrfTest.Start = test.Attempt.StartedAt.UnixMilli()
+	}
+
+	if test.Attempt.FinishedAt != nil {
+		ctrfTest.Stop = test.Attempt.FinishedAt.UnixMilli()
+	}
+
+	if len(test.Lineage) > 1 {
+		ctrfTest.Suite = test.Lineage[:len(test.Lineage)-1]
+	}
+
+	if test.Location != nil {
+		ctrfTest.FilePath = test.Location.File
+		ctrfTest.Line = test.Location.Line
+	}
+
+	// The message & trace of flaky tests are the ones of the attempt that failed
+	status := test.Attempt.Status
+	if status.OriginalStatus != nil {
+		status = *status.OriginalStatus
+	}
+
+	if ctrfTest.Flaky {
+		for _, attempt := range test.PastAttempts {
+			if attempt.Status.PotentiallyFlaky() {
+				status = attempt.Status
+				break
+			}
+		}
+	}
+
+	if status.Message != nil {
+		ctrfTest.Message = *status.Message
+	}
+
+	ctrfTest.Trace = strings.Join(status.Backtrace, "\n")
+
+	return ctrfTest
+}
+
+func ctrfStatusOf(status v1.TestStatus) string {
+	switch status.Kind {
+	case v1.TestStatusSuccessful:
+		return parsing.CTRFStatusPassed
+	case v1.TestStatusFailed, v1.TestStatusCanceled, v1.TestStatusTimedOut:
+		return parsing.CTRFStatusFailed
+	case v1.TestStatusPended, v1.TestStatusTodo:
+		return parsing.CTRFStatusPending
+	case v1.TestStatusSkipped:
+		return parsing.CTRFStatusSkipped
+	case v1.TestStatusQuarantined:
+		// Quarantined tests failed, but don't fail the build
+		return parsing.CTRFStatusOther
+	}
+
+	return parsing.CTRFStatusOther
+}
+
+func ctrfOutput(output *string) []string {
+	if output == nil || *output == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(*output, "\n"), "\n")
+}
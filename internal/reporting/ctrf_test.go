@@ -0,0 +1,124 @@
+package reporting_test
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/parsing"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CTRF Report", func() {
+	var (
+		mockFile    *mocks.File
+		testResults v1.TestResults
+	)
+
+	BeforeEach(func() {
+		mockFile = new(mocks.File)
+		mockFile.Builder = new(strings.Builder)
+
+		message := "expected true to equal false"
+		line := 12
+		duration := 1500 * time.Millisecond
+		startedAt := time.Date(2022, 10, 3, 12, 0, 0, 0, time.UTC)
+		finishedAt := startedAt.Add(duration)
+		stdout := "some\noutput\n"
+
+		testResults = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				{
+					Name:     "Foo bar is flaky",
+					Lineage:  []string{"Foo", "bar", "is flaky"},
+					Location: &v1.Location{File: "./spec/foo_spec.rb", Line: &line},
+					Attempt: v1.TestAttempt{
+						Duration:   &duration,
+						StartedAt:  &startedAt,
+						FinishedAt: &finishedAt,
+						Stdout:     &stdout,
+						Status:     v1.NewSuccessfulTestStatus(),
+					},
+					PastAttempts: []v1.TestAttempt{
+						{Status: v1.NewFailedTestStatus(&message, nil, []string{"a.rb:1", "b.rb:2"})},
+					},
+				},
+				{
+					Name:    "times out",
+					Attempt: v1.TestAttempt{Status: v1.NewTimedOutTestStatus()},
+				},
+				{
+					Name:    "is quarantined",
+					Attempt: v1.TestAttempt{Status: v1.NewQuarantinedTestStatus(v1.NewFailedTestStatus(&message, nil, nil))},
+				},
+				{
+					Name:    "is todo",
+					Attempt: v1.TestAttempt{Status: v1.NewTodoTestStatus(nil)},
+				},
+			},
+			[]v1.OtherError{{Message: "syntax error"}},
+		)
+	})
+
+	It("maps the test results to CTRF", func() {
+		var report parsing.CTRFReport
+
+		Expect(reporting.WriteCTRFSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+		Expect(json.Unmarshal([]byte(mockFile.Builder.String()), &report)).To(Succeed())
+
+		Expect(report.ReportFormat).To(Equal("CTRF"))
+		Expect(report.Results.Tool.Name).To(Equal("RSpec"))
+		Expect(*report.Results.Summary).To(Equal(parsing.CTRFSummary{
+			Tests:   5,
+			Passed:  1,
+			Failed:  2,
+			Pending: 1,
+			Other:   1,
+			Start:   1664798400000,
+			Stop:    1664798401500,
+		}))
+
+		flaky := report.Results.Tests[0]
+		Expect(flaky.Status).To(Equal("passed"))
+		Expect(flaky.Duration).To(Equal(1500.0))
+		Expect(flaky.Suite).To(Equal(parsing.CTRFSuite{"Foo", "bar"}))
+		Expect(flaky.FilePath).To(Equal("./spec/foo_spec.rb"))
+		Expect(*flaky.Line).To(Equal(12))
+		Expect(flaky.Retries).To(Equal(1))
+		Expect(flaky.Flaky).To(BeTrue())
+		Expect(flaky.Message).To(Equal("expected true to equal false"))
+		Expect(flaky.Trace).To(Equal("a.rb:1\nb.rb:2"))
+		Expect(flaky.Stdout).To(Equal([]string{"some", "output"}))
+
+		Expect(report.Results.Tests[1].Status).To(Equal("failed"))
+		Expect(report.Results.Tests[1].RawStatus).To(Equal("timedOut"))
+		Expect(report.Results.Tests[2].Status).To(Equal("other"))
+		Expect(report.Results.Tests[2].RawStatus).To(Equal("quarantined"))
+		Expect(report.Results.Tests[4].RawStatus).To(Equal("otherError"))
+	})
+
+	It("writes suites as strings", func() {
+		Expect(reporting.WriteCTRFSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+		Expect(mockFile.Builder.String()).To(ContainSubstring(`"suite": "Foo > bar"`))
+	})
+
+	It("can be parsed by Captain", func() {
+		Expect(reporting.WriteCTRFSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+
+		parsedResults, err := parsing.CTRFParser{}.Parse(strings.NewReader(mockFile.Builder.String()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsedResults.Framework).To(Equal(v1.RubyRSpecFramework))
+		Expect(parsedResults.Tests).To(HaveLen(4))
+		Expect(parsedResults.OtherErrors).To(HaveLen(1))
+		Expect(parsedResults.Tests[0].Flaky()).To(BeTrue())
+		Expect(parsedResults.Tests[1].Attempt.Status.Kind).To(Equal(v1.TestStatusTimedOut))
+		Expect(parsedResults.Tests[2].Attempt.Status.Kind).To(Equal(v1.TestStatusFailed))
+		Expect(parsedResults.Tests[3].Attempt.Status.Kind).To(Equal(v1.TestStatusTodo))
+	})
+})
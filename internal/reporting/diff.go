@@ -0,0 +1,376 @@
+package reporting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	// DefaultDurationRegressionThreshold is how much longer than before (in percent) a test needs to take in order to
+	// count as slower.
+	DefaultDurationRegressionThreshold = 50.0
+
+	// DurationRegressionMinIncrease is how much longer than before a test needs to take in absolute terms in order to
+	// count as slower, regardless of the threshold. This keeps noise from fast tests out.
+	DurationRegressionMinIncrease = time.Second
+
+	markdownDiffNoBaseline = "No baseline to compare against was found. Captain stores the results of every run in " +
+		"OSS mode, which means that a diff will be available from the next run onwards.\n"
+)
+
+var (
+	newlyFailingSection markdownTestSection = "❌ Newly Failing"
+	newlyFlakySection   markdownTestSection = "🔁 Newly Flaky"
+	newlyPassingSection markdownTestSection = "✅ Newly Passing"
+	addedSection        markdownTestSection = "➕ Added"
+	removedSection      markdownTestSection = "➖ Removed"
+	slowerSection       markdownTestSection = "🐢 Slower"
+)
+
+// TestResultsDiff describes how the tests of two runs differ.
+type TestResultsDiff struct {
+	// NewlyFailing are tests that failed, but didn't fail in the base run
+	NewlyFailing []v1.Test
+	// NewlyPassing are tests that passed, but failed in the base run
+	NewlyPassing []v1.Test
+	// NewlyFlaky are tests that were flaky, but weren't flaky in the base run
+	NewlyFlaky []v1.Test
+	// Added are tests that weren't part of the base run
+	Added []v1.Test
+	// Removed are tests of the base run that are missing
+	Removed []v1.Test
+	// DurationRegressions are tests that took considerably longer than in the base run, slowest first
+	DurationRegressions []DurationRegression
+}
+
+// DurationRegression is a test that took considerably longer than in the base run.
+type DurationRegression struct {
+	Test   v1.Test
+	Before time.Duration
+	After  time.Duration
+}
+
+// Empty returns whether there are no differences.
+func (d TestResultsDiff) Empty() bool {
+	return len(d.NewlyFailing) == 0 && len(d.NewlyPassing) == 0 && len(d.NewlyFlaky) == 0 && len(d.Added) == 0 &&
+		len(d.Removed) == 0 && len(d.DurationRegressions) == 0
+}
+
+// DiffTestResults compares the tests of `head` to the ones of `base`. Tests are matched using `v1.Test.Matches` unless
+// identity components are given (see `v1.Test.Identify`).
+func DiffTestResults(base, head v1.TestResults, identityComponents []string) TestResultsDiff {
+	return diffTestResults(base, head, identityComponents, DefaultDurationRegressionThreshold)
+}
+
+func diffTestResults(base, head v1.TestResults, identityComponents []string, threshold float64) TestResultsDiff {
+	diff := TestResultsDiff{
+		NewlyFailing:        make([]v1.Test, 0),
+		NewlyPassing:        make([]v1.Test, 0),
+		NewlyFlaky:          make([]v1.Test, 0),
+		Added:               make([]v1.Test, 0),
+		Removed:             make([]v1.Test, 0),
+		DurationRegressions: make([]DurationRegression, 0),
+	}
+
+	// Tests are bucketed by a key first, so that we don't need to compare every test to every other test. Tests that
+	// lack one of the identity components can't be matched at all, which is why they end up as added or removed.
+	keyOf := func(test v1.Test) (string, bool) {
+		if len(identityComponents) == 0 {
+			return test.Name, true
+		}
+
+		key, err := test.Identify(identityComponents, true)
+		return key, err == nil
+	}
+
+	baseTestsByKey := make(map[string][]int)
+	for i, test := range base.Tests {
+		if key, ok := keyOf(test); ok {
+			baseTestsByKey[key] = append(baseTestsByKey[key], i)
+		}
+	}
+
+	matchedBaseTests := make([]bool, len(base.Tests))
+
+	for _, headTest := range head.Tests {
+		baseIndex := -1
+		key, ok := keyOf(headTest)
+		if !ok {
+			diff.Added = append(diff.Added, headTest)
+			continue
+		}
+
+		for _, i := range baseTestsByKey[key] {
+			if matchedBaseTests[i] {
+				continue
+			}
+
+			if len(identityComponents) == 0 && !base.Tests[i].Matches(headTest) {
+				continue
+			}
+
+			baseIndex = i
+			break
+		}
+
+		if baseIndex == -1 {
+			diff.Added = append(diff.Added, headTest)
+			continue
+		}
+
+		matchedBaseTests[baseIndex] = true
+		baseTest := base.Tests[baseIndex]
+
+		switch {
+		case headTest.Attempt.Status.ImpliesFailure() && !baseTest.Attempt.Status.ImpliesFailure():
+			diff.NewlyFailing = append(diff.NewlyFailing, headTest)
+		case headTest.Flaky() && !baseTest.Flaky():
+			diff.NewlyFlaky = append(diff.NewlyFlaky, headTest)
+		case headTest.Attempt.Status.Kind == v1.TestStatusSuccessful && baseTest.Attempt.Status.ImpliesFailure():
+			diff.NewlyPassing = append(diff.NewlyPassing, headTest)
+		}
+
+		if regression, ok := durationRegressionOf(baseTest, headTest, threshold); ok {
+			diff.DurationRegressions = append(diff.DurationRegressions, regression)
+		}
+	}
+
+	for i, baseTest := range base.Tests {
+		if !matchedBaseTests[i] {
+			diff.Removed = append(diff.Removed, baseTest)
+		}
+	}
+
+	sort.SliceStable(diff.DurationRegressions, func(i, j int) bool {
+		a, b := diff.DurationRegressions[i], diff.DurationRegressions[j]
+		return a.After-a.Before > b.After-b.Before
+	})
+
+	return diff
+}
+
+func durationRegressionOf(baseTest, headTest v1.Test, threshold float64) (DurationRegression, bool) {
+	if baseTest.Attempt.Status.ImpliesSkipped() || headTest.Attempt.Status.ImpliesSkipped() {
+		return DurationRegression{}, false
+	}
+
+	before, baseOK := durationOf(baseTest)
+	after, headOK := durationOf(headTest)
+	if !baseOK || !headOK || !isDurationRegression(before, after, threshold) {
+		return DurationRegression{}, false
+	}
+
+	return DurationRegression{Test: headTest, Before: before, After: after}, true
+}
+
+// durationOf returns the duration of the final attempt of a test. Retries are deliberately left out, as they would
+// make tests look slower whenever they flake. It returns false if the duration wasn't reported.
+func durationOf(test v1.Test) (time.Duration, bool) {
+	if test.Attempt.Duration == nil {
+		return 0, false
+	}
+
+	return *test.Attempt.Duration, true
+}
+
+// isDurationRegression returns whether `after` is more than `threshold` percent and DurationRegressionMinIncrease
+// longer than `before`.
+func isDurationRegression(before, after time.Duration, threshold float64) bool {
+	return after-before >= DurationRegressionMinIncrease && float64(after) >= float64(before)*(1+threshold/100)
+}
+
+// WriteDiffMarkdownSummary writes a markdown summary of how the test results differ from the baseline, i.e. the
+// stored results of the previous run.
+func WriteDiffMarkdownSummary(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	var markdown string
+
+	if cfg.Baseline == nil {
+		markdown = fmt.Sprintf("%v\n%v", markdownDiffHeader(cfg), markdownDiffNoBaseline)
+	} else {
+		var err error
+		markdown, err = DiffMarkdown(*cfg.Baseline, testResults, nil, cfg)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if _, err := file.Write([]byte(markdown)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// DiffMarkdown renders the differences between two test results as markdown. The identity components are optional,
+// see `DiffTestResults`.
+func DiffMarkdown(base, head v1.TestResults, identityComponents []string, cfg Configuration) (string, error) {
+	diff := diffTestResults(base, head, identityComponents, cfg.durationRegressionThreshold())
+
+	markdown := new(strings.Builder)
+	if _, err := markdown.WriteString(markdownDiffHeader(cfg)); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := writeMarkdownDiffSummaryLine(markdown, diff); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	writers := []func() (bool, error){
+		func() (bool, error) {
+			return writeMarkdownSection(
+				markdown,
+				newlyFailingSection,
+				head.Framework,
+				diff.NewlyFailing,
+				func(test v1.Test) *v1.TestStatus { return &test.Attempt.Status },
+				cfg,
+			)
+		},
+		func() (bool, error) {
+			return writeMarkdownSection(markdown, newlyFlakySection, head.Framework, diff.NewlyFlaky, flakyStatusOf, cfg)
+		},
+		func() (bool, error) {
+			return writeMarkdownDiffList(markdown, newlyPassingSection, diff.NewlyPassing)
+		},
+		func() (bool, error) {
+			return writeMarkdownDiffList(markdown, addedSection, diff.Added)
+		},
+		func() (bool, error) {
+			return writeMarkdownDiffList(markdown, removedSection, diff.Removed)
+		},
+		func() (bool, error) {
+			return writeMarkdownDurationRegressions(markdown, diff.DurationRegressions)
+		},
+	}
+
+	for _, write := range writers {
+		shouldTruncate, err := write()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		if shouldTruncate {
+			if _, err := markdown.WriteString(markdownResultsTruncated); err != nil {
+				return "", errors.WithStack(err)
+			}
+			break
+		}
+	}
+
+	return markdown.String(), nil
+}
+
+func markdownDiffHeader(cfg Configuration) string {
+	if cfg.SuiteID == "" {
+		return "# Test Results Diff\n\n"
+	}
+
+	return fmt.Sprintf("# `%v` Diff\n\n", cfg.SuiteID)
+}
+
+func writeMarkdownDiffSummaryLine(markdown *strings.Builder, diff TestResultsDiff) error {
+	if diff.Empty() {
+		if _, err := markdown.WriteString("No differences to the baseline\n"); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}
+
+	counts := []struct {
+		value int
+		label string
+	}{
+		{len(diff.NewlyFailing), "newly failing"},
+		{len(diff.NewlyFlaky), "newly flaky"},
+		{len(diff.NewlyPassing), "newly passing"},
+		{len(diff.Added), "added"},
+		{len(diff.Removed), "removed"},
+		{len(diff.DurationRegressions), "slower"},
+	}
+
+	parts := make([]string, 0, len(counts))
+	for _, count := range counts {
+		if count.value > 0 {
+			parts = append(parts, fmt.Sprintf("%v %v", count.value, count.label))
+		}
+	}
+
+	if _, err := markdown.WriteString(strings.Join(parts, ", ") + "\n"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func writeMarkdownDiffList(markdown *strings.Builder, section markdownTestSection, tests []v1.Test) (bool, error) {
+	if len(tests) == 0 {
+		return false, nil
+	}
+
+	if _, err := markdown.WriteString(fmt.Sprintf("\n## %v\n\n", section)); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	for _, test := range tests {
+		line := fmt.Sprintf("- %v\n", markdownDiffTestName(test))
+		if test.Attempt.Status.Kind != v1.TestStatusSuccessful && section != newlyPassingSection {
+			line = fmt.Sprintf("- %v (%v)\n", markdownDiffTestName(test), test.Attempt.Status.Kind)
+		}
+
+		if oneMB-markdown.Len()-len(line)-len(markdownResultsTruncated) <= 0 {
+			return true, nil
+		}
+
+		if _, err := markdown.WriteString(line); err != nil {
+			return false, errors.WithStack(err)
+		}
+	}
+
+	return false, nil
+}
+
+func writeMarkdownDurationRegressions(markdown *strings.Builder, regressions []DurationRegression) (bool, error) {
+	if len(regressions) == 0 {
+		return false, nil
+	}
+
+	if _, err := markdown.WriteString(
+		fmt.Sprintf("\n## %v\n\n| Test | Before | After |\n| --- | --- | --- |\n", slowerSection),
+	); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	for _, regression := range regressions {
+		line := fmt.Sprintf(
+			"| %v | %v | %v |\n",
+			strings.ReplaceAll(markdownDiffTestName(regression.Test), "|", "\\|"),
+			regression.Before.Round(time.Millisecond),
+			regression.After.Round(time.Millisecond),
+		)
+
+		if oneMB-markdown.Len()-len(line)-len(markdownResultsTruncated) <= 0 {
+			return true, nil
+		}
+
+		if _, err := markdown.WriteString(line); err != nil {
+			return false, errors.WithStack(err)
+		}
+	}
+
+	return false, nil
+}
+
+func markdownDiffTestName(test v1.Test) string {
+	if test.Location == nil {
+		return test.Name
+	}
+
+	return fmt.Sprintf("%v (`%v`)", test.Name, test.Location.String())
+}
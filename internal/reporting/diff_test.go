@@ -0,0 +1,169 @@
+package reporting_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+	var (
+		base v1.TestResults
+		head v1.TestResults
+	)
+
+	test := func(name string, status v1.TestStatus, duration time.Duration, pastAttempts ...v1.TestAttempt) v1.Test {
+		id := "./spec/foo_spec.rb[" + name + "]"
+
+		return v1.Test{
+			ID:           &id,
+			Name:         name,
+			Location:     &v1.Location{File: "./spec/foo_spec.rb"},
+			Attempt:      v1.TestAttempt{Status: status, Duration: &duration},
+			PastAttempts: pastAttempts,
+		}
+	}
+
+	BeforeEach(func() {
+		message := "expected true to equal false"
+		failed := v1.NewFailedTestStatus(&message, nil, nil)
+		successful := v1.NewSuccessfulTestStatus()
+
+		base = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				test("starts failing", successful, time.Second),
+				test("gets fixed", failed, time.Second),
+				test("starts flaking", successful, time.Second),
+				test("gets slower", successful, time.Second),
+				test("gets a bit slower", successful, time.Second),
+				test("gets removed", successful, time.Second),
+				test("stays failing", failed, time.Second),
+			},
+			nil,
+		)
+
+		head = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				test("starts failing", failed, time.Second),
+				test("gets fixed", successful, time.Second),
+				test("starts flaking", successful, time.Second, v1.TestAttempt{Status: failed}),
+				test("gets slower", successful, 3*time.Second),
+				test("gets a bit slower", successful, 1200*time.Millisecond),
+				test("gets added", successful, time.Second),
+				test("stays failing", failed, time.Second),
+			},
+			nil,
+		)
+	})
+
+	Describe("DiffTestResults", func() {
+		It("categorizes the differences", func() {
+			diff := reporting.DiffTestResults(base, head, nil)
+
+			names := func(tests []v1.Test) []string {
+				result := make([]string, len(tests))
+				for i, test := range tests {
+					result[i] = test.Name
+				}
+				return result
+			}
+
+			Expect(names(diff.NewlyFailing)).To(Equal([]string{"starts failing"}))
+			Expect(names(diff.NewlyPassing)).To(Equal([]string{"gets fixed"}))
+			Expect(names(diff.NewlyFlaky)).To(Equal([]string{"starts flaking"}))
+			Expect(names(diff.Added)).To(Equal([]string{"gets added"}))
+			Expect(names(diff.Removed)).To(Equal([]string{"gets removed"}))
+			Expect(diff.DurationRegressions).To(HaveLen(1))
+			Expect(diff.DurationRegressions[0].Test.Name).To(Equal("gets slower"))
+			Expect(diff.DurationRegressions[0].Before).To(Equal(time.Second))
+			Expect(diff.DurationRegressions[0].After).To(Equal(3 * time.Second))
+		})
+
+		It("requires tests to match exactly by default", func() {
+			head.Tests[0].Location = &v1.Location{File: "./spec/bar_spec.rb"}
+
+			diff := reporting.DiffTestResults(base, head, nil)
+
+			Expect(diff.NewlyFailing).To(BeEmpty())
+			Expect(diff.Added).To(HaveLen(2))
+			Expect(diff.Removed).To(HaveLen(2))
+		})
+
+		It("matches tests by identity components if given", func() {
+			head.Tests[0].Location = &v1.Location{File: "./spec/foo_spec.rb", Line: new(int)}
+
+			diff := reporting.DiffTestResults(base, head, []string{"description", "file"})
+
+			Expect(diff.NewlyFailing).To(HaveLen(1))
+			Expect(diff.Added).To(HaveLen(1))
+			Expect(diff.Removed).To(HaveLen(1))
+		})
+
+		It("reports tests that lack an identity component as added or removed", func() {
+			base.Tests[0].Location = nil
+			head.Tests[0].Location = nil
+
+			diff := reporting.DiffTestResults(base, head, []string{"description", "file"})
+
+			Expect(diff.NewlyFailing).To(BeEmpty())
+			Expect(diff.Added).To(ContainElement(head.Tests[0]))
+			Expect(diff.Removed).To(ContainElement(base.Tests[0]))
+		})
+
+		It("reports no differences for identical results", func() {
+			Expect(reporting.DiffTestResults(head, head, nil).Empty()).To(BeTrue())
+		})
+	})
+
+	Describe("WriteDiffMarkdownSummary", func() {
+		var (
+			mockFile *mocks.File
+			cfg      reporting.Configuration
+		)
+
+		BeforeEach(func() {
+			mockFile = new(mocks.File)
+			mockFile.Builder = new(strings.Builder)
+			cfg = reporting.Configuration{SuiteID: "some-suite-id", Baseline: &base}
+		})
+
+		It("summarizes the differences", func() {
+			Expect(reporting.WriteDiffMarkdownSummary(mockFile, head, cfg)).To(Succeed())
+
+			markdown := mockFile.Builder.String()
+			Expect(markdown).To(HavePrefix(
+				"# `some-suite-id` Diff\n\n1 newly failing, 1 newly flaky, 1 newly passing, 1 added, 1 removed, 1 slower\n",
+			))
+			Expect(markdown).To(ContainSubstring("## ❌ Newly Failing"))
+			Expect(markdown).To(ContainSubstring("<summary><strong>starts failing</strong></summary>"))
+			Expect(markdown).To(ContainSubstring("## 🔁 Newly Flaky"))
+			Expect(markdown).To(ContainSubstring("## ✅ Newly Passing\n\n- gets fixed (`./spec/foo_spec.rb`)\n"))
+			Expect(markdown).To(ContainSubstring("## ➕ Added\n\n- gets added (`./spec/foo_spec.rb`)\n"))
+			Expect(markdown).To(ContainSubstring("## ➖ Removed\n\n- gets removed (`./spec/foo_spec.rb`)\n"))
+			Expect(markdown).To(ContainSubstring("| gets slower (`./spec/foo_spec.rb`) | 1s | 3s |"))
+			Expect(markdown).NotTo(ContainSubstring("stays failing"))
+		})
+
+		It("mentions that there are no differences", func() {
+			cfg.Baseline = &head
+
+			Expect(reporting.WriteDiffMarkdownSummary(mockFile, head, cfg)).To(Succeed())
+			Expect(mockFile.Builder.String()).To(Equal("# `some-suite-id` Diff\n\nNo differences to the baseline\n"))
+		})
+
+		It("mentions a missing baseline", func() {
+			cfg.Baseline = nil
+
+			Expect(reporting.WriteDiffMarkdownSummary(mockFile, head, cfg)).To(Succeed())
+			Expect(mockFile.Builder.String()).To(ContainSubstring("No baseline to compare against was found"))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package reporting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// gitLabCodeQualityIssue is a single entry of a GitLab code quality report, which is a subset of the Code Climate
+// specification. See https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
+type gitLabCodeQualityIssue struct {
+	Description string                    `json:"description"`
+	CheckName   string                    `json:"check_name"`
+	Fingerprint string                    `json:"fingerprint"`
+	Severity    string                    `json:"severity"`
+	Location    gitLabCodeQualityLocation `json:"location"`
+}
+
+type gitLabCodeQualityLocation struct {
+	Path  string                 `json:"path"`
+	Lines gitLabCodeQualityLines `json:"lines"`
+}
+
+type gitLabCodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// gitLabCodeQualitySections are the markdown sections that end up in the code quality report. Failing tests are
+// already part of the JUnit report, the code quality report highlights the ones that don't fail the build.
+var gitLabCodeQualitySections = []struct {
+	section   markdownTestSection
+	checkName string
+	severity  string
+	label     string
+}{
+	{section: flakySection, checkName: "captain-flaky-test", severity: "minor", label: "Flaky test"},
+	{section: quarantinedSection, checkName: "captain-quarantined-test", severity: "info", label: "Quarantined test"},
+}
+
+// WriteGitLabCodeQualityReport writes a GitLab code quality report that lists all flaky & quarantined tests. GitLab
+// displays these in the merge request widget when the file is uploaded as a `codequality` report artifact.
+func WriteGitLabCodeQualityReport(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	issues := make([]gitLabCodeQualityIssue, 0)
+	testsBySection := testsByMarkdownSection(testResults)
+
+	for _, section := range gitLabCodeQualitySections {
+		for _, test := range testsBySection[section.section] {
+			location := gitLabCodeQualityLocation{Path: ".", Lines: gitLabCodeQualityLines{Begin: 1}}
+			if test.Location != nil && test.Location.File != "" {
+				location.Path = test.Location.File
+				if test.Location.Line != nil {
+					location.Lines.Begin = *test.Location.Line
+				}
+			}
+
+			description := fmt.Sprintf("%s: %s", section.label, test.Name)
+			if retries := len(test.PastAttempts); retries > 0 {
+				description = fmt.Sprintf("%s (retried %d %s)", description, retries, pluralize(retries, "time", "times"))
+			}
+
+			// The fingerprint needs to be stable across builds so that GitLab can tell new issues from resolved ones
+			fingerprint := sha256.Sum256([]byte(fmt.Sprintf(
+				"%s\x00%s\x00%s\x00%s", cfg.SuiteID, section.checkName, location.Path, test.Name,
+			)))
+
+			issues = append(issues, gitLabCodeQualityIssue{
+				Description: description,
+				CheckName:   section.checkName,
+				Fingerprint: hex.EncodeToString(fingerprint[:]),
+				Severity:    section.severity,
+				Location:    location,
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(issues); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package reporting_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitLab Code Quality Report", func() {
+	var (
+		mockFile    *mocks.File
+		testResults v1.TestResults
+		cfg         reporting.Configuration
+	)
+
+	BeforeEach(func() {
+		mockFile = new(mocks.File)
+		mockFile.Builder = new(strings.Builder)
+
+		message := "expected true to equal false"
+		line := 12
+
+		testResults = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				{
+					Name:     "is flaky",
+					Location: &v1.Location{File: "./spec/foo_spec.rb", Line: &line},
+					Attempt:  v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+					PastAttempts: []v1.TestAttempt{
+						{Status: v1.NewFailedTestStatus(&message, nil, nil)},
+					},
+				},
+				{
+					Name:    "is quarantined",
+					Attempt: v1.TestAttempt{Status: v1.NewQuarantinedTestStatus(v1.NewFailedTestStatus(nil, nil, nil))},
+				},
+				{
+					Name:    "fails",
+					Attempt: v1.TestAttempt{Status: v1.NewFailedTestStatus(&message, nil, nil)},
+				},
+				{
+					Name:    "is successful",
+					Attempt: v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+				},
+			},
+			nil,
+		)
+
+		cfg = reporting.Configuration{SuiteID: "some-suite-id"}
+	})
+
+	It("lists flaky & quarantined tests", func() {
+		var issues []map[string]any
+
+		Expect(reporting.WriteGitLabCodeQualityReport(mockFile, testResults, cfg)).To(Succeed())
+		Expect(json.Unmarshal([]byte(mockFile.Builder.String()), &issues)).To(Succeed())
+		Expect(issues).To(HaveLen(2))
+
+		Expect(issues[0]["description"]).To(Equal("Flaky test: is flaky (retried 1 time)"))
+		Expect(issues[0]["check_name"]).To(Equal("captain-flaky-test"))
+		Expect(issues[0]["severity"]).To(Equal("minor"))
+		Expect(issues[0]["location"]).To(Equal(map[string]any{
+			"path":  "./spec/foo_spec.rb",
+			"lines": map[string]any{"begin": float64(12)},
+		}))
+
+		Expect(issues[1]["description"]).To(Equal("Quarantined test: is quarantined"))
+		Expect(issues[1]["severity"]).To(Equal("info"))
+		Expect(issues[1]["location"]).To(HaveKeyWithValue("path", "."))
+	})
+
+	It("produces stable fingerprints", func() {
+		Expect(reporting.WriteGitLabCodeQualityReport(mockFile, testResults, cfg)).To(Succeed())
+		first := mockFile.Builder.String()
+
+		mockFile.Builder.Reset()
+		Expect(reporting.WriteGitLabCodeQualityReport(mockFile, testResults, cfg)).To(Succeed())
+		Expect(mockFile.Builder.String()).To(Equal(first))
+		Expect(first).To(MatchRegexp(`"fingerprint": "[0-9a-f]{64}"`))
+	})
+
+	It("writes an empty list without flaky or quarantined tests", func() {
+		testResults = *v1.NewTestResults(v1.RubyRSpecFramework, nil, nil)
+
+		Expect(reporting.WriteGitLabCodeQualityReport(mockFile, testResults, cfg)).To(Succeed())
+		Expect(strings.TrimSpace(mockFile.Builder.String())).To(Equal("[]"))
+	})
+})
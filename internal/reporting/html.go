@@ -0,0 +1,364 @@
+package reporting
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+type htmlReport struct {
+	SuiteID  string
+	CloudURL string
+	Summary  string
+	Counts   []htmlCount
+	Root     *htmlGroup
+}
+
+type htmlCount struct {
+	Status string
+	Label  string
+	Count  int
+}
+
+// htmlGroup is a node of the lineage tree. Tests without a lineage are grouped by their file instead.
+type htmlGroup struct {
+	Name   string
+	Open   bool
+	Groups []*htmlGroup
+	Tests  []htmlTest
+}
+
+type htmlTest struct {
+	Name     string
+	Status   string
+	Location string
+	Duration string
+	Command  string
+	Attempts []htmlAttempt
+}
+
+type htmlAttempt struct {
+	Number      int
+	Status      string
+	Duration    string
+	Message     string
+	Backtrace   string
+	Stdout      string
+	Stderr      string
+	Attachments []htmlAttachment
+}
+
+type htmlAttachment struct {
+	Name string
+	Path string
+	// Inline is a data URL of attachments whose content is part of the test results
+	Inline template.URL
+	Image  bool
+}
+
+// htmlContentTypeRegexp matches content types that are safe to use in data URLs
+var htmlContentTypeRegexp = regexp.MustCompile(`^[\w.+-]+/[\w.+-]+$`)
+
+// htmlStatuses are the statuses that tests can be filtered by, in order of importance
+var htmlStatuses = []htmlCount{
+	{Status: "failed", Label: "Failed"},
+	{Status: "timedOut", Label: "Timed Out"},
+	{Status: "canceled", Label: "Canceled"},
+	{Status: "flaky", Label: "Flaky"},
+	{Status: "quarantined", Label: "Quarantined"},
+	{Status: "skipped", Label: "Skipped"},
+	{Status: "successful", Label: "Successful"},
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .SuiteID }} Summary</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 code { font-size: 0.9em; }
+pre { background: #f6f8fa; padding: 0.75em; overflow-x: auto; white-space: pre-wrap; }
+details { margin: 0.25em 0 0.25em 1em; }
+summary { cursor: pointer; }
+.filters button { margin: 0 0.25em 0.5em 0; padding: 0.25em 0.75em; cursor: pointer; }
+.filters button { border: 1px solid #d0d7de; border-radius: 1em; background: #fff; }
+.filters button.active { background: #1f2328; color: #fff; }
+.test { border-left: 4px solid #d0d7de; padding-left: 0.5em; }
+.test .meta { color: #656d76; font-size: 0.9em; }
+.status { display: inline-block; min-width: 6em; font-size: 0.8em; font-weight: bold; text-transform: uppercase; }
+[data-status="failed"], [data-status="timedOut"], [data-status="canceled"] { border-color: #cf222e; }
+[data-status="flaky"], [data-status="quarantined"] { border-color: #bf8700; }
+[data-status="successful"] { border-color: #1a7f37; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1><code>{{ .SuiteID }}</code> Summary</h1>
+{{ if .CloudURL }}<p><a href="{{ .CloudURL }}">View in Captain Cloud</a></p>{{ end }}
+<p>{{ .Summary }}</p>
+<div class="filters">
+<button class="active" data-filter="all">All</button>
+{{- range .Counts }}
+<button data-filter="{{ .Status }}">{{ .Label }} ({{ .Count }})</button>
+{{- end }}
+</div>
+{{ template "group" .Root }}
+<script>
+(function () {
+  var buttons = document.querySelectorAll(".filters button");
+  buttons.forEach(function (button) {
+    button.addEventListener("click", function () {
+      var filter = button.getAttribute("data-filter");
+      buttons.forEach(function (other) { other.classList.toggle("active", other === button); });
+      document.querySelectorAll(".test").forEach(function (test) {
+        test.classList.toggle("hidden", filter !== "all" && test.getAttribute("data-status") !== filter);
+      });
+      document.querySelectorAll(".group").forEach(function (group) {
+        group.classList.toggle("hidden", group.querySelector(".test:not(.hidden)") === null);
+        if (filter !== "all") { group.open = true; }
+      });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+{{ define "group" -}}
+{{ range .Groups }}
+<details class="group"{{ if .Open }} open{{ end }}>
+<summary>{{ .Name }}</summary>
+{{- template "group" . }}
+</details>
+{{- end }}
+{{- range .Tests }}
+<details class="test" data-status="{{ .Status }}">
+<summary>
+<span class="status">{{ .Status }}</span> {{ .Name }}
+{{- if .Duration }} <span class="meta">({{ .Duration }})</span>{{ end }}
+</summary>
+{{- if .Location }}
+<p class="meta">Defined at <code>{{ .Location }}</code></p>
+{{- end }}
+{{- if .Command }}
+<p class="meta">Retry with <code>{{ .Command }}</code></p>
+{{- end }}
+{{- range .Attempts }}
+<details class="attempt"{{ if or .Message .Backtrace }} open{{ end }}>
+<summary>
+Attempt {{ .Number }}: {{ .Status }}
+{{- if .Duration }} <span class="meta">({{ .Duration }})</span>{{ end }}
+</summary>
+{{- if .Message }}
+<pre>{{ .Message }}</pre>
+{{- end }}
+{{- if .Backtrace }}
+<pre>{{ .Backtrace }}</pre>
+{{- end }}
+{{- if .Stdout }}
+<p class="meta">stdout</p><pre>{{ .Stdout }}</pre>
+{{- end }}
+{{- if .Stderr }}
+<p class="meta">stderr</p><pre>{{ .Stderr }}</pre>
+{{- end }}
+{{- if .Attachments }}
+<p class="meta">attachments</p>
+<ul>
+{{- range .Attachments }}
+<li>
+{{- if .Path }}<a href="{{ .Path }}">{{ .Name }}</a>
+{{- else }}<a href="{{ .Inline }}" download="{{ .Name }}">{{ .Name }}</a>{{ end }}
+{{- if and .Image .Inline }}<br><img src="{{ .Inline }}" alt="{{ .Name }}">{{ end -}}
+</li>
+{{- end }}
+</ul>
+{{- end }}
+</details>
+{{- end }}
+</details>
+{{- end }}
+{{- end }}
+`
+
+// WriteHTMLSummary writes a self-contained HTML page that can be used to browse the test results, e.g. as a CI
+// artifact. It doesn't reference any external resources.
+func WriteHTMLSummary(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	parsedTemplate, err := template.New("htmlTemplate").Parse(htmlTemplate)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	report := htmlReport{
+		SuiteID: cfg.SuiteID,
+		Root:    new(htmlGroup),
+	}
+
+	summary := new(strings.Builder)
+	if err := writeMarkdownSummaryLine(summary, testResults); err != nil {
+		return errors.WithStack(err)
+	}
+	report.Summary = strings.TrimSpace(summary.String())
+
+	report.CloudURL = cloudURL(cfg)
+
+	counts := make(map[string]int)
+	retryTemplate, substitution := retryTemplateAndSubstitutionFor(testResults.Framework, cfg.RetryCommandTemplate)
+
+	for _, test := range testResults.Tests {
+		status := htmlStatusOf(test)
+		counts[status]++
+
+		htmlTest := htmlTest{
+			Name:     test.Name,
+			Status:   status,
+			Duration: htmlDuration(test.Attempt.Duration),
+			Attempts: make([]htmlAttempt, 0, len(test.PastAttempts)+1),
+		}
+
+		if test.Location != nil {
+			htmlTest.Location = test.Location.String()
+		}
+
+		if status != "successful" && status != "skipped" {
+			htmlTest.Command = retryCommandFor(testResults.Framework, retryTemplate, substitution, test)
+		}
+
+		for i, attempt := range append(append([]v1.TestAttempt{}, test.PastAttempts...), test.Attempt) {
+			htmlTest.Attempts = append(htmlTest.Attempts, newHTMLAttempt(i+1, attempt))
+		}
+
+		group := report.Root
+		for _, name := range htmlGroupPath(test) {
+			group = group.child(name)
+			if status != "successful" && status != "skipped" {
+				group.Open = true
+			}
+		}
+		group.Tests = append(group.Tests, htmlTest)
+	}
+
+	for _, count := range htmlStatuses {
+		if counts[count.Status] > 0 {
+			count.Count = counts[count.Status]
+			report.Counts = append(report.Counts, count)
+		}
+	}
+
+	if err := parsedTemplate.Execute(file, report); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (g *htmlGroup) child(name string) *htmlGroup {
+	for _, group := range g.Groups {
+		if group.Name == name {
+			return group
+		}
+	}
+
+	group := &htmlGroup{Name: name}
+	g.Groups = append(g.Groups, group)
+	return group
+}
+
+// htmlGroupPath returns the groups that a test is nested under. The last lineage component is the test itself.
+func htmlGroupPath(test v1.Test) []string {
+	if len(test.Lineage) > 1 {
+		return test.Lineage[:len(test.Lineage)-1]
+	}
+
+	if test.Location != nil && test.Location.File != "" {
+		return []string{test.Location.File}
+	}
+
+	return nil
+}
+
+func htmlStatusOf(test v1.Test) string {
+	switch {
+	case test.Flaky():
+		return "flaky"
+	case test.Attempt.Status.ImpliesSkipped():
+		return "skipped"
+	default:
+		return string(test.Attempt.Status.Kind)
+	}
+}
+
+func newHTMLAttempt(number int, attempt v1.TestAttempt) htmlAttempt {
+	status := attempt.Status
+	if status.Kind == v1.TestStatusQuarantined && status.OriginalStatus != nil {
+		status = *status.OriginalStatus
+	}
+
+	htmlAttempt := htmlAttempt{
+		Number:    number,
+		Status:    string(attempt.Status.Kind),
+		Duration:  htmlDuration(attempt.Duration),
+		Backtrace: stripansi.Strip(strings.Join(status.Backtrace, "\n")),
+	}
+
+	messages := make([]string, 0, 2)
+	if status.Exception != nil {
+		messages = append(messages, *status.Exception)
+	}
+	if status.Message != nil {
+		messages = append(messages, *status.Message)
+	}
+	htmlAttempt.Message = stripansi.Strip(strings.Join(messages, "\n\n"))
+
+	if attempt.Stdout != nil {
+		htmlAttempt.Stdout = stripansi.Strip(*attempt.Stdout)
+	}
+	if attempt.Stderr != nil {
+		htmlAttempt.Stderr = stripansi.Strip(*attempt.Stderr)
+	}
+
+	for _, attachment := range attempt.Attachments {
+		htmlAttachment := htmlAttachment{
+			Name:  attachment.Name,
+			Image: strings.HasPrefix(attachment.ContentType, "image/"),
+		}
+
+		if attachment.Path != nil {
+			htmlAttachment.Path = *attachment.Path
+		}
+
+		if attachment.Content != nil && htmlContentTypeRegexp.MatchString(attachment.ContentType) {
+			if _, err := base64.StdEncoding.DecodeString(*attachment.Content); err == nil {
+				// Both the content type & the content were validated, which makes the URL safe to use
+				htmlAttachment.Inline = template.URL( //nolint:gosec
+					fmt.Sprintf("data:%v;base64,%v", attachment.ContentType, *attachment.Content),
+				)
+			}
+		}
+
+		if htmlAttachment.Path == "" && htmlAttachment.Inline == "" {
+			continue
+		}
+
+		htmlAttempt.Attachments = append(htmlAttempt.Attachments, htmlAttachment)
+	}
+
+	return htmlAttempt
+}
+
+func htmlDuration(duration *time.Duration) string {
+	if duration == nil {
+		return ""
+	}
+
+	return duration.Round(time.Millisecond).String()
+}
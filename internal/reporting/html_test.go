@@ -0,0 +1,138 @@
+package reporting_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy"
+
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/providers"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTML Report", func() {
+	var (
+		mockFile    *mocks.File
+		testResults v1.TestResults
+		cfg         reporting.Configuration
+	)
+
+	BeforeEach(func() {
+		mockFile = new(mocks.File)
+		mockFile.Builder = new(strings.Builder)
+
+		id1 := "./spec/foo/bar.rb[1:1]"
+		id2 := "./spec/foo/bar.rb[1:2]"
+		id3 := "./spec/foo/bar.rb[1:3]"
+		id4 := "./spec/foo/baz.rb[1:1]"
+		message := "expected <true> to equal <false>"
+		stdout := "some output"
+		line := 12
+		fast := 20 * time.Millisecond
+		slow := 1500 * time.Millisecond
+
+		testResults = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				{
+					ID:       &id1,
+					Name:     "Foo bar is successful",
+					Lineage:  []string{"Foo", "bar", "is successful"},
+					Location: &v1.Location{File: "./spec/foo/bar.rb"},
+					Attempt:  v1.TestAttempt{Duration: &fast, Status: v1.NewSuccessfulTestStatus()},
+				},
+				{
+					ID:       &id2,
+					Name:     "Foo bar fails",
+					Lineage:  []string{"Foo", "bar", "fails"},
+					Location: &v1.Location{File: "./spec/foo/bar.rb", Line: &line},
+					Attempt: v1.TestAttempt{
+						Duration: &slow,
+						Status:   v1.NewFailedTestStatus(&message, nil, []string{"./spec/foo/bar.rb:13"}),
+						Stdout:   &stdout,
+					},
+					PastAttempts: []v1.TestAttempt{
+						{Duration: &fast, Status: v1.NewFailedTestStatus(&message, nil, nil)},
+					},
+				},
+				{
+					ID:       &id3,
+					Name:     "Foo is flaky",
+					Lineage:  []string{"Foo", "is flaky"},
+					Location: &v1.Location{File: "./spec/foo/bar.rb"},
+					Attempt:  v1.TestAttempt{Duration: &fast, Status: v1.NewSuccessfulTestStatus()},
+					PastAttempts: []v1.TestAttempt{
+						{Duration: &fast, Status: v1.NewFailedTestStatus(&message, nil, nil)},
+					},
+				},
+				{
+					ID:       &id4,
+					Name:     "a skipped test",
+					Location: &v1.Location{File: "./spec/foo/baz.rb"},
+					Attempt:  v1.TestAttempt{Status: v1.NewSkippedTestStatus(nil)},
+				},
+			},
+			nil,
+		)
+
+		cfg = reporting.Configuration{
+			SuiteID:      "some-suite-id",
+			CloudEnabled: true,
+			CloudHost:    "example.com",
+			Provider: providers.Provider{
+				BranchName: "some/branch",
+				CommitSha:  "abcdef113131",
+			},
+		}
+	})
+
+	It("produces a self-contained page", func() {
+		Expect(reporting.WriteHTMLSummary(mockFile, testResults, cfg)).To(Succeed())
+		cupaloy.SnapshotT(GinkgoT(), mockFile.Builder.String())
+	})
+
+	It("doesn't reference external resources", func() {
+		Expect(reporting.WriteHTMLSummary(mockFile, testResults, cfg)).To(Succeed())
+		Expect(mockFile.Builder.String()).NotTo(MatchRegexp(`(src|href)="(https?:)?//[^"]*\.(js|css)"`))
+	})
+
+	It("escapes the test results", func() {
+		Expect(reporting.WriteHTMLSummary(mockFile, testResults, cfg)).To(Succeed())
+		Expect(mockFile.Builder.String()).To(ContainSubstring("expected &lt;true&gt; to equal &lt;false&gt;"))
+		Expect(mockFile.Builder.String()).NotTo(ContainSubstring("<true>"))
+	})
+
+	It("includes the retry command of failed tests", func() {
+		Expect(reporting.WriteHTMLSummary(mockFile, testResults, cfg)).To(Succeed())
+		Expect(mockFile.Builder.String()).To(ContainSubstring(
+			"Retry with <code>bundle exec rspec &#39;./spec/foo/bar.rb[1:2]&#39;</code>",
+		))
+	})
+
+	It("lists every attempt of a test", func() {
+		Expect(reporting.WriteHTMLSummary(mockFile, testResults, cfg)).To(Succeed())
+		Expect(strings.Count(mockFile.Builder.String(), `<details class="attempt"`)).To(Equal(6))
+	})
+
+	It("links the attachments of an attempt", func() {
+		path := "test-results/foo-bar/trace.zip"
+		content := "aGVsbG8="
+		testResults.Tests[1].Attempt.Attachments = []v1.Attachment{
+			{Name: "trace", ContentType: "application/zip", Path: &path},
+			{Name: "screenshot", ContentType: "image/png", Content: &content},
+			{Name: "malicious", ContentType: "text/html;charset=utf-8", Content: &content},
+		}
+
+		Expect(reporting.WriteHTMLSummary(mockFile, testResults, cfg)).To(Succeed())
+
+		html := mockFile.Builder.String()
+		Expect(html).To(ContainSubstring(`<a href="test-results/foo-bar/trace.zip">trace</a>`))
+		Expect(html).To(ContainSubstring(`<img src="data:image/png;base64,aGVsbG8=" alt="screenshot">`))
+		Expect(html).NotTo(ContainSubstring("malicious"))
+	})
+})
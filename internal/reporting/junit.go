@@ -2,92 +2,145 @@ package reporting
 
 import (
 	"encoding/xml"
+	"strings"
 	"time"
 
 	"github.com/acarl005/stripansi"
 
 	"github.com/rwx-research/captain-cli/internal/errors"
 	"github.com/rwx-research/captain-cli/internal/fs"
-	"github.com/rwx-research/captain-cli/internal/parsing"
 	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
 )
 
-func WriteJUnitSummary(file fs.File, testResults v1.TestResults, _ Configuration) error {
-	result := parsing.JUnitTestResults{
-		TestSuites: make([]parsing.JUnitTestSuite, 0),
-	}
+// The JUnit report follows the format of Maven Surefire, which is the closest thing to a JUnit XML standard. Most
+// notably, retries are reported as `flakyFailure` / `rerunFailure` elements, which Jenkins & GitLab understand.
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr,omitempty"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       float64          `xml:"time,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
 
-	finishedAt := time.Time{}
-	startedAt := time.Time{}
-	suite := parsing.JUnitTestSuite{}
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	File      string          `xml:"file,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
 
-	suite.Errors = testResults.Summary.OtherErrors + testResults.Summary.TimedOut
-	suite.Failures = testResults.Summary.Canceled + testResults.Summary.Failed
-	suite.Skipped = testResults.Summary.Pended + testResults.Summary.Skipped + testResults.Summary.Todo
+	startedAt  time.Time
+	finishedAt time.Time
+	// untimed is the summed duration (in seconds) of the tests without start & finish times
+	untimed float64
+}
 
-	for _, test := range testResults.Tests {
-		testCase := parsing.JUnitTestCase{
-			Name:      test.Name,
-			SystemErr: test.Attempt.Stderr,
-			SystemOut: test.Attempt.Stdout,
-		}
+type junitTestCase struct {
+	Name          string           `xml:"name,attr"`
+	ClassName     string           `xml:"classname,attr,omitempty"`
+	Time          float64          `xml:"time,attr"`
+	Timestamp     string           `xml:"timestamp,attr,omitempty"`
+	File          string           `xml:"file,attr,omitempty"`
+	Line          *int             `xml:"line,attr"`
+	Properties    []junitProperty  `xml:"properties>property,omitempty"`
+	Skipped       *junitSkipped    `xml:"skipped"`
+	Failure       *junitFailure    `xml:"failure"`
+	Error         *junitFailure    `xml:"error"`
+	FlakyFailures []junitRerun     `xml:"flakyFailure"`
+	FlakyErrors   []junitRerun     `xml:"flakyError"`
+	RerunFailures []junitRerun     `xml:"rerunFailure"`
+	RerunErrors   []junitRerun     `xml:"rerunError"`
+	SystemOut     *junitCharacters `xml:"system-out"`
+	SystemErr     *junitCharacters `xml:"system-err"`
+}
 
-		if test.Attempt.Duration != nil {
-			testCase.Time = test.Attempt.Duration.Seconds()
-		}
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
 
-		if test.Attempt.StartedAt != nil && test.Attempt.StartedAt.Before(startedAt) {
-			startedAt = *test.Attempt.StartedAt
-		}
+type junitSkipped struct {
+	Message *string `xml:"message,attr"`
+}
 
-		if test.Attempt.FinishedAt != nil && test.Attempt.FinishedAt.After(finishedAt) {
-			finishedAt = *test.Attempt.FinishedAt
-		}
+type junitFailure struct {
+	Message *string `xml:"message,attr"`
+	Type    *string `xml:"type,attr"`
+	Details string  `xml:",chardata"`
+}
 
-		if test.Location != nil {
-			if test.Location.File != "" {
-				testCase.File = &test.Location.File
-			}
+// junitRerun is a previous attempt of a test. Surefire calls these `flakyFailure` if the test passed eventually and
+// `rerunFailure` otherwise.
+type junitRerun struct {
+	Message    *string          `xml:"message,attr"`
+	Type       *string          `xml:"type,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Time       *float64         `xml:"time,attr"`
+	StackTrace *junitCharacters `xml:"stackTrace"`
+	SystemOut  *junitCharacters `xml:"system-out"`
+	SystemErr  *junitCharacters `xml:"system-err"`
+}
 
-			if test.Location.Line != nil {
-				testCase.Line = test.Location.Line
-			}
-		}
+type junitCharacters struct {
+	Contents string `xml:",chardata"`
+}
 
-		message := test.Attempt.Status.Message
-		if message != nil {
-			strippedMessage := stripansi.Strip(*test.Attempt.Status.Message)
-			message = &strippedMessage
-		}
+// WriteJUnitSummary writes the test results as JUnit XML. Tests are grouped into one test suite per file (or per
+// top-level lineage component if there is no file), and every past attempt of a test is reported as well.
+func WriteJUnitSummary(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	result := junitTestSuites{Name: cfg.SuiteID}
 
-		//nolint:exhaustive
-		switch test.Attempt.Status.Kind {
-		case v1.TestStatusPended, v1.TestStatusSkipped, v1.TestStatusTodo:
-			testCase.Skipped = &parsing.JUnitSkipped{
-				Message: message,
-			}
-		case v1.TestStatusCanceled, v1.TestStatusFailed:
-			testCase.Failure = &parsing.JUnitFailure{
-				Message: message,
-			}
-		case v1.TestStatusTimedOut:
-			testCase.Error = &parsing.JUnitFailure{
-				Message: message,
-			}
+	suites := make(map[string]*junitTestSuite)
+	suiteOrder := make([]string, 0)
+
+	for _, test := range testResults.Tests {
+		suiteName, suiteFile := junitSuiteOf(test, cfg)
+		suite, ok := suites[suiteName]
+		if !ok {
+			suite = &junitTestSuite{Name: suiteName, File: suiteFile}
+			suites[suiteName] = suite
+			suiteOrder = append(suiteOrder, suiteName)
 		}
 
-		suite.TestCases = append(suite.TestCases, testCase)
+		suite.add(newJUnitTestCase(test), test.Attempt)
 	}
 
-	if !startedAt.IsZero() {
-		suite.Time = finishedAt.Sub(startedAt).Seconds()
-		suite.Timestamp = startedAt.String()
+	if len(testResults.OtherErrors) > 0 {
+		suite := &junitTestSuite{Name: "Other errors"}
+		suites[suite.Name] = suite
+		suiteOrder = append(suiteOrder, suite.Name)
+
+		for _, otherError := range testResults.OtherErrors {
+			suite.add(newJUnitOtherErrorTestCase(otherError), v1.TestAttempt{})
+		}
 	}
 
-	totalTests := len(suite.TestCases)
-	suite.Tests = &totalTests
+	for _, name := range suiteOrder {
+		suite := suites[name]
+
+		// The wall-clock time of the tests with start & finish times doesn't cover the ones without
+		suite.Time = suite.untimed
+		if !suite.startedAt.IsZero() {
+			suite.Timestamp = junitTimestamp(&suite.startedAt)
+			if suite.finishedAt.After(suite.startedAt) {
+				suite.Time += suite.finishedAt.Sub(suite.startedAt).Seconds()
+			}
+		}
 
-	result.TestSuites = append(result.TestSuites, suite)
+		result.Tests += suite.Tests
+		result.Failures += suite.Failures
+		result.Errors += suite.Errors
+		result.Skipped += suite.Skipped
+		result.Time += suite.Time
+		result.TestSuites = append(result.TestSuites, *suite)
+	}
 
 	_, err := file.Write([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"))
 	if err != nil {
@@ -103,3 +156,205 @@ func WriteJUnitSummary(file fs.File, testResults v1.TestResults, _ Configuration
 
 	return nil
 }
+
+func (s *junitTestSuite) add(testCase junitTestCase, attempt v1.TestAttempt) {
+	s.Tests++
+
+	switch {
+	case testCase.Failure != nil:
+		s.Failures++
+	case testCase.Error != nil:
+		s.Errors++
+	case testCase.Skipped != nil:
+		s.Skipped++
+	}
+
+	// Without start & finish times, all that's known is how long the test took
+	if attempt.StartedAt == nil || attempt.FinishedAt == nil {
+		s.untimed += testCase.Time
+	}
+
+	if attempt.StartedAt != nil && (s.startedAt.IsZero() || attempt.StartedAt.Before(s.startedAt)) {
+		s.startedAt = *attempt.StartedAt
+	}
+
+	if attempt.FinishedAt != nil && attempt.FinishedAt.After(s.finishedAt) {
+		s.finishedAt = *attempt.FinishedAt
+	}
+
+	s.TestCases = append(s.TestCases, testCase)
+}
+
+// junitSuiteOf returns the name & file of the suite that a test belongs to.
+func junitSuiteOf(test v1.Test, cfg Configuration) (string, string) {
+	if test.Location != nil && test.Location.File != "" {
+		return test.Location.File, test.Location.File
+	}
+
+	if len(test.Lineage) > 1 {
+		return test.Lineage[0], ""
+	}
+
+	if cfg.SuiteID != "" {
+		return cfg.SuiteID, ""
+	}
+
+	return "captain", ""
+}
+
+func newJUnitTestCase(test v1.Test) junitTestCase {
+	testCase := junitTestCase{
+		Name:      test.Name,
+		Time:      junitSeconds(test.Attempt.Duration),
+		Timestamp: junitTimestamp(test.Attempt.StartedAt),
+		SystemOut: junitText(test.Attempt.Stdout),
+		SystemErr: junitText(test.Attempt.Stderr),
+	}
+
+	if len(test.Lineage) > 1 {
+		testCase.ClassName = strings.Join(test.Lineage[:len(test.Lineage)-1], " ")
+	}
+
+	if test.Location != nil {
+		testCase.File = test.Location.File
+		testCase.Line = test.Location.Line
+
+		if testCase.ClassName == "" {
+			testCase.ClassName = test.Location.File
+		}
+	}
+
+	status := test.Attempt.Status
+	failure := newJUnitFailure(status)
+
+	//nolint:exhaustive
+	switch status.Kind {
+	case v1.TestStatusPended, v1.TestStatusSkipped, v1.TestStatusTodo:
+		testCase.Skipped = &junitSkipped{Message: failure.Message}
+	case v1.TestStatusCanceled, v1.TestStatusFailed:
+		testCase.Failure = &failure
+	case v1.TestStatusTimedOut:
+		testCase.Error = &failure
+	case v1.TestStatusQuarantined:
+		// Quarantined tests don't fail the build, which is why they are reported as passing
+		testCase.Properties = append(testCase.Properties, junitProperty{Name: "captain.quarantined", Value: "true"})
+		if status.OriginalStatus != nil {
+			testCase.Properties = append(testCase.Properties, junitProperty{
+				Name:  "captain.original_status",
+				Value: string(status.OriginalStatus.Kind),
+			})
+		}
+	}
+
+	flaky := test.Flaky()
+	if flaky {
+		testCase.Properties = append(testCase.Properties, junitProperty{Name: "captain.flaky", Value: "true"})
+	}
+
+	for _, attempt := range test.PastAttempts {
+		status := attempt.Status
+		if status.Kind == v1.TestStatusQuarantined && status.OriginalStatus != nil {
+			status = *status.OriginalStatus
+		}
+
+		if !status.ImpliesFailure() {
+			continue
+		}
+
+		rerun := newJUnitRerun(attempt, status)
+		isError := status.Kind == v1.TestStatusTimedOut
+
+		switch {
+		case flaky && isError:
+			testCase.FlakyErrors = append(testCase.FlakyErrors, rerun)
+		case flaky:
+			testCase.FlakyFailures = append(testCase.FlakyFailures, rerun)
+		case isError:
+			testCase.RerunErrors = append(testCase.RerunErrors, rerun)
+		default:
+			testCase.RerunFailures = append(testCase.RerunFailures, rerun)
+		}
+	}
+
+	return testCase
+}
+
+func newJUnitOtherErrorTestCase(otherError v1.OtherError) junitTestCase {
+	message := stripansi.Strip(otherError.Message)
+	testCase := junitTestCase{
+		Name: message,
+		Error: &junitFailure{
+			Message: &message,
+			Type:    otherError.Exception,
+			Details: stripansi.Strip(strings.Join(otherError.Backtrace, "\n")),
+		},
+	}
+
+	if otherError.Location != nil {
+		testCase.File = otherError.Location.File
+		testCase.Line = otherError.Location.Line
+	}
+
+	return testCase
+}
+
+func newJUnitFailure(status v1.TestStatus) junitFailure {
+	failure := junitFailure{
+		Type:    status.Exception,
+		Details: stripansi.Strip(strings.Join(status.Backtrace, "\n")),
+	}
+
+	if status.Message != nil {
+		strippedMessage := stripansi.Strip(*status.Message)
+		failure.Message = &strippedMessage
+	}
+
+	return failure
+}
+
+func newJUnitRerun(attempt v1.TestAttempt, status v1.TestStatus) junitRerun {
+	failure := newJUnitFailure(status)
+	rerun := junitRerun{
+		Message:   failure.Message,
+		Type:      failure.Type,
+		Timestamp: junitTimestamp(attempt.StartedAt),
+		SystemOut: junitText(attempt.Stdout),
+		SystemErr: junitText(attempt.Stderr),
+	}
+
+	if attempt.Duration != nil {
+		seconds := attempt.Duration.Seconds()
+		rerun.Time = &seconds
+	}
+
+	if failure.Details != "" {
+		rerun.StackTrace = &junitCharacters{Contents: failure.Details}
+	}
+
+	return rerun
+}
+
+func junitSeconds(duration *time.Duration) float64 {
+	if duration == nil {
+		return 0
+	}
+
+	return duration.Seconds()
+}
+
+// junitTimestamp formats a timestamp as ISO-8601.
+func junitTimestamp(timestamp *time.Time) string {
+	if timestamp == nil || timestamp.IsZero() {
+		return ""
+	}
+
+	return timestamp.UTC().Format(time.RFC3339Nano)
+}
+
+func junitText(text *string) *junitCharacters {
+	if text == nil {
+		return nil
+	}
+
+	return &junitCharacters{Contents: stripansi.Strip(*text)}
+}
@@ -3,6 +3,7 @@ package reporting_test
 import (
 	"encoding/xml"
 	"strings"
+	"time"
 
 	"github.com/rwx-research/captain-cli/internal/mocks"
 	"github.com/rwx-research/captain-cli/internal/parsing"
@@ -92,26 +93,129 @@ var _ = Describe("JUnit Report", func() {
 
 		Expect(reporting.WriteJUnitSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
 		Expect(xml.Unmarshal([]byte(mockFile.Builder.String()), &result)).To(Succeed())
-		Expect(result.TestSuites).To(HaveLen(1))
+		Expect(result.TestSuites).To(HaveLen(2))
 
-		Expect(result.TestSuites[0].Errors).To(Equal(12))
-		Expect(result.TestSuites[0].Failures).To(Equal(9))
-		Expect(result.TestSuites[0].Skipped).To(Equal(25))
-
-		Expect(result.TestSuites[0].TestCases).To(HaveLen(3))
+		Expect(result.TestSuites[0].Name).To(Equal("/path/to/file"))
+		Expect(result.TestSuites[0].Failures).To(Equal(0))
+		Expect(result.TestSuites[0].TestCases).To(HaveLen(1))
 		Expect(result.TestSuites[0].TestCases[0].Name).To(Equal("name of the test"))
 		Expect(*result.TestSuites[0].TestCases[0].File).To(Equal("/path/to/file"))
 		Expect(*result.TestSuites[0].TestCases[0].Line).To(Equal(0))
 
-		Expect(result.TestSuites[0].TestCases[1].Name).To(Equal("failed test message only w/o ansi"))
-		Expect(*result.TestSuites[0].TestCases[1].Failure.Message).To(Equal("expected true to equal false"))
+		Expect(result.TestSuites[1].Name).To(Equal("captain"))
+		Expect(result.TestSuites[1].Errors).To(Equal(0))
+		Expect(result.TestSuites[1].Failures).To(Equal(2))
+		Expect(result.TestSuites[1].Skipped).To(Equal(0))
+		Expect(result.TestSuites[1].TestCases).To(HaveLen(2))
+
+		Expect(result.TestSuites[1].TestCases[0].Name).To(Equal("failed test message only w/o ansi"))
+		Expect(*result.TestSuites[1].TestCases[0].Failure.Message).To(Equal("expected true to equal false"))
 
-		Expect(result.TestSuites[0].TestCases[2].Name).To(Equal("failed test message only w/ ansi"))
-		Expect(*result.TestSuites[0].TestCases[2].Failure.Message).To(Equal(`Failure/Error: expect(thanos).to eq("inevitable")
+		Expect(result.TestSuites[1].TestCases[1].Name).To(Equal("failed test message only w/ ansi"))
+		Expect(*result.TestSuites[1].TestCases[1].Failure.Message).To(Equal(`Failure/Error: expect(thanos).to eq("inevitable")
 
   expected: "inevitable"
        got: "evitable"
 
   (compared using ==)`))
 	})
+
+	Context("with lineage, retries & quarantined tests", func() {
+		BeforeEach(func() {
+			message := "expected true to equal false"
+			startedAt := time.Date(2022, 10, 3, 12, 0, 0, 0, time.UTC)
+			finishedAt := startedAt.Add(3 * time.Second)
+			duration := time.Second
+
+			testResults = *v1.NewTestResults(
+				v1.RubyRSpecFramework,
+				[]v1.Test{
+					{
+						Name:    "Foo bar is flaky",
+						Lineage: []string{"Foo", "bar", "is flaky"},
+						Attempt: v1.TestAttempt{
+							Duration:   &duration,
+							StartedAt:  &startedAt,
+							FinishedAt: &finishedAt,
+							Status:     v1.NewSuccessfulTestStatus(),
+						},
+						PastAttempts: []v1.TestAttempt{
+							{Duration: &duration, Status: v1.NewFailedTestStatus(&message, nil, []string{"a.rb:1"})},
+						},
+					},
+					{
+						Name:    "Foo still fails",
+						Lineage: []string{"Foo", "still fails"},
+						Attempt: v1.TestAttempt{Status: v1.NewFailedTestStatus(&message, nil, nil)},
+						PastAttempts: []v1.TestAttempt{
+							{Status: v1.NewFailedTestStatus(&message, nil, nil)},
+							{Status: v1.NewTimedOutTestStatus()},
+						},
+					},
+					{
+						Name:    "Foo is quarantined",
+						Lineage: []string{"Foo", "is quarantined"},
+						Attempt: v1.TestAttempt{
+							Status: v1.NewQuarantinedTestStatus(v1.NewFailedTestStatus(&message, nil, nil)),
+						},
+					},
+				},
+				nil,
+			)
+		})
+
+		It("groups the tests by their lineage", func() {
+			var result parsing.JUnitTestResults
+
+			cfg := reporting.Configuration{SuiteID: "some-suite"}
+			Expect(reporting.WriteJUnitSummary(mockFile, testResults, cfg)).To(Succeed())
+			Expect(xml.Unmarshal([]byte(mockFile.Builder.String()), &result)).To(Succeed())
+			Expect(result.TestSuites).To(HaveLen(1))
+			Expect(result.TestSuites[0].Name).To(Equal("Foo"))
+			Expect(result.TestSuites[0].Failures).To(Equal(1))
+			Expect(result.TestSuites[0].TestCases).To(HaveLen(3))
+			Expect(result.TestSuites[0].TestCases[0].ClassName).To(Equal("Foo bar"))
+			Expect(result.TestSuites[0].TestCases[1].ClassName).To(Equal("Foo"))
+		})
+
+		It("reports retries as flaky & rerun failures", func() {
+			Expect(reporting.WriteJUnitSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+
+			output := mockFile.Builder.String()
+			Expect(strings.Count(output, "<flakyFailure ")).To(Equal(1))
+			Expect(output).To(ContainSubstring("<stackTrace>a.rb:1</stackTrace>"))
+			Expect(strings.Count(output, "<rerunFailure ")).To(Equal(1))
+			Expect(strings.Count(output, "<rerunError")).To(Equal(1))
+			Expect(output).To(ContainSubstring(`<property name="captain.flaky" value="true"></property>`))
+		})
+
+		It("marks quarantined tests", func() {
+			Expect(reporting.WriteJUnitSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+
+			output := mockFile.Builder.String()
+			Expect(output).To(ContainSubstring(`<property name="captain.quarantined" value="true"></property>`))
+			Expect(output).To(ContainSubstring(`<property name="captain.original_status" value="failed"></property>`))
+		})
+
+		It("uses ISO-8601 timestamps", func() {
+			Expect(reporting.WriteJUnitSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+
+			output := mockFile.Builder.String()
+			Expect(output).To(ContainSubstring(`time="3" timestamp="2022-10-03T12:00:00Z"`))
+		})
+
+		It("adds the durations of tests without timestamps to the time of their suite", func() {
+			duration := 2 * time.Second
+			testResults.Tests = append(testResults.Tests, v1.Test{
+				Name:    "Foo is slow",
+				Lineage: []string{"Foo", "is slow"},
+				Attempt: v1.TestAttempt{Duration: &duration, Status: v1.NewSuccessfulTestStatus()},
+			})
+
+			Expect(reporting.WriteJUnitSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+
+			output := mockFile.Builder.String()
+			Expect(output).To(ContainSubstring(`time="5" timestamp="2022-10-03T12:00:00Z"`))
+		})
+	})
 })
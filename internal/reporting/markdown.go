@@ -22,15 +22,29 @@ var (
 	timedOutSection    markdownTestSection = "⏳ Timed Out"
 	quarantinedSection markdownTestSection = "🏥 Quarantined"
 	canceledSection    markdownTestSection = "🚫 Canceled"
+
+	orderedMarkdownSections = []markdownTestSection{
+		flakySection,
+		failedSection,
+		timedOutSection,
+		quarantinedSection,
+		canceledSection,
+	}
 )
 
 type markdownTest struct {
-	Name      string
-	Location  string
-	Command   string
-	Message   *string
-	Backtrace string
-	Retries   int
+	Name        string
+	Location    string
+	Command     string
+	Message     *string
+	Backtrace   string
+	Retries     int
+	Attachments []markdownAttachment
+}
+
+type markdownAttachment struct {
+	Name string
+	Path string
 }
 
 const (
@@ -42,7 +56,9 @@ const (
 <dl>
 {{ if .Retries }}<dd>Retried {{ .Retries}} time{{ if ne .Retries 1 }}s{{end}}</dd>{{ end }}
 {{ if .Location }}<dd>Defined at <code>{{ .Location }}</code></dd>{{ end }}
-{{ if .Command }}<dd>Retry with <code>{{ .Command }}</code></dd>{{ end }}
+{{ if .Command }}<dd>Retry with <code>{{ .Command }}</code></dd>{{ end }}{{ if .Attachments }}
+<dd>Attachments: {{ range $i, $attachment := .Attachments }}{{ if $i }}, {{ end -}}
+<a href="{{ $attachment.Path }}">{{ $attachment.Name }}</a>{{ end }}</dd>{{ end }}
 {{ if or .Message .Backtrace }}
 <dd>
 <details>
@@ -68,16 +84,8 @@ func WriteMarkdownSummary(file fs.File, testResults v1.TestResults, cfg Configur
 		return errors.WithStack(err)
 	}
 
-	if cfg.CloudEnabled {
-		if _, err := markdown.WriteString(
-			fmt.Sprintf(
-				"[🔗 View in Captain Cloud](https://%v/captain/deep_link/test_suite_summaries/%v/%v/%v)\n\n",
-				cfg.CloudHost,
-				cfg.SuiteID,
-				cfg.Provider.BranchName,
-				cfg.Provider.CommitSha,
-			),
-		); err != nil {
+	if url := cloudURL(cfg); url != "" {
+		if _, err := markdown.WriteString(fmt.Sprintf("[🔗 View in Captain Cloud](%v)\n\n", url)); err != nil {
 			return errors.WithStack(err)
 		}
 	}
@@ -100,15 +108,7 @@ func WriteMarkdownSummary(file fs.File, testResults v1.TestResults, cfg Configur
 		canceledSection:    writeMarkdownCanceledSection,
 	}
 
-	orderedSections := []markdownTestSection{
-		flakySection,
-		failedSection,
-		timedOutSection,
-		quarantinedSection,
-		canceledSection,
-	}
-
-	for _, section := range orderedSections {
+	for _, section := range orderedMarkdownSections {
 		shouldTruncate, err := writersBySection[section](markdown, testResults.Framework, testsBySection[section], cfg)
 		if err != nil {
 			return errors.WithStack(err)
@@ -254,23 +254,26 @@ func writeMarkdownFlakySection(
 		flakySection,
 		framework,
 		tests,
-		func(test v1.Test) *v1.TestStatus {
-			if test.Attempt.Status.PotentiallyFlaky() {
-				return &test.Attempt.Status
-			}
-
-			for _, attempt := range test.PastAttempts {
-				if attempt.Status.PotentiallyFlaky() {
-					return &attempt.Status
-				}
-			}
-
-			return nil
-		},
+		flakyStatusOf,
 		cfg,
 	)
 }
 
+// flakyStatusOf returns the status of the attempt that makes a test flaky.
+func flakyStatusOf(test v1.Test) *v1.TestStatus {
+	if test.Attempt.Status.PotentiallyFlaky() {
+		return &test.Attempt.Status
+	}
+
+	for _, attempt := range test.PastAttempts {
+		if attempt.Status.PotentiallyFlaky() {
+			return &attempt.Status
+		}
+	}
+
+	return nil
+}
+
 func writeMarkdownFailedSection(
 	markdown *strings.Builder,
 	framework v1.Framework,
@@ -372,24 +375,13 @@ func writeMarkdownSection(
 			location = test.Location.String()
 		}
 
-		retryCommand := ""
-		if retryTemplate != nil && substitution != nil {
-			substitutions, _ := substitution.SubstitutionsFor(
-				*retryTemplate,
-				*v1.NewTestResults(framework, []v1.Test{test}, nil),
-				func(test v1.Test) bool { return true },
-			)
-
-			if len(substitutions) >= 1 {
-				retryCommand = retryTemplate.Substitute(substitutions[0])
-			}
-		}
 		failedStatus := findFailedStatus(test)
 		markdownTest := markdownTest{
-			Name:     test.Name,
-			Location: location,
-			Command:  retryCommand,
-			Retries:  len(test.PastAttempts),
+			Name:        test.Name,
+			Location:    location,
+			Command:     retryCommandFor(framework, retryTemplate, substitution, test),
+			Retries:     len(test.PastAttempts),
+			Attachments: markdownAttachmentsOf(test),
 		}
 		if failedStatus != nil {
 			markdownTest.Backtrace = stripansi.Strip(strings.Join(failedStatus.Backtrace, "\n"))
@@ -416,6 +408,53 @@ func writeMarkdownSection(
 	return false, nil
 }
 
+// markdownAttachmentsOf returns the attachments of all attempts of a test that can be linked to.
+func markdownAttachmentsOf(test v1.Test) []markdownAttachment {
+	attachments := make([]markdownAttachment, 0)
+	seenPaths := make(map[string]struct{})
+
+	for _, attempt := range append(append([]v1.TestAttempt{}, test.PastAttempts...), test.Attempt) {
+		for _, attachment := range attempt.Attachments {
+			if attachment.Path == nil {
+				continue
+			}
+
+			if _, ok := seenPaths[*attachment.Path]; ok {
+				continue
+			}
+			seenPaths[*attachment.Path] = struct{}{}
+
+			attachments = append(attachments, markdownAttachment{Name: attachment.Name, Path: *attachment.Path})
+		}
+	}
+
+	return attachments
+}
+
+// retryCommandFor returns the command that retries a single test, or an empty string if there is none.
+func retryCommandFor(
+	framework v1.Framework,
+	retryTemplate *templating.CompiledTemplate,
+	substitution targetedretries.Substitution,
+	test v1.Test,
+) string {
+	if retryTemplate == nil || substitution == nil {
+		return ""
+	}
+
+	substitutions, _ := substitution.SubstitutionsFor(
+		*retryTemplate,
+		*v1.NewTestResults(framework, []v1.Test{test}, nil),
+		func(test v1.Test) bool { return true },
+	)
+
+	if len(substitutions) == 0 {
+		return ""
+	}
+
+	return retryTemplate.Substitute(substitutions[0])
+}
+
 func retryTemplateAndSubstitutionFor(
 	framework v1.Framework,
 	retryCommandTemplate string,
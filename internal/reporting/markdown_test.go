@@ -170,6 +170,24 @@ var _ = Describe("Markdown Report", func() {
 		cupaloy.SnapshotT(GinkgoT(), summary)
 	})
 
+	It("links the attachments of failed tests", func() {
+		screenshot := "test-results/failed-test/screenshot.png"
+		trace := "test-results/failed-test/trace.zip"
+		testResults.Tests[1].PastAttempts[0].Attachments = []v1.Attachment{
+			{Name: "screenshot", ContentType: "image/png", Path: &screenshot},
+		}
+		testResults.Tests[1].Attempt.Attachments = []v1.Attachment{
+			{Name: "screenshot", ContentType: "image/png", Path: &screenshot},
+			{Name: "trace", ContentType: "application/zip", Path: &trace},
+		}
+
+		Expect(reporting.WriteMarkdownSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+		Expect(mockFile.Builder.String()).To(ContainSubstring(
+			`<dd>Attachments: <a href="test-results/failed-test/screenshot.png">screenshot</a>, ` +
+				`<a href="test-results/failed-test/trace.zip">trace</a></dd>`,
+		))
+	})
+
 	It("produces a truncated summary <= 1MB", func() {
 		cfg := reporting.Configuration{
 			SuiteID:      "some-suite-id",
@@ -0,0 +1,217 @@
+package reporting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/acarl005/stripansi"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	"github.com/rwx-research/captain-cli/internal/providers"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+// TemplateReport is the data that user-defined report templates are rendered against.
+type TemplateReport struct {
+	// SuiteID is the ID of the test suite
+	SuiteID string
+	// Framework is the test framework that produced the test results
+	Framework v1.Framework
+	// Summary contains the number of tests per status
+	Summary v1.Summary
+	// CloudURL links to the test results in Captain Cloud. It's empty if Captain Cloud isn't used.
+	CloudURL string
+	// Provider describes the CI provider & commit. It's empty if Captain Cloud isn't used.
+	Provider providers.Provider
+	// Tests are all tests in the order they were reported in
+	Tests []TemplateTest
+	// TestsByStatus groups the tests by the status of their final attempt, e.g. "failed" or "quarantined"
+	TestsByStatus map[string][]TemplateTest
+	// Flaky are the tests that passed after a retry
+	Flaky []TemplateTest
+	// Quarantined are the tests that failed but were quarantined
+	Quarantined []TemplateTest
+	// OtherErrors are errors that happened outside of tests
+	OtherErrors []v1.OtherError
+}
+
+// TemplateTest is a single test of a TemplateReport.
+type TemplateTest struct {
+	ID       string
+	Name     string
+	Lineage  []string
+	Location string
+	// Status is the status of the final attempt, e.g. "successful", "failed", or "quarantined"
+	Status string
+	// OriginalStatus is the status of a quarantined test before it was quarantined
+	OriginalStatus string
+	Flaky          bool
+	Duration       time.Duration
+	Retries        int
+	// Message, Exception & Backtrace describe the failure of the test. For flaky tests, this is the first failure.
+	Message   string
+	Exception string
+	Backtrace []string
+	Stdout    string
+	Stderr    string
+	// Command retries only this test. It's empty if the test didn't fail or if there is no retry command for the
+	// framework.
+	Command string
+}
+
+// templateFuncs are the helper functions available to user-defined report templates.
+var templateFuncs = template.FuncMap{
+	"truncate":  templateTruncate,
+	"stripAnsi": stripansi.Strip,
+	"json":      templateJSON,
+	"pluralize": pluralize,
+	"join":      strings.Join,
+}
+
+// NewTemplateReporter returns a reporter that renders the given `text/template` against a TemplateReport. The name
+// is only used in error messages.
+func NewTemplateReporter(
+	name string,
+	text string,
+) (func(fs.File, v1.TestResults, Configuration) error, error) {
+	parsedTemplate, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.NewConfigurationError(
+			fmt.Sprintf("Invalid report template %q", name),
+			err.Error(),
+			"Report templates use the syntax of Go's 'text/template' package, see https://pkg.go.dev/text/template",
+		)
+	}
+
+	return func(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+		if err := parsedTemplate.Execute(file, newTemplateReport(testResults, cfg)); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, nil
+}
+
+func newTemplateReport(testResults v1.TestResults, cfg Configuration) TemplateReport {
+	report := TemplateReport{
+		SuiteID:       cfg.SuiteID,
+		Framework:     testResults.Framework,
+		Summary:       testResults.Summary,
+		Tests:         make([]TemplateTest, 0, len(testResults.Tests)),
+		TestsByStatus: make(map[string][]TemplateTest),
+		Flaky:         make([]TemplateTest, 0),
+		Quarantined:   make([]TemplateTest, 0),
+		OtherErrors:   testResults.OtherErrors,
+	}
+
+	if cfg.CloudEnabled {
+		report.Provider = cfg.Provider
+		report.CloudURL = cloudURL(cfg)
+	}
+
+	retryTemplate, substitution := retryTemplateAndSubstitutionFor(testResults.Framework, cfg.RetryCommandTemplate)
+
+	for _, test := range testResults.Tests {
+		templateTest := newTemplateTest(test)
+		templateTest.Command = retryCommandFor(testResults.Framework, retryTemplate, substitution, test)
+
+		report.Tests = append(report.Tests, templateTest)
+		report.TestsByStatus[templateTest.Status] = append(report.TestsByStatus[templateTest.Status], templateTest)
+
+		if templateTest.Flaky {
+			report.Flaky = append(report.Flaky, templateTest)
+		}
+
+		if test.Attempt.Status.Kind == v1.TestStatusQuarantined {
+			report.Quarantined = append(report.Quarantined, templateTest)
+		}
+	}
+
+	return report
+}
+
+func newTemplateTest(test v1.Test) TemplateTest {
+	templateTest := TemplateTest{
+		Name:    test.Name,
+		Lineage: test.Lineage,
+		Status:  string(test.Attempt.Status.Kind),
+		Flaky:   test.Flaky(),
+		Retries: len(test.PastAttempts),
+	}
+
+	if test.ID != nil {
+		templateTest.ID = *test.ID
+	}
+
+	if test.Location != nil {
+		templateTest.Location = test.Location.String()
+	}
+
+	if test.Attempt.Duration != nil {
+		templateTest.Duration = *test.Attempt.Duration
+	}
+
+	if test.Attempt.Stdout != nil {
+		templateTest.Stdout = *test.Attempt.Stdout
+	}
+
+	if test.Attempt.Stderr != nil {
+		templateTest.Stderr = *test.Attempt.Stderr
+	}
+
+	status := test.Attempt.Status
+	if status.OriginalStatus != nil {
+		templateTest.OriginalStatus = string(status.OriginalStatus.Kind)
+		status = *status.OriginalStatus
+	}
+
+	if templateTest.Flaky {
+		for _, attempt := range test.PastAttempts {
+			if attempt.Status.PotentiallyFlaky() {
+				status = attempt.Status
+				break
+			}
+		}
+	}
+
+	if status.Message != nil {
+		templateTest.Message = *status.Message
+	}
+
+	if status.Exception != nil {
+		templateTest.Exception = *status.Exception
+	}
+
+	templateTest.Backtrace = status.Backtrace
+
+	return templateTest
+}
+
+// templateTruncate shortens a string to at most `length` characters. Its argument order allows for pipelines, e.g.
+// `{{ .Message | truncate 100 }}`.
+func templateTruncate(length int, value string) string {
+	runes := []rune(value)
+	if length < 0 || len(runes) <= length {
+		return value
+	}
+
+	if length <= 3 {
+		return string(runes[:length])
+	}
+
+	return string(runes[:length-3]) + "..."
+}
+
+// templateJSON encodes a value as JSON, which also escapes strings for use inside of JSON documents.
+func templateJSON(value any) (string, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(buf), nil
+}
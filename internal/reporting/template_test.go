@@ -0,0 +1,115 @@
+package reporting_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/providers"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template Report", func() {
+	var (
+		mockFile    *mocks.File
+		testResults v1.TestResults
+		cfg         reporting.Configuration
+	)
+
+	render := func(text string) string {
+		reporter, err := reporting.NewTemplateReporter("test.tmpl", text)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reporter(mockFile, testResults, cfg)).To(Succeed())
+		return mockFile.Builder.String()
+	}
+
+	BeforeEach(func() {
+		mockFile = new(mocks.File)
+		mockFile.Builder = new(strings.Builder)
+
+		id1 := "./spec/foo_spec.rb[1:1]"
+		id2 := "./spec/foo_spec.rb[1:2]"
+		message := "\x1b[31mexpected \"true\"\x1b[0m to equal false"
+		duration := 1500 * time.Millisecond
+
+		testResults = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				{
+					ID:       &id1,
+					Name:     "fails",
+					Location: &v1.Location{File: "./spec/foo_spec.rb"},
+					Attempt: v1.TestAttempt{
+						Duration: &duration,
+						Status:   v1.NewFailedTestStatus(&message, nil, nil),
+					},
+				},
+				{
+					ID:       &id2,
+					Name:     "is flaky",
+					Location: &v1.Location{File: "./spec/foo_spec.rb"},
+					Attempt:  v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+					PastAttempts: []v1.TestAttempt{
+						{Status: v1.NewFailedTestStatus(&message, nil, nil)},
+					},
+				},
+				{
+					Name:    "is quarantined",
+					Attempt: v1.TestAttempt{Status: v1.NewQuarantinedTestStatus(v1.NewTimedOutTestStatus())},
+				},
+			},
+			nil,
+		)
+
+		cfg = reporting.Configuration{
+			SuiteID:      "some-suite-id",
+			CloudEnabled: true,
+			CloudHost:    "example.com",
+			Provider:     providers.Provider{BranchName: "main", CommitSha: "abc123", ProviderName: "github"},
+		}
+	})
+
+	It("renders the summary, provider & cloud link", func() {
+		Expect(render(
+			"{{ .SuiteID }}: {{ .Summary.Tests }} {{ pluralize .Summary.Tests \"test\" \"tests\" }} " +
+				"on {{ .Provider.BranchName }} ({{ .CloudURL }})",
+		)).To(Equal(
+			"some-suite-id: 3 tests on main " +
+				"(https://example.com/captain/deep_link/test_suite_summaries/some-suite-id/main/abc123)",
+		))
+	})
+
+	It("groups the tests", func() {
+		Expect(render(
+			"{{ range .TestsByStatus.failed }}failed: {{ .Name }} ({{ .Duration }})\n{{ end }}" +
+				"{{ range .Flaky }}flaky: {{ .Name }} after {{ .Retries }}\n{{ end }}" +
+				"{{ range .Quarantined }}quarantined: {{ .Name }} ({{ .OriginalStatus }})\n{{ end }}",
+		)).To(Equal("failed: fails (1.5s)\nflaky: is flaky after 1\nquarantined: is quarantined (timedOut)\n"))
+	})
+
+	It("includes retry commands", func() {
+		Expect(render("{{ range .Tests }}{{ .Name }}: {{ .Command }}\n{{ end }}")).To(Equal(
+			"fails: bundle exec rspec './spec/foo_spec.rb[1:1]'\nis flaky: \nis quarantined: \n",
+		))
+	})
+
+	It("provides helpers for escaping & truncation", func() {
+		Expect(render(`{"text": {{ (index .Tests 0).Message | stripAnsi | truncate 18 | json }}}`)).To(Equal(
+			`{"text": "expected \"true\"..."}`,
+		))
+	})
+
+	It("reports invalid templates as configuration errors", func() {
+		_, err := reporting.NewTemplateReporter("test.tmpl", "{{ .SuiteID ")
+		Expect(err).To(HaveOccurred())
+
+		var configurationError errors.ConfigurationError
+		Expect(errors.As(err, &configurationError)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("Invalid report template"))
+	})
+})
@@ -2,52 +2,156 @@ package reporting
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
 
+	"github.com/acarl005/stripansi"
+
 	"github.com/rwx-research/captain-cli/internal/errors"
 	"github.com/rwx-research/captain-cli/internal/fs"
 	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
 )
 
+// textSummaryLimit is the number of tests that are listed per section of a text summary
+const textSummaryLimit = 25
+
+// textSummaryOtherKinds are statuses of tests that the markdown summary leaves out, but that are still listed in a text
+// summary after the markdown sections.
+var textSummaryOtherKinds = []v1.TestStatusKind{v1.TestStatusSkipped, v1.TestStatusPended, v1.TestStatusTodo}
+
+type textSummarySection struct {
+	label string
+	tests []v1.Test
+	// statusOf returns the status whose message is shown, or nil if there is none
+	statusOf func(v1.Test) *v1.TestStatus
+}
+
+// WriteTextSummary writes a plain-text summary of all tests that didn't simply pass. Tests are grouped in the same
+// sections as the markdown summary, followed by the tests that didn't run. Sections are in a fixed order & list
+// their tests in the order they were reported in, which keeps the output stable between runs.
 func WriteTextSummary(file fs.File, testResults v1.TestResults, _ Configuration) error {
-	statuses := make(map[v1.TestStatusKind][]string)
-	totalTests := testResults.Summary.Tests
+	summary := new(strings.Builder)
+	if _, err := summary.WriteString(
+		fmt.Sprintf("\nCaptain detected a total of %d tests.\n", testResults.Summary.Tests),
+	); err != nil {
+		return errors.WithStack(err)
+	}
 
-	for _, test := range testResults.Tests {
-		if test.Attempt.Status.Kind == v1.TestStatusSuccessful {
+	for _, section := range textSummarySections(testResults) {
+		if len(section.tests) == 0 {
 			continue
 		}
 
-		tests, ok := statuses[test.Attempt.Status.Kind]
-		if !ok {
-			tests = make([]string, 0)
+		if _, err := summary.WriteString(fmt.Sprintf("\n%s (%d):\n", section.label, len(section.tests))); err != nil {
+			return errors.WithStack(err)
 		}
 
-		tests = append(tests, test.Name)
-		statuses[test.Attempt.Status.Kind] = tests
+		for i, test := range section.tests {
+			if i == textSummaryLimit {
+				if _, err := summary.WriteString(fmt.Sprintf("... and %d more\n", len(section.tests)-i)); err != nil {
+					return errors.WithStack(err)
+				}
+				break
+			}
+
+			if err := writeTextSummaryTest(summary, test, section.statusOf(test)); err != nil {
+				return errors.WithStack(err)
+			}
+		}
 	}
 
-	_, err := file.Write([]byte(fmt.Sprintf("\nCaptain detected a total of %d tests.\n", totalTests)))
-	if err != nil {
+	if _, err := file.Write([]byte(summary.String())); err != nil {
 		return errors.WithStack(err)
 	}
 
-	for status, tests := range statuses {
-		statusName := []rune(status)
-		statusName[0] = unicode.ToUpper(statusName[0])
+	return nil
+}
 
-		_, err := file.Write([]byte(fmt.Sprintf("\n%s (%d):\n", string(statusName), len(tests))))
-		if err != nil {
-			return errors.WithStack(err)
+func textSummarySections(testResults v1.TestResults) []textSummarySection {
+	sections := make([]textSummarySection, 0, len(orderedMarkdownSections)+len(textSummaryOtherKinds))
+	attemptStatusOf := func(test v1.Test) *v1.TestStatus { return &test.Attempt.Status }
+
+	testsBySection := testsByMarkdownSection(testResults)
+	for _, markdownSection := range orderedMarkdownSections {
+		section := textSummarySection{tests: testsBySection[markdownSection], statusOf: attemptStatusOf}
+
+		// Plain text doesn't need the emoji of the markdown headings
+		_, section.label, _ = strings.Cut(string(markdownSection), " ")
+
+		switch markdownSection {
+		case flakySection:
+			section.statusOf = flakyStatusOf
+		case quarantinedSection:
+			section.statusOf = func(test v1.Test) *v1.TestStatus { return test.Attempt.Status.OriginalStatus }
 		}
 
-		for _, testName := range tests {
-			_, err := file.Write([]byte(fmt.Sprintf("- %s\n", testName)))
-			if err != nil {
-				return errors.WithStack(err)
+		sections = append(sections, section)
+	}
+
+	for _, kind := range textSummaryOtherKinds {
+		section := textSummarySection{label: textSummaryLabelOf(kind), statusOf: attemptStatusOf}
+
+		for _, test := range testResults.Tests {
+			if test.Attempt.Status.Kind == kind && !test.Flaky() {
+				section.tests = append(section.tests, test)
 			}
 		}
+
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+func textSummaryLabelOf(kind v1.TestStatusKind) string {
+	label := []rune(string(kind))
+	label[0] = unicode.ToUpper(label[0])
+	return string(label)
+}
+
+func writeTextSummaryTest(summary *strings.Builder, test v1.Test, status *v1.TestStatus) error {
+	line := fmt.Sprintf("- %s", test.Name)
+	if test.Location != nil {
+		line = fmt.Sprintf("%s (%s)", line, test.Location.String())
+	}
+
+	if retries := len(test.PastAttempts); retries > 0 {
+		line = fmt.Sprintf("%s, retried %d %s", line, retries, pluralize(retries, "time", "times"))
+	}
+
+	if _, err := summary.WriteString(line + "\n"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if message := textSummaryMessageOf(status); message != "" {
+		if _, err := summary.WriteString(fmt.Sprintf("    %s\n", message)); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
 	return nil
 }
+
+// textSummaryMessageOf returns the first line of the failure message of a status
+func textSummaryMessageOf(status *v1.TestStatus) string {
+	if status == nil {
+		return ""
+	}
+
+	message := ""
+	if status.Message != nil {
+		message = strings.TrimSpace(stripansi.Strip(*status.Message))
+		message, _, _ = strings.Cut(message, "\n")
+		message = strings.TrimSpace(message)
+	}
+
+	if status.Exception != nil && *status.Exception != "" {
+		if message == "" {
+			return *status.Exception
+		}
+
+		return fmt.Sprintf("%s: %s", *status.Exception, message)
+	}
+
+	return message
+}
@@ -1,6 +1,7 @@
 package reporting_test
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/rwx-research/captain-cli/internal/mocks"
@@ -70,6 +71,74 @@ var _ = Describe("Text Report", func() {
 		Expect(summary).To(ContainSubstring("total of 4 tests"))
 		Expect(summary).To(ContainSubstring("Failed (1)"))
 		Expect(summary).To(ContainSubstring("Skipped (1)"))
-		Expect(summary).To(ContainSubstring("TimedOut (1)"))
+		Expect(summary).To(ContainSubstring("Timed Out (1)"))
+	})
+
+	It("orders sections deterministically & includes details", func() {
+		message := "expected true to equal false\n\n  diff: ..."
+		exception := "RSpec::Expectations::ExpectationNotMetError"
+		line := 4
+		testResults.Tests[1].Location = &v1.Location{File: "spec/foo_spec.rb", Line: &line}
+		testResults.Tests[1].Attempt.Status = v1.NewFailedTestStatus(&message, &exception, nil)
+		testResults.Tests[1].PastAttempts = []v1.TestAttempt{
+			{Status: v1.NewFailedTestStatus(nil, nil, nil)},
+			{Status: v1.NewFailedTestStatus(nil, nil, nil)},
+		}
+		testResults.Tests = append(testResults.Tests, v1.Test{
+			Name:         "flaky test",
+			Attempt:      v1.TestAttempt{Status: v1.NewSuccessfulTestStatus()},
+			PastAttempts: []v1.TestAttempt{{Status: v1.NewTimedOutTestStatus()}},
+		})
+
+		for i := 0; i < 10; i++ {
+			Expect(reporting.WriteTextSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+			Expect(mockFile.Builder.String()).To(Equal(
+				"\nCaptain detected a total of 4 tests.\n" +
+					"\nFlaky (1):\n- flaky test, retried 1 time\n" +
+					"\nFailed (1):\n- failed test (spec/foo_spec.rb:4), retried 2 times\n" +
+					"    RSpec::Expectations::ExpectationNotMetError: expected true to equal false\n" +
+					"\nTimed Out (1):\n- timed out test\n" +
+					"\nSkipped (1):\n- skipped test\n",
+			))
+			mockFile.Builder.Reset()
+		}
+	})
+
+	It("uses the sections of the markdown summary", func() {
+		message := "expected true to equal false"
+		testResults.Tests = []v1.Test{
+			{Name: "canceled test", Attempt: v1.TestAttempt{Status: v1.NewCanceledTestStatus()}},
+			{
+				Name: "quarantined test",
+				Attempt: v1.TestAttempt{
+					Status: v1.NewQuarantinedTestStatus(v1.NewFailedTestStatus(&message, nil, nil)),
+				},
+			},
+			{Name: "pended test", Attempt: v1.TestAttempt{Status: v1.NewPendedTestStatus(nil)}},
+		}
+
+		Expect(reporting.WriteTextSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+		Expect(mockFile.Builder.String()).To(HaveSuffix(
+			"\nQuarantined (1):\n- quarantined test\n    expected true to equal false\n" +
+				"\nCanceled (1):\n- canceled test\n" +
+				"\nPended (1):\n- pended test\n",
+		))
+	})
+
+	It("limits the number of tests per section", func() {
+		testResults.Tests = make([]v1.Test, 30)
+		for i := range testResults.Tests {
+			testResults.Tests[i] = v1.Test{
+				Name:    fmt.Sprintf("failed test %d", i),
+				Attempt: v1.TestAttempt{Status: v1.NewFailedTestStatus(nil, nil, nil)},
+			}
+		}
+
+		Expect(reporting.WriteTextSummary(mockFile, testResults, reporting.Configuration{})).To(Succeed())
+
+		summary := mockFile.Builder.String()
+		Expect(summary).To(ContainSubstring("Failed (30):\n"))
+		Expect(summary).To(ContainSubstring("- failed test 24\n... and 5 more\n"))
+		Expect(summary).NotTo(ContainSubstring("failed test 25"))
 	})
 })
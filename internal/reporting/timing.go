@@ -0,0 +1,292 @@
+package reporting
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	// timingReportLimit is the number of slowest tests & files that are part of a timing report
+	timingReportLimit = 10
+
+	// timingReportUnknownFile groups tests without a location
+	timingReportUnknownFile = "(unknown)"
+
+	timingReportMarkdownTemplate = `# {{ if .SuiteID }}` + "`{{ .SuiteID }}` " + `{{ end }}Timing Report
+
+Total test time: {{ duration .TotalDuration }}
+{{ if .SlowestTests }}
+## Slowest Tests
+
+| Test | File | Duration |
+| --- | --- | --- |
+{{ range .SlowestTests }}| {{ escape .Name }} | {{ escape .File }} | {{ duration .Duration }} |
+{{ end }}{{ end }}{{ if .SlowestFiles }}
+## Slowest Files
+
+| File | Tests | Duration |
+| --- | --- | --- |
+{{ range .SlowestFiles }}| {{ escape .File }} | {{ .Tests }} | {{ duration .Duration }} |
+{{ end }}{{ end }}{{ if .Directories }}
+## Time per Directory
+
+| Directory | Duration | Share |
+| --- | --- | --- |
+{{ range .Directories }}| {{ escape .Directory }} | {{ duration .Duration }} | {{ printf "%.1f" .Share }}% |
+{{ end }}{{ end }}
+## Duration Regressions
+
+{{ if not .TimingsAvailable -}}
+No stored timings to compare against were found.
+{{ else if not .DurationRegressions -}}
+No test file took more than {{ .Threshold }}% longer than its stored timing.
+{{ else -}}
+| File | Before | After |
+| --- | --- | --- |
+{{ range .DurationRegressions -}}
+| {{ escape .File }} | {{ duration .Before }} | {{ duration .After }} |
+{{ end }}{{ end }}`
+)
+
+// TimingReport summarizes where the time of a test suite was spent. Like the stored timings of test files, durations
+// only include the final attempt of every test.
+type TimingReport struct {
+	TotalDuration       time.Duration            `json:"totalDurationInNanoseconds"`
+	SlowestTests        []TimingReportTest       `json:"slowestTests"`
+	SlowestFiles        []TimingReportFile       `json:"slowestFiles"`
+	Directories         []TimingReportDirectory  `json:"directories"`
+	DurationRegressions []FileDurationRegression `json:"durationRegressions"`
+	// TimingsAvailable is false if there were no stored timings to detect duration regressions with
+	TimingsAvailable bool `json:"timingsAvailable"`
+}
+
+type TimingReportTest struct {
+	Name     string        `json:"name"`
+	File     string        `json:"file,omitempty"`
+	Duration time.Duration `json:"durationInNanoseconds"`
+}
+
+type TimingReportFile struct {
+	File     string        `json:"file"`
+	Tests    int           `json:"tests"`
+	Duration time.Duration `json:"durationInNanoseconds"`
+}
+
+type TimingReportDirectory struct {
+	Directory string        `json:"directory"`
+	Duration  time.Duration `json:"durationInNanoseconds"`
+	// Share is the percentage of the total duration that was spent in this directory
+	Share float64 `json:"share"`
+}
+
+// FileDurationRegression is a test file that took considerably longer than its stored timing.
+type FileDurationRegression struct {
+	File   string        `json:"file"`
+	Before time.Duration `json:"beforeInNanoseconds"`
+	After  time.Duration `json:"afterInNanoseconds"`
+}
+
+// WriteTimingReport writes the slowest tests & files, the share of time spent per directory, and the test files that
+// got slower compared to their stored timings. The report is written as JSON if the output path ends in ".json", and
+// as markdown otherwise.
+func WriteTimingReport(file fs.File, testResults v1.TestResults, cfg Configuration) error {
+	report := NewTimingReport(testResults, cfg)
+
+	if strings.EqualFold(path.Ext(file.Name()), ".json") {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(report); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}
+
+	markdown, err := timingReportMarkdown(report, cfg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := file.Write([]byte(markdown)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// NewTimingReport summarizes the durations of the test results.
+func NewTimingReport(testResults v1.TestResults, cfg Configuration) TimingReport {
+	report := TimingReport{
+		SlowestTests:        make([]TimingReportTest, 0, len(testResults.Tests)),
+		SlowestFiles:        make([]TimingReportFile, 0),
+		Directories:         make([]TimingReportDirectory, 0),
+		DurationRegressions: make([]FileDurationRegression, 0),
+		TimingsAvailable:    cfg.Timings != nil,
+	}
+
+	filesByName := make(map[string]*TimingReportFile)
+	durationsByDirectory := make(map[string]time.Duration)
+
+	for _, test := range testResults.Tests {
+		duration, _ := durationOf(test)
+		file := timingReportFileOf(test)
+
+		report.TotalDuration += duration
+		report.SlowestTests = append(report.SlowestTests, TimingReportTest{
+			Name:     test.Name,
+			File:     file,
+			Duration: duration,
+		})
+
+		if file == "" {
+			file = timingReportUnknownFile
+		}
+
+		if _, ok := filesByName[file]; !ok {
+			filesByName[file] = &TimingReportFile{File: file}
+		}
+		filesByName[file].Tests++
+		filesByName[file].Duration += duration
+
+		directory := timingReportUnknownFile
+		if file != timingReportUnknownFile {
+			directory = path.Dir(file)
+		}
+		durationsByDirectory[directory] += duration
+	}
+
+	for _, file := range filesByName {
+		report.SlowestFiles = append(report.SlowestFiles, *file)
+	}
+
+	for directory, duration := range durationsByDirectory {
+		share := 0.0
+		if report.TotalDuration > 0 {
+			share = float64(duration) / float64(report.TotalDuration) * 100
+		}
+
+		report.Directories = append(report.Directories, TimingReportDirectory{
+			Directory: directory,
+			Duration:  duration,
+			Share:     share,
+		})
+	}
+
+	// Tests keep the order they were reported in on ties. Files & directories are sorted by name instead, so that the
+	// report doesn't depend on the iteration order of maps.
+	sort.SliceStable(report.SlowestTests, func(i, j int) bool {
+		return report.SlowestTests[i].Duration > report.SlowestTests[j].Duration
+	})
+	sort.Slice(report.SlowestFiles, func(i, j int) bool {
+		a, b := report.SlowestFiles[i], report.SlowestFiles[j]
+		return a.Duration > b.Duration || (a.Duration == b.Duration && a.File < b.File)
+	})
+	sort.Slice(report.Directories, func(i, j int) bool {
+		a, b := report.Directories[i], report.Directories[j]
+		return a.Duration > b.Duration || (a.Duration == b.Duration && a.Directory < b.Directory)
+	})
+
+	if len(report.SlowestTests) > timingReportLimit {
+		report.SlowestTests = report.SlowestTests[:timingReportLimit]
+	}
+
+	if len(report.SlowestFiles) > timingReportLimit {
+		report.SlowestFiles = report.SlowestFiles[:timingReportLimit]
+	}
+
+	if cfg.Timings != nil {
+		report.DurationRegressions = FileDurationRegressions(cfg.Timings, testResults, cfg.durationRegressionThreshold())
+	}
+
+	return report
+}
+
+// FileDurationRegressions returns the test files that took more than `threshold` percent longer than their stored
+// `timings`, with the largest increase first. Files are timed the same way as when their timings are stored, i.e. by
+// summing up the final attempts of their tests. Files without a stored timing are ignored.
+func FileDurationRegressions(
+	timings map[string]time.Duration,
+	testResults v1.TestResults,
+	threshold float64,
+) []FileDurationRegression {
+	durationsByFile := make(map[string]time.Duration)
+
+	for _, test := range testResults.Tests {
+		duration, ok := durationOf(test)
+		if !ok || test.Location == nil {
+			continue
+		}
+
+		durationsByFile[test.Location.File] += duration
+	}
+
+	regressions := make([]FileDurationRegression, 0)
+
+	for file, after := range durationsByFile {
+		before, ok := timings[file]
+		if !ok || !isDurationRegression(before, after, threshold) {
+			continue
+		}
+
+		regressions = append(regressions, FileDurationRegression{File: file, Before: before, After: after})
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		a, b := regressions[i], regressions[j]
+		return a.After-a.Before > b.After-b.Before || (a.After-a.Before == b.After-b.Before && a.File < b.File)
+	})
+
+	return regressions
+}
+
+func timingReportFileOf(test v1.Test) string {
+	if test.Location == nil {
+		return ""
+	}
+
+	return test.Location.File
+}
+
+// timingReportMarkdownData is what the markdown template of timing reports is rendered against
+type timingReportMarkdownData struct {
+	TimingReport
+	SuiteID   string
+	Threshold float64
+}
+
+func timingReportMarkdown(report TimingReport, cfg Configuration) (string, error) {
+	parsedTemplate, err := template.New("timingReportMarkdownTemplate").
+		Funcs(template.FuncMap{"duration": timingReportFormatDuration, "escape": timingReportEscape}).
+		Parse(timingReportMarkdownTemplate)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	markdown := new(strings.Builder)
+	if err := parsedTemplate.Execute(markdown, timingReportMarkdownData{
+		TimingReport: report,
+		SuiteID:      cfg.SuiteID,
+		Threshold:    cfg.durationRegressionThreshold(),
+	}); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return markdown.String(), nil
+}
+
+func timingReportFormatDuration(duration time.Duration) string {
+	return duration.Round(time.Millisecond).String()
+}
+
+func timingReportEscape(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
@@ -0,0 +1,149 @@
+package reporting_test
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/rwx-research/captain-cli/internal/mocks"
+	"github.com/rwx-research/captain-cli/internal/reporting"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timing Report", func() {
+	var (
+		mockFile    *mocks.File
+		testResults v1.TestResults
+		cfg         reporting.Configuration
+	)
+
+	test := func(name, file string, durations ...time.Duration) v1.Test {
+		attempts := make([]v1.TestAttempt, len(durations))
+		for i := range durations {
+			attempts[i] = v1.TestAttempt{Duration: &durations[i], Status: v1.NewSuccessfulTestStatus()}
+		}
+
+		return v1.Test{
+			Name:         name,
+			Location:     &v1.Location{File: file},
+			Attempt:      attempts[len(attempts)-1],
+			PastAttempts: attempts[:len(attempts)-1],
+		}
+	}
+
+	BeforeEach(func() {
+		mockFile = new(mocks.File)
+		mockFile.Builder = new(strings.Builder)
+
+		testResults = *v1.NewTestResults(
+			v1.RubyRSpecFramework,
+			[]v1.Test{
+				test("is fast", "spec/models/user_spec.rb", time.Second),
+				test("is slow", "spec/models/user_spec.rb", 3*time.Second),
+				test("is retried", "spec/features/login_spec.rb", time.Second, 2*time.Second),
+				test("is slower than before", "spec/features/signup_spec.rb", 3*time.Second),
+			},
+			nil,
+		)
+
+		cfg = reporting.Configuration{
+			SuiteID: "some-suite-id",
+			Timings: map[string]time.Duration{
+				"spec/models/user_spec.rb":     4 * time.Second,
+				"spec/features/login_spec.rb":  1500 * time.Millisecond,
+				"spec/features/signup_spec.rb": time.Second,
+			},
+		}
+	})
+
+	Describe("NewTimingReport", func() {
+		It("lists the slowest tests & files by their final attempt", func() {
+			report := reporting.NewTimingReport(testResults, cfg)
+
+			Expect(report.TotalDuration).To(Equal(9 * time.Second))
+			Expect(report.SlowestTests).To(HaveLen(4))
+			Expect(report.SlowestTests[0].Name).To(Equal("is slow"))
+			Expect(report.SlowestTests[1].Name).To(Equal("is slower than before"))
+			Expect(report.SlowestTests[2].Name).To(Equal("is retried"))
+			Expect(report.SlowestTests[2].Duration).To(Equal(2 * time.Second))
+
+			Expect(report.SlowestFiles).To(Equal([]reporting.TimingReportFile{
+				{File: "spec/models/user_spec.rb", Tests: 2, Duration: 4 * time.Second},
+				{File: "spec/features/signup_spec.rb", Tests: 1, Duration: 3 * time.Second},
+				{File: "spec/features/login_spec.rb", Tests: 1, Duration: 2 * time.Second},
+			}))
+		})
+
+		It("calculates the share of time per directory", func() {
+			report := reporting.NewTimingReport(testResults, cfg)
+
+			Expect(report.Directories).To(HaveLen(2))
+			Expect(report.Directories[0].Directory).To(Equal("spec/features"))
+			Expect(report.Directories[0].Duration).To(Equal(5 * time.Second))
+			Expect(report.Directories[0].Share).To(BeNumerically("~", 55.6, 0.1))
+			Expect(report.Directories[1].Directory).To(Equal("spec/models"))
+		})
+
+		It("lists test files that got slower than their stored timings, regardless of retries", func() {
+			report := reporting.NewTimingReport(testResults, cfg)
+
+			Expect(report.TimingsAvailable).To(BeTrue())
+			Expect(report.DurationRegressions).To(Equal([]reporting.FileDurationRegression{
+				{File: "spec/features/signup_spec.rb", Before: time.Second, After: 3 * time.Second},
+			}))
+		})
+
+		It("ignores files without a stored timing", func() {
+			delete(cfg.Timings, "spec/features/signup_spec.rb")
+
+			Expect(reporting.NewTimingReport(testResults, cfg).DurationRegressions).To(BeEmpty())
+		})
+
+		It("respects the configured threshold", func() {
+			cfg.DurationRegressionThreshold = 300
+
+			Expect(reporting.NewTimingReport(testResults, cfg).DurationRegressions).To(BeEmpty())
+		})
+	})
+
+	Describe("WriteTimingReport", func() {
+		It("writes markdown by default", func() {
+			Expect(reporting.WriteTimingReport(mockFile, testResults, cfg)).To(Succeed())
+
+			markdown := mockFile.Builder.String()
+			Expect(markdown).To(HavePrefix("# `some-suite-id` Timing Report\n\nTotal test time: 9s\n"))
+			Expect(markdown).To(ContainSubstring("| is slow | spec/models/user_spec.rb | 3s |\n"))
+			Expect(markdown).To(ContainSubstring("| spec/models/user_spec.rb | 2 | 4s |\n"))
+			Expect(markdown).To(ContainSubstring("| spec/features | 5s | 55.6% |\n"))
+			Expect(markdown).To(ContainSubstring(
+				"## Duration Regressions\n\n| File | Before | After |\n| --- | --- | --- |\n" +
+					"| spec/features/signup_spec.rb | 1s | 3s |\n",
+			))
+		})
+
+		It("mentions missing timings", func() {
+			cfg.Timings = nil
+
+			Expect(reporting.WriteTimingReport(mockFile, testResults, cfg)).To(Succeed())
+			Expect(mockFile.Builder.String()).To(HaveSuffix(
+				"## Duration Regressions\n\nNo stored timings to compare against were found.\n",
+			))
+		})
+
+		It("writes JSON if the output path ends in .json", func() {
+			mockFile.MockName = func() string { return "tmp/timings.json" }
+
+			Expect(reporting.WriteTimingReport(mockFile, testResults, cfg)).To(Succeed())
+
+			var report map[string]any
+			Expect(json.Unmarshal([]byte(mockFile.Builder.String()), &report)).To(Succeed())
+			Expect(report["totalDurationInNanoseconds"]).To(Equal(float64(9 * time.Second)))
+			Expect(report["slowestTests"]).To(HaveLen(4))
+			Expect(report["durationRegressions"]).To(HaveLen(1))
+			Expect(report["timingsAvailable"]).To(BeTrue())
+		})
+	})
+})
@@ -1,16 +1,49 @@
 package runpartition
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/rwx-research/captain-cli/internal/config"
 	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
 	"github.com/rwx-research/captain-cli/internal/templating"
 )
 
+const (
+	keywordTestFiles      = "testFiles"
+	keywordTestFilesPath  = "testFilesPath"
+	keywordTestFilesJSON  = "testFilesJSON"
+	keywordPartitionIndex = "partitionIndex"
+	keywordPartitionTotal = "partitionTotal"
+)
+
+// testFileKeywords are the keywords that expose the test files of a partition. Every partition template needs to
+// contain at least one of them.
+var testFileKeywords = []string{keywordTestFiles, keywordTestFilesPath, keywordTestFilesJSON}
+
+var supportedKeywords = map[string]struct{}{
+	keywordTestFiles:      {},
+	keywordTestFilesPath:  {},
+	keywordTestFilesJSON:  {},
+	keywordPartitionIndex: {},
+	keywordPartitionTotal: {},
+}
+
+// DelimiterSubstitution substitutes the test files of a partition into a command template. The following keywords are
+// supported:
+//
+//   - testFiles: the shell-escaped test files, joined by `Delimiter`
+//   - testFilesPath: the shell-escaped path to a temporary file that lists one test file per line
+//   - testFilesJSON: the shell-escaped JSON array of test files
+//   - partitionIndex & partitionTotal: the index of the partition and the total number of partitions
 type DelimiterSubstitution struct {
-	Delimiter string
+	Delimiter      string
+	PartitionNodes config.PartitionNodes
+	FileSystem     fs.FileSystem
 }
 
 func (s DelimiterSubstitution) Example() string {
@@ -19,33 +52,105 @@ func (s DelimiterSubstitution) Example() string {
 
 func (s DelimiterSubstitution) ValidateTemplate(compiledTemplate templating.CompiledTemplate) error {
 	keywords := compiledTemplate.Keywords()
-	message := "Partitioning with a delimiter requires a template with only the 'testFiles' keyword"
+	message := "Partitioning requires a template with the 'testFiles', 'testFilesPath', or 'testFilesJSON' keyword"
 
 	if len(keywords) == 0 {
 		return errors.NewInputError("%v; no keywords were found", message)
 	}
 
-	if len(keywords) > 1 {
-		sort.Strings(keywords)
-		return errors.NewInputError("%v; these were found: %v", message, strings.Join(keywords, ", "))
+	unsupportedKeywords := make([]string, 0)
+	for _, keyword := range keywords {
+		if _, ok := supportedKeywords[keyword]; !ok {
+			unsupportedKeywords = append(unsupportedKeywords, keyword)
+		}
+	}
+
+	if len(unsupportedKeywords) > 0 {
+		sort.Strings(unsupportedKeywords)
+		return errors.NewInputError(
+			"Partition templates only support the 'testFiles', 'testFilesPath', 'testFilesJSON', 'partitionIndex', and "+
+				"'partitionTotal' keywords; these unsupported keywords were found: %v",
+			strings.Join(unsupportedKeywords, ", "),
+		)
 	}
 
-	if keywords[0] != "testFiles" {
-		return errors.NewInputError("%v; '%v' was found instead", message, keywords[0])
+	for _, keyword := range testFileKeywords {
+		if compiledTemplate.HasKeyword(keyword) {
+			return nil
+		}
 	}
 
-	return nil
+	sort.Strings(keywords)
+	return errors.NewInputError("%v; only these were found: %v", message, strings.Join(keywords, ", "))
 }
 
 func (s DelimiterSubstitution) SubstitutionLookupFor(
-	_ templating.CompiledTemplate,
+	compiledTemplate templating.CompiledTemplate,
 	testFilePaths []string,
-) (map[string]string, error) {
-	escapedTestFilePaths := make([]string, 0)
+) (map[string]string, func() error, error) {
+	lookup := make(map[string]string)
+	cleanUp := func() error { return nil }
+
+	if compiledTemplate.HasKeyword(keywordTestFiles) {
+		escapedTestFilePaths := make([]string, 0)
+
+		for _, testFilePath := range testFilePaths {
+			escapedTestFilePaths = append(escapedTestFilePaths, fmt.Sprintf("'%v'", templating.ShellEscape(testFilePath)))
+		}
+
+		lookup[keywordTestFiles] = strings.Join(escapedTestFilePaths, s.Delimiter)
+	}
+
+	if compiledTemplate.HasKeyword(keywordTestFilesJSON) {
+		encodedTestFilePaths, err := json.Marshal(testFilePaths)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+
+		lookup[keywordTestFilesJSON] = fmt.Sprintf("'%v'", templating.ShellEscape(string(encodedTestFilePaths)))
+	}
+
+	if compiledTemplate.HasKeyword(keywordPartitionIndex) {
+		lookup[keywordPartitionIndex] = strconv.Itoa(s.PartitionNodes.Index)
+	}
+
+	if compiledTemplate.HasKeyword(keywordPartitionTotal) {
+		lookup[keywordPartitionTotal] = strconv.Itoa(s.PartitionNodes.Total)
+	}
+
+	// The temporary file is written last so it doesn't linger around if any of the above fails
+	if compiledTemplate.HasKeyword(keywordTestFilesPath) {
+		filePath, err := s.writeTestFiles(testFilePaths)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lookup[keywordTestFilesPath] = fmt.Sprintf("'%v'", templating.ShellEscape(filePath))
+		cleanUp = func() error {
+			err := s.FileSystem.Remove(filePath)
+			return errors.Wrapf(err, "Unable to clean up %q", filePath)
+		}
+	}
+
+	return lookup, cleanUp, nil
+}
+
+// writeTestFiles writes the test files to a temporary file, one per line, and returns its path. The file is removed
+// again if it can't be written.
+func (s DelimiterSubstitution) writeTestFiles(testFilePaths []string) (string, error) {
+	file, err := s.FileSystem.CreateTemp("", "captain-partition-test-files")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer file.Close()
 
 	for _, testFilePath := range testFilePaths {
-		escapedTestFilePaths = append(escapedTestFilePaths, fmt.Sprintf("'%v'", templating.ShellEscape(testFilePath)))
+		if _, err := fmt.Fprintln(file, testFilePath); err != nil {
+			_ = file.Close()
+			_ = s.FileSystem.Remove(file.Name())
+			return "", errors.WithStack(err)
+		}
 	}
 
-	return map[string]string{"testFiles": strings.Join(escapedTestFilePaths, s.Delimiter)}, nil
+	return file.Name(), nil
 }
@@ -1,6 +1,12 @@
 package runpartition_test
 
 import (
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/config"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	"github.com/rwx-research/captain-cli/internal/fs"
+	"github.com/rwx-research/captain-cli/internal/mocks"
 	"github.com/rwx-research/captain-cli/internal/runpartition"
 	"github.com/rwx-research/captain-cli/internal/templating"
 
@@ -8,6 +14,15 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// failingFile is a file that can't be written to
+type failingFile struct {
+	*mocks.File
+}
+
+func (f failingFile) Write([]byte) (int, error) {
+	return 0, errors.NewSystemError("disk full")
+}
+
 var _ = Describe("DelimiterSubstitution", func() {
 	It("adheres to the Substitution interface", func() {
 		var substitution runpartition.Substitution = runpartition.DelimiterSubstitution{}
@@ -36,14 +51,14 @@ var _ = Describe("DelimiterSubstitution", func() {
 			Expect(err.Error()).To(ContainSubstring("no keywords were found"))
 		})
 
-		It("is invalid for a template without multiple placeholders", func() {
+		It("is invalid for a template with unsupported placeholders", func() {
 			substitution := runpartition.DelimiterSubstitution{}
-			compiledTemplate, compileErr := templating.CompileTemplate("some-command {{ a }} {{ b }}")
+			compiledTemplate, compileErr := templating.CompileTemplate("some-command {{ testFiles }} {{ b }} {{ a }}")
 			Expect(compileErr).NotTo(HaveOccurred())
 
 			err := substitution.ValidateTemplate(compiledTemplate)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("these were found: a, b"))
+			Expect(err.Error()).To(ContainSubstring("these unsupported keywords were found: a, b"))
 		})
 
 		It("is invalid for a template with one incorrect placeholders", func() {
@@ -53,7 +68,28 @@ var _ = Describe("DelimiterSubstitution", func() {
 
 			err := substitution.ValidateTemplate(compiledTemplate)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("'testFilesNope' was found instead"))
+			Expect(err.Error()).To(ContainSubstring("these unsupported keywords were found: testFilesNope"))
+		})
+
+		It("is invalid for a template without any test file placeholders", func() {
+			substitution := runpartition.DelimiterSubstitution{}
+			compiledTemplate, compileErr := templating.CompileTemplate("some-command {{ partitionTotal }} {{ partitionIndex }}")
+			Expect(compileErr).NotTo(HaveOccurred())
+
+			err := substitution.ValidateTemplate(compiledTemplate)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("only these were found: partitionIndex, partitionTotal"))
+		})
+
+		It("is valid when all supported placeholders are provided", func() {
+			substitution := runpartition.DelimiterSubstitution{}
+			compiledTemplate, compileErr := templating.CompileTemplate(
+				"some-command {{ testFiles }} {{ testFilesPath }} {{ testFilesJSON }} {{ partitionIndex }} {{ partitionTotal }}",
+			)
+			Expect(compileErr).NotTo(HaveOccurred())
+
+			err := substitution.ValidateTemplate(compiledTemplate)
+			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("is valid exactly testFiles is provided", func() {
@@ -71,7 +107,7 @@ var _ = Describe("DelimiterSubstitution", func() {
 			It("returns testFiles as an empty string", func() {
 				substitution := runpartition.DelimiterSubstitution{}
 				compiledTemplate, _ := templating.CompileTemplate("some-command {{ testFiles }}")
-				lookup, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{})
+				lookup, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{})
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(lookup["testFiles"]).To(Equal(""))
@@ -82,7 +118,7 @@ var _ = Describe("DelimiterSubstitution", func() {
 			It("returns testFiles separated with delimiter", func() {
 				substitution := runpartition.DelimiterSubstitution{Delimiter: " || "}
 				compiledTemplate, _ := templating.CompileTemplate("some-command {{ testFiles }}")
-				lookup, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a", "b", "c"})
+				lookup, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a", "b", "c"})
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(lookup["testFiles"]).To(Equal("'a' || 'b' || 'c'"))
@@ -90,11 +126,117 @@ var _ = Describe("DelimiterSubstitution", func() {
 			})
 		})
 
+		Context("when the partition is requested", func() {
+			It("returns the partition index and total", func() {
+				substitution := runpartition.DelimiterSubstitution{PartitionNodes: config.PartitionNodes{Index: 2, Total: 5}}
+				compiledTemplate, _ := templating.CompileTemplate(
+					"some-command --shard {{ partitionIndex }}/{{ partitionTotal }} {{ testFiles }}",
+				)
+				lookup, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lookup).To(Equal(map[string]string{
+					"partitionIndex": "2",
+					"partitionTotal": "5",
+					"testFiles":      "'a'",
+				}))
+			})
+		})
+
+		Context("when the test files are requested as JSON", func() {
+			It("returns the escaped JSON array", func() {
+				substitution := runpartition.DelimiterSubstitution{}
+				compiledTemplate, _ := templating.CompileTemplate("some-command {{ testFilesJSON }}")
+				lookup, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a spec", "it's"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lookup).To(Equal(map[string]string{"testFilesJSON": `'["a spec","it'"'"'s"]'`}))
+			})
+		})
+
+		Context("when the test files are requested as a file", func() {
+			var (
+				mockFileSystem *mocks.FileSystem
+				mockFile       *mocks.File
+				removedFiles   []string
+				substitution   runpartition.DelimiterSubstitution
+			)
+
+			BeforeEach(func() {
+				removedFiles = []string{}
+				mockFile = &mocks.File{
+					Builder:  new(strings.Builder),
+					MockName: func() string { return "/tmp/captain-partition-test-files123" },
+				}
+				mockFileSystem = new(mocks.FileSystem)
+				mockFileSystem.MockCreateTemp = func(dir, pattern string) (fs.File, error) {
+					return mockFile, nil
+				}
+				mockFileSystem.MockRemove = func(name string) error {
+					removedFiles = append(removedFiles, name)
+					return nil
+				}
+				substitution = runpartition.DelimiterSubstitution{FileSystem: mockFileSystem}
+			})
+
+			It("writes one test file per line and returns the path", func() {
+				compiledTemplate, _ := templating.CompileTemplate("some-command --list {{ testFilesPath }}")
+				lookup, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a spec", "b"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lookup).To(Equal(map[string]string{"testFilesPath": "'/tmp/captain-partition-test-files123'"}))
+				Expect(mockFile.Builder.String()).To(Equal("a spec\nb\n"))
+			})
+
+			It("removes the file on clean up", func() {
+				compiledTemplate, _ := templating.CompileTemplate("some-command --list {{ testFilesPath }}")
+				_, cleanUp, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cleanUp()).To(Succeed())
+				Expect(removedFiles).To(Equal([]string{"/tmp/captain-partition-test-files123"}))
+			})
+
+			It("escapes a path that contains spaces or quotes", func() {
+				mockFile.MockName = func() string { return "/tmp/my 'temp' dir/captain-partition-test-files123" }
+
+				compiledTemplate, _ := templating.CompileTemplate("some-command --list {{ testFilesPath }}")
+				lookup, cleanUp, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lookup["testFilesPath"]).To(Equal(`'/tmp/my '"'"'temp'"'"' dir/captain-partition-test-files123'`))
+
+				Expect(cleanUp()).To(Succeed())
+				Expect(removedFiles).To(Equal([]string{"/tmp/my 'temp' dir/captain-partition-test-files123"}))
+			})
+
+			It("removes the file if it can't be written", func() {
+				mockFileSystem.MockCreateTemp = func(dir, pattern string) (fs.File, error) {
+					return failingFile{mockFile}, nil
+				}
+
+				compiledTemplate, _ := templating.CompileTemplate("some-command --list {{ testFilesPath }}")
+				_, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a"})
+
+				Expect(err).To(HaveOccurred())
+				Expect(removedFiles).To(Equal([]string{"/tmp/captain-partition-test-files123"}))
+			})
+
+			It("doesn't remove anything when no file was written", func() {
+				compiledTemplate, _ := templating.CompileTemplate("some-command {{ testFiles }}")
+				_, cleanUp, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cleanUp()).To(Succeed())
+				Expect(removedFiles).To(BeEmpty())
+			})
+		})
+
 		Context("when file includes a space", func() {
 			It("gets escaped", func() {
 				substitution := runpartition.DelimiterSubstitution{Delimiter: ","}
 				compiledTemplate, _ := templating.CompileTemplate("some-command {{ testFiles }}")
-				lookup, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a spec", "b spec"})
+				lookup, _, err := substitution.SubstitutionLookupFor(compiledTemplate, []string{"a spec", "b spec"})
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(lookup["testFiles"]).To(Equal("'a spec','b spec'"))
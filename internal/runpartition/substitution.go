@@ -7,8 +7,10 @@ import (
 type Substitution interface {
 	Example() string
 	ValidateTemplate(compiledTemplate templating.CompiledTemplate) error
+	// SubstitutionLookupFor returns the values of the keywords in the template, as well as a function that removes any
+	// temporary files that were created for them.
 	SubstitutionLookupFor(
 		_ templating.CompiledTemplate,
 		testFilePaths []string,
-	) (map[string]string, error)
+	) (map[string]string, func() error, error)
 }
@@ -0,0 +1,385 @@
+// Package server implements a self-hosted backend for Captain. It speaks the same API as Captain Cloud - as far as the
+// CLI uses it - but stores flakes, quarantines, timings, and test results in the local storage of the server. This
+// allows several CI nodes to share their storage by pointing `CAPTAIN_HOST` at the server.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/errors"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+)
+
+const (
+	runConfigurationPath = "/api/test_suites/run_configuration"
+	timingManifestPath   = "/api/test_suites/timing_manifest"
+	testResultsPath      = "/api/test_suites/bulk_test_results"
+	uploadsPath          = "/api/test_suites/uploads/"
+
+	// parserTypeRWX is the only format that the CLI uploads test results in
+	parserTypeRWX = "rwx"
+
+	// maxUploadSize limits the size of a single test results file
+	maxUploadSize = 512 << 20
+	// uploadTimeout is how long an upload URL stays valid after it was handed out
+	uploadTimeout = time.Hour
+	// shutdownTimeout is how long in-flight requests are given to finish when the server is stopped
+	shutdownTimeout = 30 * time.Second
+)
+
+var validSuiteIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Config is the configuration of the server.
+type Config struct {
+	Log *zap.SugaredLogger
+	// Storage opens the local storage of a test suite. It is called for every request, which means that changes to
+	// the storage (e.g. using `captain add quarantine`) are picked up right away. The client is closed afterwards.
+	Storage func(suiteID string) (local.Client, error)
+	// Token is the access token that clients need to present. Any token is accepted if it is empty.
+	Token   string
+	NewUUID func() (uuid.UUID, error)
+}
+
+// Server serves the Captain API on top of the local storage.
+type Server struct {
+	Config
+
+	mutex   sync.Mutex
+	uploads map[string]pendingUpload
+}
+
+type pendingUpload struct {
+	suiteID   string
+	expiresAt time.Time
+}
+
+// New is the preferred constructor for the server.
+func New(cfg Config) (*Server, error) {
+	if cfg.Log == nil {
+		return nil, errors.NewInternalError("missing logger")
+	}
+
+	if cfg.Storage == nil {
+		return nil, errors.NewInternalError("missing storage")
+	}
+
+	if cfg.NewUUID == nil {
+		cfg.NewUUID = uuid.NewRandom
+	}
+
+	return &Server{Config: cfg, uploads: make(map[string]pendingUpload)}, nil
+}
+
+// Handler returns the HTTP handler of the API. Every endpoint is available with a `/captain` prefix as well, which is
+// what the CLI uses if the host name contains "cloud".
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	for _, prefix := range []string{"", "/captain"} {
+		mux.HandleFunc(prefix+runConfigurationPath, s.authenticated(s.getRunConfiguration, http.MethodGet))
+		mux.HandleFunc(prefix+timingManifestPath, s.authenticated(s.getTimingManifest, http.MethodGet))
+		mux.HandleFunc(prefix+testResultsPath, s.authenticated(s.testResults, http.MethodPost, http.MethodPut))
+		mux.HandleFunc(prefix+uploadsPath, s.authenticated(s.uploadTestResults, http.MethodPut))
+	}
+
+	return mux
+}
+
+// ListenAndServe serves the API on `address` until `ctx` is cancelled. TLS is enabled if both `certFile` & `keyFile`
+// are set.
+func (s *Server) ListenAndServe(ctx context.Context, address, certFile, keyFile string) error {
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if certFile != "" && keyFile != "" {
+			errs <- httpServer.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			errs <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return errors.NewSystemError("unable to serve on %q: %s", address, err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return errors.NewSystemError("unable to shut down the server: %s", err)
+	}
+
+	return nil
+}
+
+// authenticated verifies the access token & request method before passing the request on to `handle`.
+func (s *Server) authenticated(handle http.HandlerFunc, methods ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.Log.Debugf("%s %s", r.Method, r.URL.Path)
+
+		if s.Token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+				s.writeError(w, http.StatusUnauthorized, "invalid access token")
+				return
+			}
+		}
+
+		for _, method := range methods {
+			if r.Method == method {
+				handle(w, r)
+				return
+			}
+		}
+
+		s.writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method %s", r.Method))
+	}
+}
+
+func (s *Server) getRunConfiguration(w http.ResponseWriter, r *http.Request) {
+	suiteID, ok := s.suiteID(w, r.URL.Query().Get("test_suite_identifier"))
+	if !ok {
+		return
+	}
+
+	s.withStorage(w, suiteID, func(storage local.Client) (any, error) {
+		runConfiguration, err := storage.GetRunConfiguration(r.Context(), suiteID)
+		return runConfiguration, errors.WithStack(err)
+	})
+}
+
+func (s *Server) getTimingManifest(w http.ResponseWriter, r *http.Request) {
+	suiteID, ok := s.suiteID(w, r.URL.Query().Get("test_suite_identifier"))
+	if !ok {
+		return
+	}
+
+	s.withStorage(w, suiteID, func(storage local.Client) (any, error) {
+		fileTimings, err := storage.GetTestTimingManifest(r.Context(), suiteID)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return map[string]any{"file_timings": fileTimings}, nil
+	})
+}
+
+// testResults registers new test results files (POST) or updates the status of their uploads (PUT).
+func (s *Server) testResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		s.updateTestResultsStatuses(w, r)
+		return
+	}
+
+	s.registerTestResults(w, r)
+}
+
+func (s *Server) registerTestResults(w http.ResponseWriter, r *http.Request) {
+	reqBody := struct {
+		TestSuiteIdentifier string `json:"test_suite_identifier"`
+		TestResultsFiles    []struct {
+			ExternalID string `json:"external_identifier"`
+			Format     string `json:"format"`
+		} `json:"test_results_files"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("unable to parse request body: %s", err))
+		return
+	}
+
+	suiteID, ok := s.suiteID(w, reqBody.TestSuiteIdentifier)
+	if !ok {
+		return
+	}
+
+	type testResultsUpload struct {
+		ExternalID string `json:"external_identifier"`
+		ID         string `json:"id"`
+		UploadURL  string `json:"upload_url"`
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	uploads := make([]testResultsUpload, 0, len(reqBody.TestResultsFiles))
+	for _, testResultsFile := range reqBody.TestResultsFiles {
+		if testResultsFile.Format != parserTypeRWX {
+			s.writeError(
+				w, http.StatusUnprocessableEntity, fmt.Sprintf("unsupported test results format %q", testResultsFile.Format),
+			)
+			return
+		}
+
+		id, err := s.NewUUID()
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, fmt.Sprintf("unable to generate upload ID: %s", err))
+			return
+		}
+
+		uploads = append(uploads, testResultsUpload{
+			ExternalID: testResultsFile.ExternalID,
+			ID:         id.String(),
+			UploadURL:  fmt.Sprintf("%s://%s%s%s", scheme, r.Host, uploadsPath, id),
+		})
+	}
+
+	s.mutex.Lock()
+	now := time.Now()
+	for id, upload := range s.uploads {
+		if now.After(upload.expiresAt) {
+			delete(s.uploads, id)
+		}
+	}
+	for _, upload := range uploads {
+		s.uploads[upload.ID] = pendingUpload{suiteID: suiteID, expiresAt: now.Add(uploadTimeout)}
+	}
+	s.mutex.Unlock()
+
+	s.writeJSON(w, http.StatusCreated, map[string]any{"test_results_uploads": uploads})
+}
+
+func (s *Server) uploadTestResults(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/captain")
+	id = strings.TrimPrefix(id, uploadsPath)
+
+	// Claiming the upload makes sure that its test results are stored at most once, even if they're sent repeatedly.
+	// It's only given back if storing the test results failed, so that they can be sent again.
+	s.mutex.Lock()
+	upload, ok := s.uploads[id]
+	delete(s.uploads, id)
+	s.mutex.Unlock()
+
+	if !ok || time.Now().After(upload.expiresAt) {
+		s.writeError(w, http.StatusNotFound, fmt.Sprintf("unknown upload %q", id))
+		return
+	}
+
+	release := func() {
+		s.mutex.Lock()
+		s.uploads[id] = upload
+		s.mutex.Unlock()
+	}
+
+	var testResults v1.TestResults
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadSize)).Decode(&testResults); err != nil {
+		release()
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("unable to parse test results: %s", err))
+		return
+	}
+
+	s.withStorage(w, upload.suiteID, func(storage local.Client) (any, error) {
+		if _, err := storage.UpdateTestResults(r.Context(), upload.suiteID, testResults); err != nil {
+			release()
+			return nil, errors.WithStack(err)
+		}
+
+		s.Log.Infof("Stored %d test results of suite %q", len(testResults.Tests), upload.suiteID)
+		return struct{}{}, nil
+	})
+}
+
+func (s *Server) updateTestResultsStatuses(w http.ResponseWriter, r *http.Request) {
+	reqBody := struct {
+		TestSuiteIdentifier string `json:"test_suite_identifier"`
+		TestResultsFiles    []struct {
+			ID     string `json:"id"`
+			Status string `json:"upload_status"`
+		} `json:"test_results_files"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("unable to parse request body: %s", err))
+		return
+	}
+
+	s.mutex.Lock()
+	for _, testResultsFile := range reqBody.TestResultsFiles {
+		// Uploads of other suites are left alone
+		if upload, ok := s.uploads[testResultsFile.ID]; ok && upload.suiteID != reqBody.TestSuiteIdentifier {
+			continue
+		}
+
+		delete(s.uploads, testResultsFile.ID)
+
+		if testResultsFile.Status != "uploaded" {
+			s.Log.Warnf("Test results of suite %q failed to upload", reqBody.TestSuiteIdentifier)
+		}
+	}
+	s.mutex.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// suiteID validates the suite ID of a request. Apart from being required, this makes sure that the suite ID can
+// safely be used as part of a file path.
+func (s *Server) suiteID(w http.ResponseWriter, suiteID string) (string, bool) {
+	if !validSuiteIDRegexp.MatchString(suiteID) {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid test suite identifier %q", suiteID))
+		return "", false
+	}
+
+	return suiteID, true
+}
+
+// withStorage opens the storage of the suite, passes it to `handle`, and writes the returned value as JSON.
+func (s *Server) withStorage(w http.ResponseWriter, suiteID string, handle func(local.Client) (any, error)) {
+	storage, err := s.Storage(suiteID)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, fmt.Sprintf("unable to open storage of %q: %s", suiteID, err))
+		return
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			s.Log.Warnf("Unable to close storage of %q: %s", suiteID, err)
+		}
+	}()
+
+	body, err := handle(storage)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.Log.Warnf("Unable to write response: %s", err)
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
+	if status >= http.StatusInternalServerError {
+		s.Log.Errorf("%s", message)
+	} else {
+		s.Log.Debugf("%s", message)
+	}
+
+	s.writeJSON(w, status, map[string]string{"error": message})
+}
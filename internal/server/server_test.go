@@ -0,0 +1,215 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rwx-research/captain-cli/internal/backend/local"
+	"github.com/rwx-research/captain-cli/internal/backend/remote"
+	"github.com/rwx-research/captain-cli/internal/server"
+	v1 "github.com/rwx-research/captain-cli/internal/testingschema/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	const (
+		suiteID = "suite-id"
+		token   = "secret-token"
+	)
+
+	var (
+		err          error
+		databasePath string
+		httpServer   *httptest.Server
+		client       remote.Client
+	)
+
+	openStorage := func(suiteID string) (local.Client, error) {
+		return local.NewSQLiteClient(zap.NewNop().Sugar(), databasePath, suiteID)
+	}
+
+	BeforeEach(func() {
+		databasePath = filepath.Join(GinkgoT().TempDir(), "captain.db")
+
+		storage, err := openStorage(suiteID)
+		Expect(err).NotTo(HaveOccurred())
+		storage.Quarantines = append(storage.Quarantines, local.Map{
+			Order:  []string{"description", "file"},
+			Values: map[string]string{"description": "quarantined test", "file": "./spec/quarantined_spec.rb"},
+		}.ToYAML())
+		Expect(storage.Flush()).To(Succeed())
+		Expect(storage.MergeTimings(map[string]time.Duration{"./spec/slow_spec.rb": time.Minute})).To(Succeed())
+		Expect(storage.Close()).To(Succeed())
+
+		s, err := server.New(server.Config{Log: zap.NewNop().Sugar(), Storage: openStorage, Token: token})
+		Expect(err).NotTo(HaveOccurred())
+
+		httpServer = httptest.NewServer(s.Handler())
+		DeferCleanup(httpServer.Close)
+
+		serverURL, err := url.Parse(httpServer.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client, err = remote.NewClient(remote.ClientConfig{
+			Host:     serverURL.Host,
+			Insecure: true,
+			Log:      zap.NewNop().Sugar(),
+			Token:    token,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("serves the run configuration", func() {
+		runConfiguration, err := client.GetRunConfiguration(context.Background(), suiteID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runConfiguration.QuarantinedTests).To(HaveLen(1))
+		Expect(runConfiguration.QuarantinedTests[0].CompositeIdentifier).To(Equal(
+			"quarantined test -captain- ./spec/quarantined_spec.rb",
+		))
+		Expect(runConfiguration.QuarantinedTests[0].IdentityComponents).To(Equal([]string{"description", "file"}))
+	})
+
+	It("serves the timing manifest", func() {
+		timings, err := client.GetTestTimingManifest(context.Background(), suiteID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timings).To(HaveLen(1))
+		Expect(timings[0].Filepath).To(Equal("./spec/slow_spec.rb"))
+		Expect(timings[0].Duration).To(Equal(time.Minute))
+	})
+
+	It("accepts uploaded test results", func() {
+		duration := 3 * time.Second
+		uploadResults, err := client.UpdateTestResults(context.Background(), suiteID, v1.TestResults{
+			Framework: v1.RubyRSpecFramework,
+			Tests: []v1.Test{{
+				Name:     "fast test",
+				Location: &v1.Location{File: "./spec/fast_spec.rb"},
+				Attempt:  v1.TestAttempt{Duration: &duration, Status: v1.NewSuccessfulTestStatus()},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploadResults).To(HaveLen(1))
+		Expect(uploadResults[0].Uploaded).To(BeTrue())
+
+		storage, err := openStorage(suiteID)
+		Expect(err).NotTo(HaveOccurred())
+		defer storage.Close()
+		Expect(storage.Timings).To(HaveKeyWithValue("./spec/fast_spec.rb", duration))
+
+		history, err := storage.History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+	})
+
+	Describe("uploading test results", func() {
+		request := func(method, url, body string) *http.Response {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			return resp
+		}
+
+		var (
+			upload struct {
+				ID        string `json:"id"`
+				UploadURL string `json:"upload_url"`
+			}
+			testResults string
+		)
+
+		BeforeEach(func() {
+			resp := request(http.MethodPost, httpServer.URL+"/api/test_suites/bulk_test_results", fmt.Sprintf(
+				`{"test_suite_identifier":%q,"test_results_files":[{"external_identifier":"id","format":"rwx"}]}`,
+				suiteID,
+			))
+			Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+			var respBody struct {
+				Uploads []json.RawMessage `json:"test_results_uploads"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&respBody)).To(Succeed())
+			Expect(respBody.Uploads).To(HaveLen(1))
+			Expect(json.Unmarshal(respBody.Uploads[0], &upload)).To(Succeed())
+
+			encoded, err := json.Marshal(v1.NewTestResults(v1.RubyRSpecFramework, []v1.Test{}, nil))
+			Expect(err).NotTo(HaveOccurred())
+			testResults = string(encoded)
+		})
+
+		It("stores the test results of an upload only once", func() {
+			Expect(request(http.MethodPut, upload.UploadURL, testResults).StatusCode).To(Equal(http.StatusOK))
+			Expect(request(http.MethodPut, upload.UploadURL, testResults).StatusCode).To(Equal(http.StatusNotFound))
+
+			storage, err := openStorage(suiteID)
+			Expect(err).NotTo(HaveOccurred())
+			defer storage.Close()
+
+			history, err := storage.History()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+		})
+
+		It("accepts the test results again if they couldn't be parsed", func() {
+			Expect(request(http.MethodPut, upload.UploadURL, "{").StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(request(http.MethodPut, upload.UploadURL, testResults).StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("doesn't cancel the uploads of other suites", func() {
+			resp := request(http.MethodPut, httpServer.URL+"/api/test_suites/bulk_test_results", fmt.Sprintf(
+				`{"test_suite_identifier":"other-suite","test_results_files":[{"id":%q,"upload_status":"uploaded"}]}`,
+				upload.ID,
+			))
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(request(http.MethodPut, upload.UploadURL, testResults).StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	It("rejects requests with an invalid access token", func() {
+		client, err = remote.NewClient(remote.ClientConfig{
+			Host:     client.Host,
+			Insecure: true,
+			Log:      zap.NewNop().Sugar(),
+			Token:    "wrong-token",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.GetRunConfiguration(context.Background(), suiteID)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Status Code 401"))
+	})
+
+	It("rejects invalid suite IDs", func() {
+		_, err = client.GetRunConfiguration(context.Background(), "../suite")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Status Code 400"))
+	})
+
+	It("rejects uploads that weren't registered", func() {
+		req, err := http.NewRequest(
+			http.MethodPut, httpServer.URL+"/api/test_suites/uploads/unknown", strings.NewReader("{}"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
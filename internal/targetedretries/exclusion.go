@@ -0,0 +1,83 @@
+package targetedretries
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rwx-research/captain-cli/internal/templating"
+)
+
+// ExcludedTest is a test that should not be run at all, e.g. because it is quarantined.
+type ExcludedTest struct {
+	// Identity holds the exact values of the identity components of the test, keyed by component (e.g. "description",
+	// "file", or "id"). Tests that are identified by patterns can't be excluded.
+	Identity map[string]string
+}
+
+// isIdentifiedBy returns whether the identity of the test consists of exactly the given components.
+func (t ExcludedTest) isIdentifiedBy(components ...string) bool {
+	if len(t.Identity) != len(components) {
+		return false
+	}
+
+	for _, component := range components {
+		if _, ok := t.Identity[component]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Exclusion is implemented by the substitutions of frameworks that support excluding tests from a run on the command
+// line.
+type Exclusion interface {
+	// CanExclude returns whether the framework is able to exclude the test, and only the test, by its identity.
+	CanExclude(test ExcludedTest) bool
+	// ExclusionArgs returns shell-escaped command-line arguments that prevent `tests` from running. Tests that can't
+	// be excluded (see `CanExclude`) are ignored. An empty string is returned if no test can be excluded.
+	ExclusionArgs(tests []ExcludedTest) string
+}
+
+// descriptionAlternation joins the escaped descriptions of `tests` into a single, unanchored regular expression that
+// is portable to the regex flavours of Go, JavaScript, and Ruby. Tests are ignored if `canExclude` returns false.
+func descriptionAlternation(tests []ExcludedTest, canExclude func(test ExcludedTest) bool) string {
+	patterns := make([]string, 0, len(tests))
+	seen := make(map[string]struct{}, len(tests))
+
+	for _, test := range tests {
+		if !canExclude(test) {
+			continue
+		}
+
+		pattern := templating.RegexpEscape(test.Identity["description"])
+		if _, ok := seen[pattern]; ok {
+			continue
+		}
+
+		seen[pattern] = struct{}{}
+		patterns = append(patterns, pattern)
+	}
+
+	if len(patterns) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(?:%s)", strings.Join(patterns, "|"))
+}
+
+// isIdentifiedByDescription is the `CanExclude` of frameworks that filter tests by their description only. These
+// can't tell tests with the same description in different files apart, so tests that are identified by more than
+// their description can't be excluded.
+func isIdentifiedByDescription(test ExcludedTest) bool {
+	return test.isIdentifiedBy("description") && test.Identity["description"] != ""
+}
+
+// regexExclusionArgs formats the common case of a flag that takes a single regular expression.
+func regexExclusionArgs(flag string, expression string) string {
+	if expression == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s '%s'", flag, templating.ShellEscape(expression))
+}
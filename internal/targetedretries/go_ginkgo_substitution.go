@@ -61,3 +61,18 @@ func (s GoGinkgoSubstitution) SubstitutionsFor(
 
 	return []map[string]string{}, nil
 }
+
+// CanExclude accepts specs that are identified by their full text only, as `--skip` can't be scoped to a file.
+func (s GoGinkgoSubstitution) CanExclude(test ExcludedTest) bool {
+	return isIdentifiedByDescription(test)
+}
+
+// ExclusionArgs skips specs using `--skip`, which is matched against the full text of a spec.
+func (s GoGinkgoSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	alternation := descriptionAlternation(tests, s.CanExclude)
+	if alternation == "" {
+		return ""
+	}
+
+	return regexExclusionArgs("--skip", fmt.Sprintf("^%s$", alternation))
+}
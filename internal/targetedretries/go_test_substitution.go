@@ -93,3 +93,19 @@ func (s GoTestSubstitution) SubstitutionsFor(
 
 	return substitutions, nil
 }
+
+// CanExclude accepts top-level tests that are identified by their name only. `-skip` applies to every package of a
+// run, and subtests can't be excluded as `go test` matches every level of a test name separately.
+func (s GoTestSubstitution) CanExclude(test ExcludedTest) bool {
+	return isIdentifiedByDescription(test) && !strings.Contains(test.Identity["description"], "/")
+}
+
+// ExclusionArgs skips top-level tests using `-skip`.
+func (s GoTestSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	alternation := descriptionAlternation(tests, s.CanExclude)
+	if alternation == "" {
+		return ""
+	}
+
+	return regexExclusionArgs("-skip", fmt.Sprintf("^%s$", alternation))
+}
@@ -354,4 +354,29 @@ var _ = Describe("GoTestSubstitution", func() {
 			))
 		})
 	})
+
+	Describe("ExclusionArgs", func() {
+		It("skips top-level tests", func() {
+			substitution := targetedretries.GoTestSubstitution{}
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{
+				{Identity: map[string]string{"description": "TestFoo"}},
+				{Identity: map[string]string{"description": "TestBar.*"}},
+				{Identity: map[string]string{"description": "TestFoo"}},
+			})).To(Equal(`-skip '^(?:TestFoo|TestBar\.\*)$'`))
+		})
+
+		It("ignores subtests and tests that are identified by more than their name", func() {
+			substitution := targetedretries.GoTestSubstitution{}
+			tests := []targetedretries.ExcludedTest{
+				{Identity: map[string]string{"description": "TestFoo/subtest"}},
+				{Identity: map[string]string{"description": "TestFoo", "package": "some/package"}},
+				{Identity: map[string]string{"id": "some-id"}},
+			}
+
+			for _, test := range tests {
+				Expect(substitution.CanExclude(test)).To(BeFalse())
+			}
+			Expect(substitution.ExclusionArgs(tests)).To(Equal(""))
+		})
+	})
 })
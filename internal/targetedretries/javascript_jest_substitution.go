@@ -91,3 +91,19 @@ func (s JavaScriptJestSubstitution) SubstitutionsFor(
 
 	return substitutions, nil
 }
+
+// CanExclude accepts tests that are identified by their full name only, as `--testNamePattern` can't be scoped to a
+// file.
+func (s JavaScriptJestSubstitution) CanExclude(test ExcludedTest) bool {
+	return isIdentifiedByDescription(test)
+}
+
+// ExclusionArgs negates the descriptions of `tests` using a lookahead in `--testNamePattern`.
+func (s JavaScriptJestSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	alternation := descriptionAlternation(tests, s.CanExclude)
+	if alternation == "" {
+		return ""
+	}
+
+	return regexExclusionArgs("--testNamePattern", fmt.Sprintf("^(?!%s$)", alternation))
+}
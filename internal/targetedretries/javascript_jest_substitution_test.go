@@ -258,4 +258,29 @@ var _ = Describe("JavaScriptJestSubstitution", func() {
 			))
 		})
 	})
+
+	Describe("ExclusionArgs", func() {
+		It("negates the escaped descriptions", func() {
+			substitution := targetedretries.JavaScriptJestSubstitution{}
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{
+				{Identity: map[string]string{"description": `it's a test`}},
+				{Identity: map[string]string{"description": `other (test)`}},
+			})).To(Equal(`--testNamePattern '^(?!(?:it'"'"'s a test|other \(test\))$)'`))
+		})
+
+		It("ignores tests that are identified by more than their description", func() {
+			substitution := targetedretries.JavaScriptJestSubstitution{}
+			test := targetedretries.ExcludedTest{
+				Identity: map[string]string{"description": "a test", "file": "a.test.js"},
+			}
+
+			Expect(substitution.CanExclude(test)).To(BeFalse())
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{test})).To(Equal(""))
+		})
+
+		It("returns nothing without tests", func() {
+			substitution := targetedretries.JavaScriptJestSubstitution{}
+			Expect(substitution.ExclusionArgs(nil)).To(Equal(""))
+		})
+	})
 })
@@ -88,3 +88,18 @@ func (s JavaScriptMochaSubstitution) SubstitutionsFor(
 
 	return substitutions, nil
 }
+
+// CanExclude accepts tests that are identified by their full title only, as `--grep` can't be scoped to a file.
+func (s JavaScriptMochaSubstitution) CanExclude(test ExcludedTest) bool {
+	return isIdentifiedByDescription(test)
+}
+
+// ExclusionArgs inverts `--grep`, which is matched against the full title of a test.
+func (s JavaScriptMochaSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	alternation := descriptionAlternation(tests, s.CanExclude)
+	if alternation == "" {
+		return ""
+	}
+
+	return regexExclusionArgs("--grep", fmt.Sprintf("^%s$", alternation)) + " --invert"
+}
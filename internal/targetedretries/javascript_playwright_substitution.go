@@ -1,6 +1,7 @@
 package targetedretries
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -100,3 +101,51 @@ func (s JavaScriptPlaywrightSubstitution) SubstitutionsFor(
 
 	return substitutions, nil
 }
+
+// CanExclude accepts tests that are identified by their file & description, and optionally their project. Playwright
+// matches `--grep-invert` against the full title of a test, which includes all of these.
+func (s JavaScriptPlaywrightSubstitution) CanExclude(test ExcludedTest) bool {
+	if test.Identity["description"] == "" || test.Identity["file"] == "" {
+		return false
+	}
+
+	return test.isIdentifiedBy("description", "file") || test.isIdentifiedBy("description", "file", "project")
+}
+
+// ExclusionArgs skips tests using `--grep-invert`. The full title that Playwright matches this against consists of the
+// (possibly empty) project, the file, the description, and the tags of a test, separated by spaces. Tests without a
+// project in their identity are excluded from every project.
+func (s JavaScriptPlaywrightSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	patterns := make([]string, 0, len(tests))
+	seen := make(map[string]struct{}, len(tests))
+
+	for _, test := range tests {
+		if !s.CanExclude(test) {
+			continue
+		}
+
+		project := `(?:.*\s)?`
+		if value, ok := test.Identity["project"]; ok {
+			project = templating.RegexpEscape(value) + `\s`
+		}
+
+		pattern := fmt.Sprintf(
+			"%s%s\\s%s",
+			project,
+			templating.RegexpEscape(test.Identity["file"]),
+			templating.RegexpEscape(test.Identity["description"]),
+		)
+		if _, ok := seen[pattern]; ok {
+			continue
+		}
+
+		seen[pattern] = struct{}{}
+		patterns = append(patterns, pattern)
+	}
+
+	if len(patterns) == 0 {
+		return ""
+	}
+
+	return regexExclusionArgs("--grep-invert", fmt.Sprintf(`^\s*(?:%s)(?:\s@\S+)*$`, strings.Join(patterns, "|")))
+}
@@ -305,4 +305,27 @@ var _ = Describe("JavaScriptPlaywrightSubstitution", func() {
 			))
 		})
 	})
+
+	Describe("ExclusionArgs", func() {
+		It("anchors the full title of the tests, including their project & file", func() {
+			substitution := targetedretries.JavaScriptPlaywrightSubstitution{}
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{
+				{Identity: map[string]string{"description": "it's (a) test", "file": "a.spec.ts", "project": "chromium"}},
+				{Identity: map[string]string{"description": "other test", "file": "b.spec.ts"}},
+			})).To(Equal(
+				`--grep-invert '^\s*(?:chromium\sa\.spec\.ts\sit'"'"'s \(a\) test|(?:.*\s)?b\.spec\.ts\sother test)` +
+					`(?:\s@\S+)*$'`,
+			))
+		})
+
+		It("ignores tests that aren't identified by their file & description", func() {
+			substitution := targetedretries.JavaScriptPlaywrightSubstitution{}
+			test := targetedretries.ExcludedTest{
+				Identity: map[string]string{"description": "a test", "project": "chromium"},
+			}
+
+			Expect(substitution.CanExclude(test)).To(BeFalse())
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{test})).To(Equal(""))
+		})
+	})
 })
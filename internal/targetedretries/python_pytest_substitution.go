@@ -61,3 +61,36 @@ func (s PythonPytestSubstitution) SubstitutionsFor(
 
 	return []map[string]string{}, nil
 }
+
+// CanExclude accepts tests that are identified by their node ID, or by their file & description, which make up the
+// node ID.
+func (s PythonPytestSubstitution) CanExclude(test ExcludedTest) bool {
+	return s.nodeID(test) != ""
+}
+
+func (s PythonPytestSubstitution) nodeID(test ExcludedTest) string {
+	switch {
+	case test.isIdentifiedBy("id"):
+		return test.Identity["id"]
+	case test.isIdentifiedBy("description", "file"):
+		if test.Identity["description"] == "" || test.Identity["file"] == "" {
+			return ""
+		}
+		return fmt.Sprintf("%v::%v", test.Identity["file"], test.Identity["description"])
+	default:
+		return ""
+	}
+}
+
+// ExclusionArgs deselects tests by their node ID.
+func (s PythonPytestSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	args := make([]string, 0, len(tests))
+
+	for _, test := range tests {
+		if nodeID := s.nodeID(test); nodeID != "" {
+			args = append(args, fmt.Sprintf("--deselect '%v'", templating.ShellEscape(nodeID)))
+		}
+	}
+
+	return strings.Join(args, " ")
+}
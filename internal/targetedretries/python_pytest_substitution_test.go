@@ -171,4 +171,27 @@ var _ = Describe("PythonPytestSubstitution", func() {
 			))
 		})
 	})
+
+	Describe("ExclusionArgs", func() {
+		It("deselects tests by their node ID", func() {
+			substitution := targetedretries.PythonPytestSubstitution{}
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{
+				{Identity: map[string]string{"id": "tests/test_foo.py::test_bar[param]"}},
+				{Identity: map[string]string{"description": "test_baz"}},
+				{Identity: map[string]string{"description": "Test::test_qux", "file": "tests/test_foo.py"}},
+			})).To(Equal(
+				"--deselect 'tests/test_foo.py::test_bar[param]' --deselect 'tests/test_foo.py::Test::test_qux'",
+			))
+		})
+
+		It("ignores tests that are identified by something else than their node ID", func() {
+			substitution := targetedretries.PythonPytestSubstitution{}
+			test := targetedretries.ExcludedTest{
+				Identity: map[string]string{"id": "tests/test_foo.py::test_bar", "marker": "slow"},
+			}
+
+			Expect(substitution.CanExclude(test)).To(BeFalse())
+			Expect(substitution.ExclusionArgs([]targetedretries.ExcludedTest{test})).To(Equal(""))
+		})
+	})
 })
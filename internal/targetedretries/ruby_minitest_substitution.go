@@ -139,3 +139,18 @@ func (s RubyMinitestSubstitution) singleTestSubstitutions(
 
 	return substitutions, nil
 }
+
+// CanExclude accepts tests that are identified by their name only, as `--exclude` can't be scoped to a file.
+func (s RubyMinitestSubstitution) CanExclude(test ExcludedTest) bool {
+	return isIdentifiedByDescription(test)
+}
+
+// ExclusionArgs skips tests using `--exclude`, which takes a Ruby regular expression.
+func (s RubyMinitestSubstitution) ExclusionArgs(tests []ExcludedTest) string {
+	alternation := descriptionAlternation(tests, s.CanExclude)
+	if alternation == "" {
+		return ""
+	}
+
+	return regexExclusionArgs("--exclude", fmt.Sprintf("/^%s$/", alternation))
+}
@@ -60,6 +60,17 @@ func (ct CompiledTemplate) Keywords() []string {
 	return keywords
 }
 
+// HasKeyword returns whether the template contains a placeholder for the given keyword
+func (ct CompiledTemplate) HasKeyword(keyword string) bool {
+	for _, candidate := range ct.PlaceholderToKeyword {
+		if candidate == keyword {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (ct CompiledTemplate) Substitute(substitutionLookup map[string]string) string {
 	substituted := ct.Template
 	for placeholder, keyword := range ct.PlaceholderToKeyword {
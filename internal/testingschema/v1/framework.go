@@ -154,3 +154,20 @@ func (f Framework) String() string {
 
 	return fmt.Sprintf("%v (%v)", f.Kind, f.Language)
 }
+
+// IdentityComponents returns the components that are most likely to uniquely identify a test of this framework. These
+// are used whenever Captain derives the identity of a test on its own, e.g. when suggesting flakes.
+func (f Framework) IdentityComponents() []string {
+	switch f.Kind {
+	case FrameworkKindGoTest:
+		return []string{"description", "package"}
+	case FrameworkKindKarma:
+		return []string{"description", "browserName"}
+	case FrameworkKindPlaywright:
+		return []string{"description", "file", "project"}
+	case FrameworkKindxUnit:
+		return []string{"description", "type"}
+	default:
+		return []string{"description", "file"}
+	}
+}
@@ -95,4 +95,17 @@ var _ = Describe("Framework", func() {
 			)
 		})
 	})
+	Describe("IdentityComponents", func() {
+		It("uses the description and file by default", func() {
+			Expect(v1.RubyRSpecFramework.IdentityComponents()).To(Equal([]string{"description", "file"}))
+			Expect(v1.NewOtherFramework(nil, nil).IdentityComponents()).To(Equal([]string{"description", "file"}))
+		})
+
+		It("uses framework-specific meta data where necessary", func() {
+			Expect(v1.GoTestFramework.IdentityComponents()).To(Equal([]string{"description", "package"}))
+			Expect(v1.JavaScriptPlaywrightFramework.IdentityComponents()).To(
+				Equal([]string{"description", "file", "project"}),
+			)
+		})
+	})
 })
@@ -86,13 +86,23 @@ func (s TestStatus) PotentiallyFlaky() bool {
 }
 
 type TestAttempt struct {
-	Duration   *time.Duration `json:"durationInNanoseconds"`
-	Meta       map[string]any `json:"meta,omitempty"`
-	Status     TestStatus     `json:"status"`
-	Stderr     *string        `json:"stderr,omitempty"`
-	Stdout     *string        `json:"stdout,omitempty"`
-	StartedAt  *time.Time     `json:"startedAt,omitempty"`
-	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	Duration    *time.Duration `json:"durationInNanoseconds"`
+	Meta        map[string]any `json:"meta,omitempty"`
+	Status      TestStatus     `json:"status"`
+	Stderr      *string        `json:"stderr,omitempty"`
+	Stdout      *string        `json:"stdout,omitempty"`
+	StartedAt   *time.Time     `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time     `json:"finishedAt,omitempty"`
+	Attachments []Attachment   `json:"attachments,omitempty"`
+}
+
+// Attachment is a file that was produced by a test attempt, e.g. a screenshot, a video, or a trace.
+type Attachment struct {
+	Name        string  `json:"name"`
+	ContentType string  `json:"contentType"`
+	Path        *string `json:"path,omitempty"`
+	// Content is the base64 encoded content of attachments that are small enough to be inlined
+	Content *string `json:"content,omitempty"`
 }
 
 type Test struct {
@@ -140,6 +140,27 @@ var _ = Describe(versionedPrefixForQuarantining()+"OSS mode Integration Tests",
 			})
 		})
 
+		Context("with a config file", func() {
+			It("excludes the test files excluded in the config file", func() {
+				result := runCaptain(captainArgs{
+					args: []string{
+						"partition",
+						"oss-partition-with-exclude",
+						"--config-file", "fixtures/integration-tests/partition-config.yaml",
+						"fixtures/integration-tests/partition/x.rb",
+						"fixtures/integration-tests/partition/y.rb",
+						"fixtures/integration-tests/partition/z.rb",
+						"--index", "0",
+						"--total", "1",
+					},
+					env: make(map[string]string),
+				})
+
+				Expect(result.stdout).To(Equal("fixtures/integration-tests/partition/x.rb fixtures/integration-tests/partition/z.rb"))
+				Expect(result.exitCode).To(Equal(0))
+			})
+		})
+
 		Context("with timings", func() {
 			var suiteId string
 			BeforeEach(func() {